@@ -0,0 +1,663 @@
+package asn1c_go
+
+import (
+	"math/big"
+)
+
+type Node interface {
+	Pos() Position
+}
+
+type TagDefault int
+
+const (
+	ExplicitTags TagDefault = iota
+	ImplicitTags
+	AutomaticTags
+)
+
+func (t TagDefault) String() string {
+	switch t {
+	case ImplicitTags:
+		return Implicit + " " + Tags
+	case AutomaticTags:
+		return Automatic + " " + Tags
+	}
+	return Explicit + " " + Tags
+}
+
+type ModuleSet struct {
+	Modules []*ModuleDefinition
+}
+
+func (s *ModuleSet) Module(name string) *ModuleDefinition {
+	for _, module := range s.Modules {
+		if module.Name == name {
+			return module
+		}
+	}
+	return nil
+}
+
+type ModuleDefinition struct {
+	Position             Position               `json:"position"`
+	Name                 string                 `json:"name"`
+	Identifier           *ObjectIdentifierValue `json:"identifier"`
+	IRI                  string                 `json:"iri"`
+	TagDefault           TagDefault             `json:"tagDefault"`
+	ExtensibilityImplied bool                   `json:"extensibilityImplied"`
+	Exports              *ExportList            `json:"exports"`
+	Imports              []*Import              `json:"imports"`
+	Assignments          []Assignment           `json:"assignments"`
+}
+
+func (m *ModuleDefinition) Pos() Position { return m.Position }
+
+func (m *ModuleDefinition) Lookup(name string) Assignment {
+	for _, assignment := range m.Assignments {
+		if assignment.Reference() == name {
+			return assignment
+		}
+	}
+	return nil
+}
+
+// LookupImport returns the import that brings name into m, if any.
+func (m *ModuleDefinition) LookupImport(name string) *Import {
+	for _, imported := range m.Imports {
+		for _, symbol := range imported.Symbols {
+			if symbol.Name == name {
+				return imported
+			}
+		}
+	}
+	return nil
+}
+
+// ExportList is nil when the module has no EXPORTS clause, which exports
+// everything just like EXPORTS ALL.
+type ExportList struct {
+	Position Position  `json:"position"`
+	All      bool      `json:"all"`
+	Symbols  []*Symbol `json:"symbols"`
+}
+
+type Import struct {
+	Position   Position  `json:"position"`
+	Module     string    `json:"module"`
+	Identifier Value     `json:"identifier"`
+	Symbols    []*Symbol `json:"symbols"`
+}
+
+type Symbol struct {
+	Position      Position `json:"position"`
+	Name          string   `json:"name"`
+	Parameterized bool     `json:"parameterized"`
+}
+
+// Assignment is one of the assignments of a module. Each has a Doc field
+// holding the text of the comments right above it, and a Comment field
+// holding that of a comment following it on its last line.
+type Assignment interface {
+	Node
+	Reference() string
+}
+
+type TypeAssignment struct {
+	Position   Position     `json:"position"`
+	Name       string       `json:"name"`
+	Parameters []*Parameter `json:"parameters"`
+	Type       Type         `json:"type"`
+	Doc        string       `json:"doc"`
+	Comment    string       `json:"comment"`
+}
+
+type ValueAssignment struct {
+	Position Position `json:"position"`
+	Name     string   `json:"name"`
+	Type     Type     `json:"type"`
+	Value    Value    `json:"value"`
+	Doc      string   `json:"doc"`
+	Comment  string   `json:"comment"`
+}
+
+type ValueSetAssignment struct {
+	Position Position  `json:"position"`
+	Name     string    `json:"name"`
+	Type     Type      `json:"type"`
+	Set      *ValueSet `json:"set"`
+	Doc      string    `json:"doc"`
+	Comment  string    `json:"comment"`
+}
+
+type ObjectClassAssignment struct {
+	Position Position     `json:"position"`
+	Name     string       `json:"name"`
+	Class    *ObjectClass `json:"class"`
+	Doc      string       `json:"doc"`
+	Comment  string       `json:"comment"`
+}
+
+func (a *TypeAssignment) Pos() Position        { return a.Position }
+func (a *ValueAssignment) Pos() Position       { return a.Position }
+func (a *ValueSetAssignment) Pos() Position    { return a.Position }
+func (a *ObjectClassAssignment) Pos() Position { return a.Position }
+
+func (a *TypeAssignment) Reference() string        { return a.Name }
+func (a *ValueAssignment) Reference() string       { return a.Name }
+func (a *ValueSetAssignment) Reference() string    { return a.Name }
+func (a *ObjectClassAssignment) Reference() string { return a.Name }
+
+// Parameter is a dummy reference of a parameterized assignment. Governor is
+// set for a value or value set parameter and Class for an object or object
+// set parameter.
+type Parameter struct {
+	Position Position `json:"position"`
+	Name     string   `json:"name"`
+	Governor Type     `json:"governor"`
+	Class    string   `json:"class"`
+}
+
+func (p *Parameter) Pos() Position { return p.Position }
+
+type TagClass int
+
+const (
+	TagClassContext TagClass = iota
+	TagClassUniversal
+	TagClassApplication
+	TagClassPrivate
+)
+
+func (c TagClass) String() string {
+	switch c {
+	case TagClassUniversal:
+		return Universal
+	case TagClassApplication:
+		return Application
+	case TagClassPrivate:
+		return Private
+	}
+	return ""
+}
+
+type TagMode int
+
+const (
+	TagModeDefault TagMode = iota
+	TagModeImplicit
+	TagModeExplicit
+)
+
+func (m TagMode) String() string {
+	switch m {
+	case TagModeImplicit:
+		return Implicit
+	case TagModeExplicit:
+		return Explicit
+	}
+	return ""
+}
+
+type Tag struct {
+	Position Position `json:"position"`
+	Class    TagClass `json:"class"`
+	Number   Value    `json:"number"`
+	Mode     TagMode  `json:"mode"`
+}
+
+// TypeBase holds what every type notation may carry in addition to the type
+// itself: a tag prefix and any number of serially applied constraints.
+type TypeBase struct {
+	Position    Position      `json:"position"`
+	Tag         *Tag          `json:"tag"`
+	Constraints []*Constraint `json:"constraints"`
+}
+
+func (t *TypeBase) Pos() Position   { return t.Position }
+func (t *TypeBase) Base() *TypeBase { return t }
+
+type Type interface {
+	Node
+	Base() *TypeBase
+}
+
+const (
+	BitString        = Bit + " " + String
+	OctetString      = Octet + " " + String
+	ObjectIdentifier = Object + " " + Identifier
+	CharacterString  = Character + " " + String
+	EmbeddedPDV      = Embedded + " " + PDV
+)
+
+// BuiltinType is any builtin type without an inner structure, Name being its
+// keyword notation such as BOOLEAN, OCTET STRING or IA5String.
+type BuiltinType struct {
+	TypeBase `json:"base"`
+	Name     string `json:"name"`
+}
+
+type NamedNumber struct {
+	Position Position `json:"position"`
+	Name     string   `json:"name"`
+	Value    Value    `json:"value"`
+}
+
+type IntegerType struct {
+	TypeBase     `json:"base"`
+	NamedNumbers []*NamedNumber `json:"namedNumbers"`
+}
+
+// EnumeratedType is ENUMERATED. Exception is that of its extension marker.
+type EnumeratedType struct {
+	TypeBase   `json:"base"`
+	Items      []*NamedNumber `json:"items"`
+	Extensible bool           `json:"extensible"`
+	Exception  *Exception     `json:"exception"`
+	Additions  []*NamedNumber `json:"additions"`
+}
+
+type BitStringType struct {
+	TypeBase  `json:"base"`
+	NamedBits []*NamedNumber `json:"namedBits"`
+}
+
+// ComponentType is a component of a SEQUENCE or SET, or an alternative of a
+// CHOICE. Doc is the text of the comments right above it and Comment that of
+// a comment following it on its line. Tag is the tag automatic tagging gives
+// it, or else the tag written on its type with the mode resolved against the
+// module default, set by Check.
+type ComponentType struct {
+	Position     Position `json:"position"`
+	Name         string   `json:"name"`
+	Type         Type     `json:"type"`
+	Optional     bool     `json:"optional"`
+	Default      Value    `json:"default"`
+	ComponentsOf bool     `json:"componentsOf"`
+	Doc          string   `json:"doc"`
+	Comment      string   `json:"comment"`
+	Tag          *Tag     `json:"-"`
+}
+
+// ExtensionAddition is a single added component, or a version bracket
+// [[ ]] grouping several of them when Group is set.
+type ExtensionAddition struct {
+	Position   Position         `json:"position"`
+	Group      bool             `json:"group"`
+	Version    int              `json:"version"`
+	Components []*ComponentType `json:"components"`
+}
+
+// ComponentList is the body of SEQUENCE, SET and CHOICE. Components placed
+// after a second extension marker belong to the root and are held in
+// Trailing, as X.680 has them encoded right before the first marker.
+// Exception is the exception specification of the extension marker.
+// Canonical holds the root components of a SET or CHOICE in the canonical
+// order of their tags, set by Check.
+type ComponentList struct {
+	Components   []*ComponentType     `json:"components"`
+	Extensible   bool                 `json:"extensible"`
+	Exception    *Exception           `json:"exception"`
+	Additions    []*ExtensionAddition `json:"additions"`
+	ExtensionEnd bool                 `json:"extensionEnd"`
+	Trailing     []*ComponentType     `json:"trailing"`
+	Canonical    []*ComponentType     `json:"-"`
+}
+
+// RootComponents returns the root components in encoding order.
+func (l *ComponentList) RootComponents() []*ComponentType {
+	if len(l.Trailing) == 0 {
+		return l.Components
+	}
+	components := make([]*ComponentType, 0, len(l.Components)+len(l.Trailing))
+	components = append(components, l.Components...)
+	return append(components, l.Trailing...)
+}
+
+type SequenceType struct {
+	TypeBase      `json:"base"`
+	ComponentList `json:"list"`
+}
+
+type SetType struct {
+	TypeBase      `json:"base"`
+	ComponentList `json:"list"`
+}
+
+type ChoiceType struct {
+	TypeBase      `json:"base"`
+	ComponentList `json:"list"`
+}
+
+type SequenceOfType struct {
+	TypeBase    `json:"base"`
+	ElementName string `json:"elementName"`
+	Element     Type   `json:"element"`
+}
+
+type SetOfType struct {
+	TypeBase    `json:"base"`
+	ElementName string `json:"elementName"`
+	Element     Type   `json:"element"`
+}
+
+// The ANY type of X.208, withdrawn from X.680 in favour of open types.
+const (
+	Any     = "ANY"
+	Defined = "DEFINED"
+)
+
+// AnyType is the legacy ANY or ANY DEFINED BY DefinedBy, which stands for an
+// open type whose actual type is given by the DefinedBy component.
+type AnyType struct {
+	TypeBase  `json:"base"`
+	DefinedBy string `json:"definedBy"`
+}
+
+// SelectionType is "Alternative < Type", the type of an alternative of the
+// CHOICE Type.
+type SelectionType struct {
+	TypeBase    `json:"base"`
+	Alternative string `json:"alternative"`
+	Type        Type   `json:"type"`
+}
+
+type ReferencedType struct {
+	TypeBase   `json:"base"`
+	Module     string             `json:"module"`
+	Name       string             `json:"name"`
+	Parameters []*ActualParameter `json:"parameters"`
+}
+
+// ActualParameter is one argument of a parameterized reference, held as its
+// tokens until the parameter it stands for is known.
+type ActualParameter struct {
+	Position Position `json:"position"`
+	Tokens   []Token  `json:"tokens"`
+}
+
+func (a *ActualParameter) Pos() Position { return a.Position }
+
+type Value interface {
+	Node
+	valueNode()
+}
+
+type IntegerValue struct {
+	Position Position `json:"position"`
+	Value    *big.Int `json:"value"`
+}
+
+type StringValue struct {
+	Position Position `json:"position"`
+	Value    string   `json:"value"`
+}
+
+// BitStringValue is a bstring. Bits are packed from the most significant
+// bit of Bytes[0] on, Length counting them.
+type BitStringValue struct {
+	Position Position `json:"position"`
+	Bytes    []byte   `json:"bytes"`
+	Length   int      `json:"length"`
+}
+
+// OctetStringValue is an hstring, packed like BitStringValue. Length is a
+// multiple of four rather than eight, as an hstring may also denote a
+// BIT STRING value.
+type OctetStringValue struct {
+	Position Position `json:"position"`
+	Bytes    []byte   `json:"bytes"`
+	Length   int      `json:"length"`
+}
+
+type BooleanValue struct {
+	Position Position `json:"position"`
+	Value    bool     `json:"value"`
+}
+
+type NullValue struct {
+	Position Position `json:"position"`
+}
+
+// RealValue is Mantissa * Base ^ Exponent, or one of PLUS-INFINITY,
+// MINUS-INFINITY and NOT-A-NUMBER when Special is set.
+type RealValue struct {
+	Position Position `json:"position"`
+	Mantissa *big.Int `json:"mantissa"`
+	Base     int      `json:"base"`
+	Exponent int      `json:"exponent"`
+	Special  string   `json:"special"`
+}
+
+type ReferencedValue struct {
+	Position Position `json:"position"`
+	Module   string   `json:"module"`
+	Name     string   `json:"name"`
+}
+
+type ObjectIdentifierComponent struct {
+	Position Position `json:"position"`
+	Name     string   `json:"name"`
+	Value    Value    `json:"value"`
+}
+
+type ObjectIdentifierValue struct {
+	Position   Position                     `json:"position"`
+	Components []*ObjectIdentifierComponent `json:"components"`
+}
+
+type NamedValue struct {
+	Position Position `json:"position"`
+	Name     string   `json:"name"`
+	Value    Value    `json:"value"`
+}
+
+type SequenceValue struct {
+	Position   Position      `json:"position"`
+	Components []*NamedValue `json:"components"`
+}
+
+type SequenceOfValue struct {
+	Position Position `json:"position"`
+	Elements []Value  `json:"elements"`
+}
+
+type ChoiceValue struct {
+	Position Position `json:"position"`
+	Name     string   `json:"name"`
+	Value    Value    `json:"value"`
+}
+
+func (v *IntegerValue) Pos() Position          { return v.Position }
+func (v *StringValue) Pos() Position           { return v.Position }
+func (v *BooleanValue) Pos() Position          { return v.Position }
+func (v *NullValue) Pos() Position             { return v.Position }
+func (v *RealValue) Pos() Position             { return v.Position }
+func (v *BitStringValue) Pos() Position        { return v.Position }
+func (v *OctetStringValue) Pos() Position      { return v.Position }
+func (v *ReferencedValue) Pos() Position       { return v.Position }
+func (v *ObjectIdentifierValue) Pos() Position { return v.Position }
+func (v *SequenceValue) Pos() Position         { return v.Position }
+func (v *SequenceOfValue) Pos() Position       { return v.Position }
+func (v *ChoiceValue) Pos() Position           { return v.Position }
+
+func (c *ObjectIdentifierComponent) Pos() Position { return c.Position }
+
+func (*IntegerValue) valueNode()          {}
+func (*StringValue) valueNode()           {}
+func (*BooleanValue) valueNode()          {}
+func (*NullValue) valueNode()             {}
+func (*RealValue) valueNode()             {}
+func (*BitStringValue) valueNode()        {}
+func (*OctetStringValue) valueNode()      {}
+func (*ReferencedValue) valueNode()       {}
+func (*ObjectIdentifierValue) valueNode() {}
+func (*SequenceValue) valueNode()         {}
+func (*SequenceOfValue) valueNode()       {}
+func (*ChoiceValue) valueNode()           {}
+
+// ElementSetSpecs is the common shape of constraints, value sets and object
+// sets: a root set, optionally followed by an extension marker and an
+// additional set. Root is nil for a bare "...".
+type ElementSetSpecs struct {
+	Root       Element `json:"root"`
+	Extensible bool    `json:"extensible"`
+	Additional Element `json:"additional"`
+}
+
+// Constraint is a parenthesized constraint. Exception is set when it ends
+// in an exception specification.
+type Constraint struct {
+	Position        Position `json:"position"`
+	ElementSetSpecs `json:"specs"`
+	Exception       *Exception `json:"exception"`
+}
+
+// Exception is an exception specification, "!" followed by a number, a
+// value or "Type : Value", kept as written since it only matters to
+// applications.
+type Exception struct {
+	Position Position `json:"position"`
+	Tokens   []Token  `json:"tokens"`
+}
+
+type ValueSet struct {
+	Position        Position `json:"position"`
+	ElementSetSpecs `json:"specs"`
+}
+
+func (c *Constraint) Pos() Position { return c.Position }
+func (s *ValueSet) Pos() Position   { return s.Position }
+func (e *Exception) Pos() Position  { return e.Position }
+
+// UserDefined reports whether c is a user-defined constraint, in its root or
+// its additions, which X.691 leaves out of the constraints PER encodings see.
+func (c *Constraint) UserDefined() bool {
+	for _, e := range []Element{c.Root, c.Additional} {
+		if _, ok := e.(*UserDefinedConstraint); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type Element interface {
+	Node
+	elementNode()
+}
+
+type UnionElement struct {
+	Position Position  `json:"position"`
+	Elements []Element `json:"elements"`
+}
+
+type IntersectionElement struct {
+	Position Position  `json:"position"`
+	Elements []Element `json:"elements"`
+}
+
+// ExclusionElement is "Element EXCEPT Except", or "ALL EXCEPT Except" when
+// Element is nil.
+type ExclusionElement struct {
+	Position Position `json:"position"`
+	Element  Element  `json:"element"`
+	Except   Element  `json:"except"`
+}
+
+type ValueElement struct {
+	Position Position `json:"position"`
+	Value    Value    `json:"value"`
+}
+
+// RangeEndpoint is a bound of a range. Value is nil for MIN and MAX, which
+// Limit then holds, leaving that side of the range unbounded.
+type RangeEndpoint struct {
+	Position Position `json:"position"`
+	Value    Value    `json:"value"`
+	Limit    string   `json:"limit"`
+	Open     bool     `json:"open"`
+}
+
+type RangeElement struct {
+	Position Position       `json:"position"`
+	Lower    *RangeEndpoint `json:"lower"`
+	Upper    *RangeEndpoint `json:"upper"`
+}
+
+type SizeElement struct {
+	Position   Position    `json:"position"`
+	Constraint *Constraint `json:"constraint"`
+}
+
+type AlphabetElement struct {
+	Position   Position    `json:"position"`
+	Constraint *Constraint `json:"constraint"`
+}
+
+type TypeElement struct {
+	Position Position `json:"position"`
+	Includes bool     `json:"includes"`
+	Type     Type     `json:"type"`
+}
+
+type NamedConstraint struct {
+	Position   Position    `json:"position"`
+	Name       string      `json:"name"`
+	Constraint *Constraint `json:"constraint"`
+	Presence   string      `json:"presence"`
+}
+
+// InnerTypeElement is either WITH COMPONENT (Component set) or
+// WITH COMPONENTS, Partial when the list starts with "...".
+type InnerTypeElement struct {
+	Position   Position           `json:"position"`
+	Component  *Constraint        `json:"component"`
+	Partial    bool               `json:"partial"`
+	Components []*NamedConstraint `json:"components"`
+}
+
+type PatternElement struct {
+	Position Position `json:"position"`
+	Value    Value    `json:"value"`
+}
+
+type ContentsElement struct {
+	Position  Position `json:"position"`
+	Type      Type     `json:"type"`
+	EncodedBy Value    `json:"encodedBy"`
+}
+
+// UserDefinedConstraint is CONSTRAINED BY { ... }, a constraint checked by
+// means outside ASN.1. Its parameters are kept as written, and Comment holds
+// the text of the comments between its braces, which often describe it.
+type UserDefinedConstraint struct {
+	Position   Position           `json:"position"`
+	Parameters []*ActualParameter `json:"parameters"`
+	Comment    string             `json:"comment"`
+}
+
+func (e *UnionElement) Pos() Position          { return e.Position }
+func (e *IntersectionElement) Pos() Position   { return e.Position }
+func (e *ExclusionElement) Pos() Position      { return e.Position }
+func (e *ValueElement) Pos() Position          { return e.Position }
+func (e *RangeElement) Pos() Position          { return e.Position }
+func (e *SizeElement) Pos() Position           { return e.Position }
+func (e *AlphabetElement) Pos() Position       { return e.Position }
+func (e *TypeElement) Pos() Position           { return e.Position }
+func (e *InnerTypeElement) Pos() Position      { return e.Position }
+func (e *PatternElement) Pos() Position        { return e.Position }
+func (e *ContentsElement) Pos() Position       { return e.Position }
+func (e *UserDefinedConstraint) Pos() Position { return e.Position }
+
+func (*UnionElement) elementNode()          {}
+func (*IntersectionElement) elementNode()   {}
+func (*ExclusionElement) elementNode()      {}
+func (*ValueElement) elementNode()          {}
+func (*RangeElement) elementNode()          {}
+func (*SizeElement) elementNode()           {}
+func (*AlphabetElement) elementNode()       {}
+func (*TypeElement) elementNode()           {}
+func (*InnerTypeElement) elementNode()      {}
+func (*PatternElement) elementNode()        {}
+func (*ContentsElement) elementNode()       {}
+func (*UserDefinedConstraint) elementNode() {}
@@ -0,0 +1,28 @@
+package asn1c_go
+
+import "testing"
+
+func TestConstraintUserDefined(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       bool
+	}{
+		{"(CONSTRAINED BY {})", true},
+		{"(CONSTRAINED BY { -- checked -- })", true},
+		{"(1..10, ..., CONSTRAINED BY {})", true},
+		{"(CONSTRAINED BY {}, ..., 1..10)", true},
+		{"(1..10)", false},
+		{"(1..10, ..., 20)", false},
+	}
+	for _, test := range tests {
+		source := "M DEFINITIONS ::= BEGIN T ::= INTEGER " + test.constraint + " END"
+		set, err := ParseBytes("m.asn", []byte(source))
+		if nil != err {
+			t.Fatalf("%s: %v", test.constraint, err)
+		}
+		a := set.Modules[0].Assignments[0].(*TypeAssignment)
+		if got := a.Type.(*IntegerType).Constraints[0].UserDefined(); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.constraint, got, test.want)
+		}
+	}
+}
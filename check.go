@@ -0,0 +1,1096 @@
+package asn1c_go
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Reference is what a name used in a module stands for. Exactly one member
+// is set: a local assignment, an item named by the governing type, a dummy
+// reference of the enclosing parameterized assignment, or an import, which
+// is only followed into its module when modules are linked.
+type Reference struct {
+	Assignment Assignment
+	Named      *NamedNumber
+	Parameter  *Parameter
+	Import     *Import
+}
+
+// CheckedModule is a module whose references have been resolved. References
+// maps each ReferencedType, ReferencedValue, ObjectIdentifierComponent,
+// InformationObject and ObjectSetElement naming something to what it names.
+// Warnings holds what is valid but likely a mistake.
+type CheckedModule struct {
+	Module     *ModuleDefinition
+	References map[Node]*Reference
+	Warnings   ErrorList
+}
+
+// ResolveType follows t through type references to the type it stands for.
+// It stops at a reference it cannot follow within the module, such as an
+// import or a dummy reference, and returns that reference.
+func (m *CheckedModule) ResolveType(t Type) Type {
+	for i := 0; i <= len(m.Module.Assignments); i++ {
+		ref, ok := t.(*ReferencedType)
+		if !ok {
+			return t
+		}
+		switch assignment := m.assignment(ref).(type) {
+		case *TypeAssignment:
+			t = assignment.Type
+		case *ValueSetAssignment:
+			t = assignment.Type
+		default:
+			return t
+		}
+	}
+	return t
+}
+
+// ResolveValue follows v through value references to the value it stands
+// for. It returns nil when they lead out of the module or to an identifier
+// without a number.
+func (m *CheckedModule) ResolveValue(v Value) Value {
+	return resolveValue(v, func(n Node) *Reference { return m.References[n] }, nil)
+}
+
+// resolveValue follows value references with reference, and with imported
+// into other modules when it is given.
+func resolveValue(v Value, reference func(Node) *Reference, imported func(*Import, string) Assignment) Value {
+	seen := map[Value]bool{}
+	for nil != v && !seen[v] {
+		seen[v] = true
+		ref, ok := v.(*ReferencedValue)
+		if !ok {
+			return v
+		}
+		r := reference(ref)
+		if nil == r {
+			return nil
+		}
+		if nil != r.Named {
+			v = r.Named.Value
+			continue
+		}
+		assignment := r.Assignment
+		if nil != r.Import && nil != imported {
+			assignment = imported(r.Import, ref.Name)
+		}
+		a, ok := assignment.(*ValueAssignment)
+		if !ok {
+			return nil
+		}
+		v = a.Value
+	}
+	return nil
+}
+
+func (m *CheckedModule) assignment(n Node) Assignment {
+	if reference := m.References[n]; nil != reference {
+		return reference.Assignment
+	}
+	return nil
+}
+
+// checker resolves references in two passes, types and classes before
+// values, so that a value can be read once its type is known even when the
+// type comes from another module.
+type checker struct {
+	module     *ModuleDefinition
+	checked    *CheckedModule
+	program    *Program
+	parameters []*Parameter
+	bound      map[*Parameter]*Reference
+	values     bool
+	bounds     []*ReferencedValue
+	numbered   []Type
+	typed      []typedValue
+	structured []Type
+	errors     ErrorList
+}
+
+func newChecker(module *ModuleDefinition) *checker {
+	return &checker{
+		module:  module,
+		checked: &CheckedModule{Module: module, References: map[Node]*Reference{}},
+	}
+}
+
+// Check resolves the references of module. Names that are neither defined
+// nor imported are reported, as are types defined in terms of themselves
+// without a CHOICE, a list or an optional component in between and values
+// that are not of their types. The checked module is returned even when
+// there are errors.
+func Check(module *ModuleDefinition) (*CheckedModule, error) {
+	c := newChecker(module)
+	c.checkTypes()
+	c.applyTags()
+	c.checkValues()
+	c.orderComponents()
+	c.checkBounds()
+	c.checkNumbers()
+	c.checkConformance()
+	c.errors.Sort()
+	c.checked.Warnings.Sort()
+	return c.checked, c.errors.Err()
+}
+
+func (c *checker) checkTypes() {
+	c.checkExports()
+	c.checkDefinitions()
+	for _, assignment := range c.module.Assignments {
+		c.checkAssignment(assignment)
+	}
+	c.checkCycles()
+}
+
+func (c *checker) checkValues() {
+	c.values = true
+	for _, assignment := range c.module.Assignments {
+		c.checkAssignment(assignment)
+	}
+	c.checkFinite()
+}
+
+func (c *checker) errorf(pos Position, format string, args ...interface{}) {
+	c.errors = append(c.errors, &Error{Position: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) warnf(pos Position, format string, args ...interface{}) {
+	c.checked.Warnings = append(c.checked.Warnings, &Error{Position: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// lookup finds what name stands for, dummy references shadowing the
+// assignments and imports of the module. In an instance the dummies stand
+// for what they are bound to.
+func (c *checker) lookup(module, name string) *Reference {
+	if len(module) != 0 && module != c.module.Name {
+		for _, imported := range c.module.Imports {
+			if imported.Module == module {
+				return &Reference{Import: imported}
+			}
+		}
+		return nil
+	}
+	if len(module) == 0 {
+		for _, parameter := range c.parameters {
+			if parameter.Name == name {
+				if bound := c.bound[parameter]; nil != bound {
+					return bound
+				}
+				return &Reference{Parameter: parameter}
+			}
+		}
+	}
+	if assignment := c.module.Lookup(name); nil != assignment {
+		return &Reference{Assignment: assignment}
+	}
+	if imported := c.module.LookupImport(name); nil != imported {
+		return &Reference{Import: imported}
+	}
+	return nil
+}
+
+func (c *checker) resolve(n Node, module, name string) *Reference {
+	reference := c.lookup(module, name)
+	if nil == reference {
+		if len(module) != 0 && module != c.module.Name {
+			c.errorf(n.Pos(), "module %s is not imported", module)
+		} else {
+			c.errorf(n.Pos(), "undefined reference %s", name)
+		}
+		return nil
+	}
+	c.checked.References[n] = reference
+	return reference
+}
+
+func (c *checker) checkExports() {
+	if nil == c.module.Exports {
+		return
+	}
+	for _, symbol := range c.module.Exports.Symbols {
+		if nil == c.lookup("", symbol.Name) && nil == builtinObjectClasses[symbol.Name] {
+			c.errorf(symbol.Position, "exported symbol %s is not defined", symbol.Name)
+		}
+	}
+}
+
+// checkDefinitions reports names assigned twice in the module, imported
+// twice, or both imported and assigned.
+func (c *checker) checkDefinitions() {
+	var (
+		imported = map[string]Position{}
+		assigned = map[string]Position{}
+	)
+	for _, i := range c.module.Imports {
+		for _, symbol := range i.Symbols {
+			if pos, ok := imported[symbol.Name]; ok {
+				c.errorf(symbol.Position, "%s already imported at %s", symbol.Name, pos)
+				continue
+			}
+			imported[symbol.Name] = symbol.Position
+		}
+	}
+	for _, assignment := range c.module.Assignments {
+		name := assignment.Reference()
+		if pos, ok := assigned[name]; ok {
+			c.errorf(assignment.Pos(), "%s already defined at %s", name, pos)
+			continue
+		}
+		assigned[name] = assignment.Pos()
+		if pos, ok := imported[name]; ok {
+			c.errorf(assignment.Pos(), "%s shadows the import at %s", name, pos)
+		}
+	}
+}
+
+func (c *checker) checkAssignment(assignment Assignment) {
+	switch a := assignment.(type) {
+	case *TypeAssignment:
+		c.parameters = a.Parameters
+		for _, parameter := range a.Parameters {
+			if nil != parameter.Governor {
+				c.checkType(parameter.Governor)
+			}
+			if len(parameter.Class) != 0 {
+				c.checkClass(parameter.Position, parameter.Class)
+			}
+		}
+		c.checkType(a.Type)
+		c.parameters = nil
+	case *ValueAssignment:
+		c.checkType(a.Type)
+		c.checkValue(a.Value, a.Type)
+		if c.values {
+			c.typed = append(c.typed, typedValue{value: a.Value, typ: a.Type})
+		}
+	case *ValueSetAssignment:
+		c.checkType(a.Type)
+		c.checkElements(a.Set.ElementSetSpecs, a.Type)
+	case *ObjectClassAssignment:
+		if len(a.Class.Reference) != 0 {
+			c.checkClass(a.Position, a.Class.Reference)
+			return
+		}
+		for _, field := range a.Class.Fields {
+			c.checkFieldSpec(field)
+		}
+	case *ObjectAssignment:
+		c.checkObject(a.Object, c.checkClass(a.Position, a.Class))
+	case *ObjectSetAssignment:
+		c.checkObjectSet(a.Set, c.checkClass(a.Position, a.Class))
+	}
+}
+
+// checkClass reports a class that is neither defined nor imported, and
+// returns its definition when it is known.
+func (c *checker) checkClass(pos Position, name string) *ObjectClass {
+	class, last := c.module.objectClass(name)
+	if nil == class && nil == c.module.LookupImport(last) && !c.values {
+		c.errorf(pos, "undefined class %s", last)
+	}
+	if nil == class && nil != c.program {
+		class = lookupObjectClass(c.program.definitions(), c.module, name)
+	}
+	return class
+}
+
+func (c *checker) checkFieldSpec(field *FieldSpec) {
+	if nil != field.Type {
+		c.checkType(field.Type)
+	}
+	if len(field.Class) != 0 {
+		c.checkClass(field.Position, field.Class)
+	}
+	if nil != field.Default {
+		c.checkSetting(field.Default, field, nil)
+	}
+}
+
+func (c *checker) checkType(t Type) {
+	base := t.Base()
+	if nil != base.Tag {
+		c.checkValue(base.Tag.Number, nil)
+	}
+	switch t := t.(type) {
+	case *ReferencedType:
+		if c.values {
+			break
+		}
+		reference := c.resolve(t, t.Module, t.Name)
+		if nil == reference {
+			break
+		}
+		switch a := reference.Assignment.(type) {
+		case nil, *TypeAssignment, *ValueSetAssignment:
+		default:
+			c.errorf(t.Position, "%s is not a type", a.Reference())
+		}
+	case *IntegerType, *BitStringType:
+		c.checkNamedNumbers(t)
+	case *EnumeratedType:
+		if c.module.ExtensibilityImplied {
+			t.Extensible = true
+		}
+		c.checkNamedNumbers(t)
+	case *SequenceType, *SetType, *ChoiceType:
+		if !c.values {
+			c.structured = append(c.structured, t)
+			c.checkExtensions(componentList(t))
+		}
+		c.checkComponents(componentList(t))
+	case *SequenceOfType:
+		c.checkType(t.Element)
+	case *SetOfType:
+		c.checkType(t.Element)
+	case *SelectionType:
+		c.checkType(t.Type)
+	case *ObjectClassFieldType:
+		c.checkClass(t.Position, t.Class)
+	}
+	for _, constraint := range base.Constraints {
+		c.checkElements(constraint.ElementSetSpecs, t)
+	}
+}
+
+// checkNamedNumbers also reports names t gives twice. The numbers are
+// left to checkNumbers, once values are resolved.
+func (c *checker) checkNamedNumbers(t Type) {
+	numbers := namedNumbers(t)
+	for i, number := range numbers {
+		if nil != number.Value {
+			c.checkValue(number.Value, nil)
+		}
+		if c.values {
+			continue
+		}
+		for _, other := range numbers[:i] {
+			if other.Name == number.Name {
+				c.errorf(number.Position, "%s already defined at %s", number.Name, other.Position)
+				break
+			}
+		}
+	}
+	if !c.values {
+		c.numbered = append(c.numbered, t)
+	}
+}
+
+// checkNumbers reports numbers given twice among the named numbers, named
+// bits and enumeration items of a type, negative bit numbers, and
+// enumeration additions not numbered above the additions before them, as
+// X.680 requires. Numbers that cannot be resolved are skipped.
+func (c *checker) checkNumbers() {
+	for _, t := range c.numbered {
+		var (
+			numbers    = namedNumbers(t)
+			values     []*big.Int
+			enumerated = false
+		)
+		switch t := t.(type) {
+		case *EnumeratedType:
+			values, enumerated = c.enumerate(t), true
+		default:
+			for _, number := range numbers {
+				var value *big.Int
+				if v, ok := c.valueOf(number.Value).(*IntegerValue); ok {
+					value = v.Value
+				}
+				values = append(values, value)
+			}
+		}
+		if len(values) != len(numbers) {
+			continue
+		}
+		for i, number := range numbers {
+			if nil == values[i] {
+				continue
+			}
+			if _, ok := t.(*BitStringType); ok && values[i].Sign() < 0 {
+				c.errorf(number.Position, "%s has the negative bit number %s", number.Name, values[i])
+			}
+			for j, other := range numbers[:i] {
+				if nil != values[j] && values[i].Cmp(values[j]) == 0 {
+					c.errorf(number.Position, "%s reuses the number %s of %s at %s", number.Name, values[i], other.Name, other.Position)
+					break
+				}
+			}
+		}
+		if !enumerated {
+			continue
+		}
+		// Equal numbers are reported as reused above.
+		additions := t.(*EnumeratedType).Additions
+		for i := len(numbers) - len(additions) + 1; i < len(numbers); i++ {
+			if values[i].Cmp(values[i-1]) < 0 {
+				c.errorf(numbers[i].Position, "%s is numbered %s, not above %s of %s at %s", numbers[i].Name, values[i], values[i-1], numbers[i-1].Name, numbers[i-1].Position)
+			}
+		}
+	}
+}
+
+// Enumerate returns the numbers of the items of t, root items first, as
+// X.680 assigns them, or nil when a number cannot be resolved.
+func (m *CheckedModule) Enumerate(t *EnumeratedType) []*big.Int {
+	c := &checker{module: m.Module, checked: m}
+	return c.enumerate(t)
+}
+
+// Enumerate is that of CheckedModule, following references through
+// imports.
+func (p *Program) Enumerate(t *EnumeratedType) []*big.Int {
+	c := &checker{program: p}
+	return c.enumerate(t)
+}
+
+// enumerate returns the numbers of the items of t, root items first, as
+// X.680 assigns them: root items without a number take the least
+// non-negative numbers the others leave free, and additions without one
+// the least number above the addition before them that the root leaves
+// free. It returns nil when a number cannot be resolved.
+func (c *checker) enumerate(t *EnumeratedType) []*big.Int {
+	var (
+		values = make([]*big.Int, 0, len(t.Items)+len(t.Additions))
+		used   = map[string]bool{}
+	)
+	explicit := func(item *NamedNumber) (*big.Int, bool) {
+		v, ok := c.valueOf(item.Value).(*IntegerValue)
+		if !ok {
+			return nil, false
+		}
+		return v.Value, true
+	}
+	for _, item := range t.Items {
+		if nil == item.Value {
+			continue
+		}
+		value, ok := explicit(item)
+		if !ok {
+			return nil
+		}
+		used[value.String()] = true
+	}
+	next := new(big.Int)
+	for _, item := range t.Items {
+		if nil != item.Value {
+			value, _ := explicit(item)
+			values = append(values, value)
+			continue
+		}
+		for used[next.String()] {
+			next.Add(next, big.NewInt(1))
+		}
+		value := new(big.Int).Set(next)
+		used[value.String()] = true
+		values = append(values, value)
+	}
+	next = new(big.Int)
+	for _, item := range t.Additions {
+		var value *big.Int
+		if nil != item.Value {
+			var ok bool
+			if value, ok = explicit(item); !ok {
+				return nil
+			}
+		} else {
+			for used[next.String()] {
+				next.Add(next, big.NewInt(1))
+			}
+			value = new(big.Int).Set(next)
+		}
+		values = append(values, value)
+		next = new(big.Int).Add(value, big.NewInt(1))
+	}
+	return values
+}
+
+func (c *checker) checkComponents(list *ComponentList) {
+	all := components(list)
+	for i, component := range all {
+		if c.values || len(component.Name) == 0 {
+			continue
+		}
+		for _, other := range all[:i] {
+			if other.Name == component.Name {
+				c.errorf(component.Position, "component %s already defined at %s", component.Name, other.Position)
+				break
+			}
+		}
+	}
+	for _, component := range all {
+		c.checkType(component.Type)
+		if nil != component.Default {
+			c.checkValue(component.Default, component.Type)
+			if c.values {
+				c.typed = append(c.typed, typedValue{value: component.Default, typ: component.Type})
+			}
+		}
+	}
+}
+
+// checkExtensions reports extension addition groups whose version numbers
+// do not increase, and warns of root components placed after the
+// extensions, as X.680 encodes them ahead of the extensions all the same.
+// In a module with EXTENSIBILITY IMPLIED the list is made extensible.
+func (c *checker) checkExtensions(list *ComponentList) {
+	if c.module.ExtensibilityImplied {
+		list.Extensible = true
+	}
+	var last *ExtensionAddition
+	for _, addition := range list.Additions {
+		if addition.Version == 0 {
+			continue
+		}
+		if nil != last && addition.Version <= last.Version {
+			c.errorf(addition.Position, "version %d does not follow version %d at %s", addition.Version, last.Version, last.Position)
+		}
+		last = addition
+	}
+	if len(list.Trailing) != 0 {
+		c.warnf(list.Trailing[0].Position, "root component %s follows the extension additions", list.Trailing[0].Name)
+	}
+}
+
+// componentList returns the components of a SEQUENCE, SET or CHOICE, or nil
+// for other types.
+func componentList(t Type) *ComponentList {
+	switch t := t.(type) {
+	case *SequenceType:
+		return &t.ComponentList
+	case *SetType:
+		return &t.ComponentList
+	case *ChoiceType:
+		return &t.ComponentList
+	}
+	return nil
+}
+
+// components lists every component of list, extension additions included.
+func components(list *ComponentList) []*ComponentType {
+	all := append([]*ComponentType(nil), list.Components...)
+	for _, addition := range list.Additions {
+		all = append(all, addition.Components...)
+	}
+	return append(all, list.Trailing...)
+}
+
+// governor returns the type naming the values written for t: the type
+// itself once references are followed, or the reference it stops at.
+func (c *checker) governor(t Type) Type {
+	for i := 0; nil != t && i <= len(c.module.Assignments); i++ {
+		switch u := t.(type) {
+		case *ReferencedType:
+			var next Type
+			if nil != c.program {
+				next = c.program.ResolveType(u)
+			} else {
+				next = c.checked.ResolveType(u)
+			}
+			if next == t {
+				return t
+			}
+			t = next
+		case *SelectionType:
+			choice, ok := c.governor(u.Type).(*ChoiceType)
+			if !ok {
+				return nil
+			}
+			t = componentType(&choice.ComponentList, u.Alternative)
+		case *ObjectClassFieldType:
+			class := c.objectClass(u.Class)
+			if nil == class || len(u.Field) != 1 || nil == class.Field(u.Field[0]) {
+				return nil
+			}
+			t = class.Field(u.Field[0]).Type
+		default:
+			return t
+		}
+	}
+	return t
+}
+
+func (c *checker) objectClass(name string) *ObjectClass {
+	if nil != c.program {
+		return lookupObjectClass(c.program.definitions(), c.module, name)
+	}
+	return c.module.ObjectClass(name)
+}
+
+func componentType(list *ComponentList, name string) Type {
+	for _, component := range components(list) {
+		if component.Name == name {
+			return component.Type
+		}
+	}
+	return nil
+}
+
+// namedNumbers returns the identifiers t defines, the items of an
+// enumeration root items first.
+func namedNumbers(t Type) []*NamedNumber {
+	switch t := t.(type) {
+	case *IntegerType:
+		return t.NamedNumbers
+	case *EnumeratedType:
+		return append(append([]*NamedNumber(nil), t.Items...), t.Additions...)
+	case *BitStringType:
+		return t.NamedBits
+	}
+	return nil
+}
+
+// namedNumber finds name among the identifiers the governing type defines.
+func namedNumber(governor Type, name string) *NamedNumber {
+	for _, number := range namedNumbers(governor) {
+		if number.Name == name {
+			return number
+		}
+	}
+	return nil
+}
+
+// checkValue resolves the references in v, a value of governor. A nil
+// governor stands for a type without identifiers of its own.
+func (c *checker) checkValue(v Value, governor Type) {
+	if !c.values {
+		return
+	}
+	governor = c.governor(governor)
+	switch v := v.(type) {
+	case *ReferencedValue:
+		if len(v.Module) == 0 {
+			if number := namedNumber(governor, v.Name); nil != number {
+				c.checked.References[v] = &Reference{Named: number}
+				return
+			}
+		}
+		if _, open := governor.(*ReferencedType); open && nil == c.lookup(v.Module, v.Name) {
+			// The identifier may belong to a type the module imports.
+			return
+		}
+		if _, ok := governor.(*EnumeratedType); ok && nil == c.lookup(v.Module, v.Name) {
+			c.errorf(v.Position, "%s is not an item of the ENUMERATED at %s", v.Name, governor.Pos())
+			return
+		}
+		reference := c.resolve(v, v.Module, v.Name)
+		if nil == reference {
+			return
+		}
+		switch a := reference.Assignment.(type) {
+		case nil, *ValueAssignment:
+		default:
+			c.errorf(v.Position, "%s is not a value", a.Reference())
+		}
+	case *ObjectIdentifierValue:
+		for _, component := range v.Components {
+			switch {
+			case nil != component.Value:
+				c.checkValue(component.Value, nil)
+			case nil != c.lookup("", component.Name):
+				c.checked.References[component] = c.lookup("", component.Name)
+			}
+		}
+	case *SequenceValue:
+		var list *ComponentList
+		switch t := governor.(type) {
+		case *SequenceType:
+			list = &t.ComponentList
+		case *SetType:
+			list = &t.ComponentList
+		}
+		for _, component := range v.Components {
+			var t Type
+			if nil != list {
+				if t = componentType(list, component.Name); nil == t {
+					c.errorf(component.Position, "no component %s", component.Name)
+					continue
+				}
+			}
+			c.checkValue(component.Value, t)
+		}
+	case *SequenceOfValue:
+		var element Type
+		switch t := governor.(type) {
+		case *SequenceOfType:
+			element = t.Element
+		case *SetOfType:
+			element = t.Element
+		}
+		for _, e := range v.Elements {
+			c.checkValue(e, element)
+		}
+	case *ChoiceValue:
+		var t Type
+		if choice, ok := governor.(*ChoiceType); ok {
+			if t = componentType(&choice.ComponentList, v.Name); nil == t {
+				c.errorf(v.Position, "no alternative %s", v.Name)
+				return
+			}
+		}
+		c.checkValue(v.Value, t)
+	}
+}
+
+// checkElements resolves the references of a constraint or value set whose
+// values are of governor.
+func (c *checker) checkElements(specs ElementSetSpecs, governor Type) {
+	if nil != specs.Root {
+		c.checkElement(specs.Root, governor)
+	}
+	if nil != specs.Additional {
+		c.checkElement(specs.Additional, governor)
+	}
+}
+
+func (c *checker) checkElement(element Element, governor Type) {
+	switch e := element.(type) {
+	case *UnionElement:
+		for _, element := range e.Elements {
+			c.checkElement(element, governor)
+		}
+	case *IntersectionElement:
+		for _, element := range e.Elements {
+			c.checkElement(element, governor)
+		}
+	case *ExclusionElement:
+		if nil != e.Element {
+			c.checkElement(e.Element, governor)
+		}
+		c.checkElement(e.Except, governor)
+	case *ValueElement:
+		c.checkValue(e.Value, governor)
+	case *RangeElement:
+		for _, endpoint := range []*RangeEndpoint{e.Lower, e.Upper} {
+			if nil != endpoint.Value {
+				c.checkValue(endpoint.Value, governor)
+			}
+		}
+	case *SizeElement:
+		c.checkElements(e.Constraint.ElementSetSpecs, nil)
+		if c.values {
+			eachValue(e.Constraint.ElementSetSpecs, func(v Value) {
+				if ref, ok := v.(*ReferencedValue); ok {
+					c.bounds = append(c.bounds, ref)
+				}
+			})
+		}
+	case *AlphabetElement:
+		c.checkElements(e.Constraint.ElementSetSpecs, governor)
+	case *TypeElement:
+		c.checkType(e.Type)
+	case *InnerTypeElement:
+		t := c.governor(governor)
+		if nil != e.Component {
+			var element Type
+			switch t := t.(type) {
+			case *SequenceOfType:
+				element = t.Element
+			case *SetOfType:
+				element = t.Element
+			}
+			c.checkElements(e.Component.ElementSetSpecs, element)
+		}
+		for _, named := range e.Components {
+			if nil == named.Constraint {
+				continue
+			}
+			var component Type
+			switch t := t.(type) {
+			case *SequenceType:
+				component = componentType(&t.ComponentList, named.Name)
+			case *SetType:
+				component = componentType(&t.ComponentList, named.Name)
+			case *ChoiceType:
+				component = componentType(&t.ComponentList, named.Name)
+			}
+			c.checkElements(named.Constraint.ElementSetSpecs, component)
+		}
+	case *PatternElement:
+		c.checkValue(e.Value, nil)
+	case *ContentsElement:
+		if nil != e.Type {
+			c.checkType(e.Type)
+		}
+		if nil != e.EncodedBy {
+			c.checkValue(e.EncodedBy, nil)
+		}
+	case *TableConstraint:
+		var class *ObjectClass
+		if t, ok := governor.(*ObjectClassFieldType); ok {
+			class = c.objectClass(t.Class)
+		}
+		c.checkObjectSet(e.Set, class)
+	}
+}
+
+// checkBounds reports the references in size constraints that do not
+// stand for a number, once all values are resolved. References to dummies
+// and into modules not linked are skipped.
+func (c *checker) checkBounds() {
+	for _, ref := range c.bounds {
+		reference := c.checked.References[ref]
+		if nil == reference || nil != reference.Parameter || (nil != reference.Import && nil == c.program) {
+			continue
+		}
+		if _, ok := c.valueOf(ref).(*IntegerValue); !ok {
+			c.errorf(ref.Position, "%s is not an integer", ref.Name)
+		}
+	}
+}
+
+// eachValue calls f with the values of the single values and ranges of
+// specs, not descending into nested constraints.
+func eachValue(specs ElementSetSpecs, f func(Value)) {
+	var walk func(Element)
+	walk = func(element Element) {
+		switch e := element.(type) {
+		case *UnionElement:
+			for _, element := range e.Elements {
+				walk(element)
+			}
+		case *IntersectionElement:
+			for _, element := range e.Elements {
+				walk(element)
+			}
+		case *ExclusionElement:
+			if nil != e.Element {
+				walk(e.Element)
+			}
+			walk(e.Except)
+		case *ValueElement:
+			f(e.Value)
+		case *RangeElement:
+			for _, endpoint := range []*RangeEndpoint{e.Lower, e.Upper} {
+				if nil != endpoint.Value {
+					f(endpoint.Value)
+				}
+			}
+		}
+	}
+	for _, element := range []Element{specs.Root, specs.Additional} {
+		if nil != element {
+			walk(element)
+		}
+	}
+}
+
+func (c *checker) checkObject(object *InformationObject, class *ObjectClass) {
+	if len(object.Reference) != 0 {
+		if c.values {
+			return
+		}
+		reference := c.resolve(object, object.Module, object.Reference)
+		if nil == reference {
+			return
+		}
+		switch a := reference.Assignment.(type) {
+		case nil, *ObjectAssignment:
+		default:
+			c.errorf(object.Position, "%s is not an object", a.Reference())
+		}
+		return
+	}
+	if nil == class {
+		return
+	}
+	for _, setting := range object.Fields {
+		if field := class.Field(setting.Name); nil != field {
+			c.checkSetting(setting.Setting, field, object)
+		}
+	}
+}
+
+// checkSetting resolves the references of a setting of field in object, or
+// of its default when object is nil.
+func (c *checker) checkSetting(setting *Setting, field *FieldSpec, object *InformationObject) {
+	switch field.Kind {
+	case TypeField:
+		c.checkType(setting.Type)
+	case FixedTypeValueField:
+		c.checkValue(setting.Value, field.Type)
+	case VariableTypeValueField:
+		c.checkValue(setting.Value, settingType(object, field))
+	case FixedTypeValueSetField:
+		c.checkElements(setting.ValueSet.ElementSetSpecs, field.Type)
+	case VariableTypeValueSetField:
+		c.checkElements(setting.ValueSet.ElementSetSpecs, settingType(object, field))
+	case ObjectField:
+		c.checkObject(setting.Object, c.checkClass(setting.Position, field.Class))
+	case ObjectSetField:
+		c.checkObjectSet(setting.ObjectSet, c.checkClass(setting.Position, field.Class))
+	}
+}
+
+// settingType returns the type object sets for the type field that governs
+// a variable-type field.
+func settingType(object *InformationObject, field *FieldSpec) Type {
+	if nil == object || len(field.TypeField) != 1 {
+		return nil
+	}
+	if setting := object.Field(field.TypeField[0]); nil != setting {
+		return setting.Type
+	}
+	return nil
+}
+
+func (c *checker) checkObjectSet(set *ObjectSet, class *ObjectClass) {
+	var walk func(Element)
+	walk = func(element Element) {
+		switch e := element.(type) {
+		case *UnionElement:
+			for _, element := range e.Elements {
+				walk(element)
+			}
+		case *IntersectionElement:
+			for _, element := range e.Elements {
+				walk(element)
+			}
+		case *ExclusionElement:
+			if nil != e.Element {
+				walk(e.Element)
+			}
+			walk(e.Except)
+		case *ObjectElement:
+			c.checkObject(e.Object, class)
+		case *ObjectSetElement:
+			if c.values {
+				return
+			}
+			reference := c.resolve(e, e.Module, e.Name)
+			if nil == reference {
+				return
+			}
+			switch a := reference.Assignment.(type) {
+			case nil, *ObjectSetAssignment:
+			default:
+				c.errorf(e.Position, "%s is not an object set", a.Reference())
+			}
+		}
+	}
+	if nil != set.Root {
+		walk(set.Root)
+	}
+	if nil != set.Additional {
+		walk(set.Additional)
+	}
+}
+
+// checkCycles reports types that are references to themselves. Recursion
+// through the components of a SEQUENCE, SET or CHOICE, or the element of a
+// SEQUENCE OF or SET OF, is allowed, checkFinite telling the recursion that
+// leaves no finite value.
+func (c *checker) checkCycles() {
+	for _, assignment := range c.module.Assignments {
+		start, ok := assignment.(*TypeAssignment)
+		if !ok {
+			continue
+		}
+		var (
+			t    = start.Type
+			seen = map[Assignment]bool{start: true}
+		)
+		for {
+			ref, ok := t.(*ReferencedType)
+			if !ok {
+				break
+			}
+			next, ok := c.checked.assignment(ref).(*TypeAssignment)
+			if !ok {
+				break
+			}
+			if next == start {
+				c.errorf(start.Position, "type %s is defined in terms of itself", start.Name)
+				break
+			}
+			if seen[next] {
+				break
+			}
+			seen[next] = true
+			t = next.Type
+		}
+	}
+}
+
+// checkFinite reports the SEQUENCE and SET types that hold themselves
+// through mandatory root components only, so that none of their values is
+// finite. An OPTIONAL or DEFAULT component, an extension addition, a CHOICE
+// or a SEQUENCE OF on the way ends the recursion.
+func (c *checker) checkFinite() {
+	for _, assignment := range c.module.Assignments {
+		a, ok := assignment.(*TypeAssignment)
+		if !ok || len(a.Parameters) != 0 {
+			continue
+		}
+		var list *ComponentList
+		switch t := a.Type.(type) {
+		case *SequenceType:
+			list = &t.ComponentList
+		case *SetType:
+			list = &t.ComponentList
+		default:
+			continue
+		}
+		if path := c.loop(list, list, nil, map[*ComponentList]bool{}); nil != path {
+			c.errorf(a.Position, "type %s holds itself through mandatory components %s, so has no finite value", a.Name, strings.Join(path, "."))
+		}
+	}
+}
+
+// structure returns the components of t when it is a SEQUENCE or SET once
+// references are followed, nil otherwise.
+func (c *checker) structure(t Type) *ComponentList {
+	switch t := c.governor(t).(type) {
+	case *SequenceType:
+		return &t.ComponentList
+	case *SetType:
+		return &t.ComponentList
+	}
+	return nil
+}
+
+// loop returns the names of the mandatory components leading from list to
+// target, following path, or nil when there are none.
+func (c *checker) loop(list, target *ComponentList, path []string, seen map[*ComponentList]bool) []string {
+	if seen[list] {
+		return nil
+	}
+	seen[list] = true
+	for _, component := range c.mandatory(list, 0) {
+		next := c.structure(component.Type)
+		if nil == next {
+			continue
+		}
+		path := append(path[:len(path):len(path)], component.Name)
+		if next == target {
+			return path
+		}
+		if found := c.loop(next, target, path, seen); nil != found {
+			return found
+		}
+	}
+	return nil
+}
+
+// mandatory lists the root components of list that are neither OPTIONAL
+// nor DEFAULT, those COMPONENTS OF brings in included.
+func (c *checker) mandatory(list *ComponentList, depth int) []*ComponentType {
+	if depth > len(c.module.Assignments) {
+		return nil
+	}
+	var found []*ComponentType
+	for _, components := range [][]*ComponentType{list.Components, list.Trailing} {
+		for _, component := range components {
+			switch {
+			case component.ComponentsOf:
+				if included := c.structure(component.Type); nil != included {
+					found = append(found, c.mandatory(included, depth+1)...)
+				}
+			case !component.Optional && nil == component.Default:
+				found = append(found, component)
+			}
+		}
+	}
+	return found
+}
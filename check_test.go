@@ -0,0 +1,616 @@
+package asn1c_go
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// link writes each source to a file of a temporary directory, named after
+// its position in sources, and links them.
+func link(t *testing.T, sources ...string) (*Program, error) {
+	t.Helper()
+	var (
+		dir   = t.TempDir()
+		files []string
+	)
+	for i, source := range sources {
+		filename := filepath.Join(dir, fmt.Sprintf("%d.asn", i+1))
+		if err := ioutil.WriteFile(filename, []byte(source), 0644); nil != err {
+			t.Fatal(err)
+		}
+		files = append(files, filename)
+	}
+	return ParseAndLink(files, nil)
+}
+
+// messages returns err, an ErrorList, as "file:line:column: message" lines
+// with only the base names of the files, in the messages too.
+func messages(t *testing.T, err error) []string {
+	t.Helper()
+	if nil == err {
+		return nil
+	}
+	list, ok := err.(ErrorList)
+	if !ok {
+		t.Fatalf("got %T, want ErrorList: %v", err, err)
+	}
+	var lines []string
+	for _, e := range list {
+		pos := e.Position
+		message := strings.ReplaceAll(e.Message, filepath.Dir(pos.Filename)+string(filepath.Separator), "")
+		lines = append(lines, fmt.Sprintf("%s:%d:%d: %s", filepath.Base(pos.Filename), pos.Line, pos.Column, message))
+	}
+	return lines
+}
+
+func TestCheckCycles(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name: "lower case",
+			source: `M DEFINITIONS ::= BEGIN
+Alpha ::= Beta
+Beta ::= Alpha
+END`,
+			want: []string{
+				"1.asn:2:1: type Alpha is defined in terms of itself",
+				"1.asn:3:1: type Beta is defined in terms of itself",
+			},
+		},
+		{
+			name: "upper case",
+			source: `M DEFINITIONS ::= BEGIN
+A ::= B
+B ::= A
+END`,
+			want: []string{
+				"1.asn:2:1: type A is defined in terms of itself",
+				"1.asn:3:1: type B is defined in terms of itself",
+			},
+		},
+		{
+			name: "through a component",
+			source: `M DEFINITIONS ::= BEGIN
+List ::= SEQUENCE { head INTEGER, tail List OPTIONAL }
+END`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := link(t, test.source)
+			if got := messages(t, err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckFinite(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name: "mutual",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+LoopA ::= SEQUENCE { b LoopB }
+LoopB ::= SET { n BOOLEAN, a LoopA }
+END`,
+			want: []string{
+				"1.asn:2:1: type LoopA holds itself through mandatory components b.a, so has no finite value",
+				"1.asn:3:1: type LoopB holds itself through mandatory components a.b, so has no finite value",
+			},
+		},
+		{
+			name: "nested and included",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Outer ::= SEQUENCE { inner SEQUENCE { outer Alias } }
+Alias ::= Outer
+Base ::= SEQUENCE { base Base2 }
+Base2 ::= SEQUENCE { COMPONENTS OF Base }
+END`,
+			want: []string{
+				"1.asn:2:1: type Outer holds itself through mandatory components inner.outer, so has no finite value",
+				"1.asn:5:1: type Base2 holds itself through mandatory components base, so has no finite value",
+			},
+		},
+		{
+			name: "broken",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Optional ::= SEQUENCE { next Optional OPTIONAL }
+Default ::= SEQUENCE { next Default DEFAULT { } }
+Extension ::= SEQUENCE { ..., next Extension }
+Choice ::= SEQUENCE { next CHOICE { more Choice, none NULL } }
+List ::= SEQUENCE { next SEQUENCE OF List }
+END`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := link(t, test.source)
+			if got := messages(t, err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckDuplicates(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []string
+		want    []string
+	}{
+		{
+			name: "types",
+			sources: []string{`M DEFINITIONS ::= BEGIN
+T ::= INTEGER
+T ::= BOOLEAN
+END`},
+			want: []string{"1.asn:3:1: T already defined at 1.asn:2:1"},
+		},
+		{
+			name: "value set and type",
+			sources: []string{`M DEFINITIONS ::= BEGIN
+T ::= INTEGER
+V ::= T
+V T ::= { 1 | 2 }
+END`},
+			want: []string{"1.asn:4:1: V already defined at 1.asn:3:1"},
+		},
+		{
+			name: "components",
+			sources: []string{`M DEFINITIONS ::= BEGIN
+S ::= SEQUENCE { a INTEGER, b BOOLEAN, a NULL }
+C ::= CHOICE { a INTEGER, ..., a BOOLEAN }
+END`},
+			want: []string{
+				"1.asn:2:40: component a already defined at 1.asn:2:18",
+				"1.asn:3:32: component a already defined at 1.asn:3:16",
+			},
+		},
+		{
+			name: "named numbers",
+			sources: []string{`M DEFINITIONS ::= BEGIN
+I ::= INTEGER { one(1), two(2), one(3) }
+B ::= BIT STRING { a(0), b(0) }
+END`},
+			want: []string{
+				"1.asn:2:33: one already defined at 1.asn:2:17",
+				"1.asn:3:26: b reuses the number 0 of a at 1.asn:3:20",
+			},
+		},
+		{
+			name: "shadowed import",
+			sources: []string{`M DEFINITIONS ::= BEGIN
+IMPORTS T, U, T FROM N;
+T ::= BOOLEAN
+END`, `N DEFINITIONS ::= BEGIN
+T ::= INTEGER
+U ::= NULL
+END`},
+			want: []string{
+				"1.asn:2:15: T already imported at 1.asn:2:9",
+				"1.asn:3:1: T shadows the import at 1.asn:2:9",
+			},
+		},
+		{
+			name: "distinct",
+			sources: []string{`M DEFINITIONS ::= BEGIN
+T ::= SEQUENCE { a INTEGER { one(1), two(2) }, b BIT STRING { a(0), b(1) } }
+U ::= CHOICE { a T, b T }
+t T ::= { a 1, b '0'B }
+END`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := link(t, test.sources...)
+			if got := messages(t, err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckNumbers(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name: "valid",
+			source: `M DEFINITIONS ::= BEGIN
+I ::= INTEGER { low(-1), zero(0), high(max) }
+max INTEGER ::= 10
+B ::= BIT STRING { a(0), b(1), c(7) }
+E ::= ENUMERATED { red, green(5), blue, ..., violet(7), indigo }
+F ::= ENUMERATED { a(2), b(1), ..., c(3) }
+END`,
+		},
+		{
+			name: "invalid",
+			source: `M DEFINITIONS ::= BEGIN
+I ::= INTEGER { one(1), also(ten), one(2) }
+ten INTEGER ::= 1
+B ::= BIT STRING { a(0), b(-1), c(0) }
+E ::= ENUMERATED { red(0), green(0) }
+F ::= ENUMERATED { a, b, ..., c(1) }
+G ::= ENUMERATED { a, ..., b(5), c(3) }
+END`,
+			want: []string{
+				"1.asn:2:25: also reuses the number 1 of one at 1.asn:2:17",
+				"1.asn:2:36: one already defined at 1.asn:2:17",
+				"1.asn:4:26: b has the negative bit number -1",
+				"1.asn:4:33: c reuses the number 0 of a at 1.asn:4:20",
+				"1.asn:5:28: green reuses the number 0 of red at 1.asn:5:20",
+				"1.asn:6:31: c reuses the number 1 of b at 1.asn:6:23",
+				"1.asn:7:34: c is numbered 3, not above 5 of b at 1.asn:7:28",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := link(t, test.source)
+			if got := messages(t, err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckConformance(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name: "valid",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+base OBJECT IDENTIFIER ::= { iso(1) member-body(2) 840 }
+arc INTEGER ::= 113549
+pkcs OBJECT IDENTIFIER ::= { base arc 1 }
+Level ::= INTEGER (0..7)
+Small ::= Level (0..3)
+Wide ::= INTEGER (0..3, ...)
+Color ::= ENUMERATED { red, green, blue }
+Settings ::= SEQUENCE { level Small DEFAULT 2, color Color DEFAULT green, on BOOLEAN DEFAULT TRUE }
+defaults Settings ::= { level 1, color blue, on FALSE }
+maximum Level ::= 7
+wide Wide ::= 9
+END`,
+		},
+		{
+			name: "invalid",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+arc INTEGER ::= 113549
+flag BOOLEAN ::= TRUE
+bad OBJECT IDENTIFIER ::= { flag 1 }
+worse OBJECT IDENTIFIER ::= { 1 flag }
+Level ::= INTEGER (0..7)
+Small ::= Level (0..3)
+Color ::= ENUMERATED { red, green, blue }
+Settings ::= SEQUENCE { level Small DEFAULT 5, color Color DEFAULT 1 }
+high Level ::= 8
+higher Small ::= 5
+yes INTEGER ::= TRUE
+defaults Settings ::= { level 9, color purple }
+END`,
+			want: []string{
+				"1.asn:4:29: flag is not an object identifier, its type is at 1.asn:3:6",
+				"1.asn:5:33: flag is not an integer, its type is at 1.asn:3:6",
+				"1.asn:9:45: value 5 is outside the constraint at 1.asn:7:17",
+				"1.asn:9:68: ENUMERATED value expected for the type at 1.asn:8:11",
+				"1.asn:10:16: value 8 is outside the constraint at 1.asn:6:19",
+				"1.asn:11:18: value 5 is outside the constraint at 1.asn:7:17",
+				"1.asn:12:17: INTEGER value expected for the type at 1.asn:12:5",
+				"1.asn:13:31: value 9 is outside the constraint at 1.asn:7:17",
+				"1.asn:13:40: purple is not an item of the ENUMERATED at 1.asn:8:11",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := link(t, test.source)
+			if got := messages(t, err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	program, err := link(t, `Top DEFINITIONS ::= BEGIN
+IMPORTS Message FROM Messages Kind FROM Messages Base FROM Base;
+Top ::= SEQUENCE { message Message, kind Kind, base Base }
+END`, `Messages DEFINITIONS ::= BEGIN
+IMPORTS Header FROM Headers;
+Message ::= SEQUENCE { header Header }
+Kind ::= ENUMERATED { request, response }
+END
+Headers DEFINITIONS ::= BEGIN
+IMPORTS Kind FROM Messages Base FROM Base;
+Header ::= SEQUENCE { kind Kind, base Base }
+END`, `Base DEFINITIONS ::= BEGIN
+Base ::= INTEGER
+END`)
+	if nil != err {
+		t.Fatal(err)
+	}
+	graph := program.DependencyGraph()
+	names := func(modules []*CheckedModule) string {
+		var names []string
+		for _, module := range modules {
+			names = append(names, module.Module.Name)
+		}
+		return strings.Join(names, " ")
+	}
+	imports := map[string]string{}
+	for module, from := range graph.Imports {
+		imports[module.Module.Name] = names(from)
+	}
+	if want := map[string]string{
+		"Top":      "Messages Base",
+		"Messages": "Headers",
+		"Headers":  "Messages Base",
+	}; !reflect.DeepEqual(imports, want) {
+		t.Errorf("got imports %q, want %q", imports, want)
+	}
+	if got, want := names(graph.Order), "Base Messages Headers Top"; got != want {
+		t.Errorf("got order %q, want %q", got, want)
+	}
+	var cycles []string
+	for _, cycle := range graph.Cycles {
+		cycles = append(cycles, names(cycle))
+	}
+	if want := []string{"Messages Headers"}; !reflect.DeepEqual(cycles, want) {
+		t.Errorf("got cycles %q, want %q", cycles, want)
+	}
+	var dot strings.Builder
+	if err := graph.WriteDOT(&dot); nil != err {
+		t.Fatal(err)
+	}
+	want := `digraph modules {
+	"Top";
+	"Messages";
+	"Headers";
+	"Base";
+	"Top" -> "Messages";
+	"Top" -> "Base";
+	"Messages" -> "Headers" [color=red];
+	"Headers" -> "Messages" [color=red];
+	"Headers" -> "Base";
+}
+`
+	if dot.String() != want {
+		t.Errorf("got DOT\n%s\nwant\n%s", dot.String(), want)
+	}
+}
+
+func TestDependencyGraphNGAP(t *testing.T) {
+	program, err := ParseAndLink([]string{"Samples/012/NGAP-PDU-Descriptions.asn1"}, []string{"Samples/012"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	graph := program.DependencyGraph()
+	var order []string
+	for _, module := range graph.Order {
+		order = append(order, module.Module.Name)
+	}
+	want := []string{
+		"NGAP-CommonDataTypes",
+		"NGAP-Constants",
+		"NGAP-Containers",
+		"NGAP-IEs",
+		"NGAP-PDU-Contents",
+		"NGAP-PDU-Descriptions",
+	}
+	if !reflect.DeepEqual(order, want) || len(graph.Cycles) != 0 {
+		t.Errorf("got order %q and %d cycles, want %q and none", order, len(graph.Cycles), want)
+	}
+}
+
+func TestCheckExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		want     []string
+		warnings []string
+	}{
+		{
+			name: "versions",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+S ::= SEQUENCE { a INTEGER, ..., [[ 3: b INTEGER ]], [[ 3: c INTEGER ]], [[ 2: d INTEGER ]] }
+C ::= CHOICE { a INTEGER, ..., [[ 3: b INTEGER ]], [[ e INTEGER ]], [[ 4: c INTEGER ]] }
+END`,
+			want: []string{
+				"1.asn:2:54: version 3 does not follow version 3 at 1.asn:2:34",
+				"1.asn:2:74: version 2 does not follow version 3 at 1.asn:2:54",
+			},
+		},
+		{
+			name: "trailing",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+S ::= SEQUENCE { a INTEGER, ..., b INTEGER, ..., c INTEGER }
+END`,
+			warnings: []string{"1.asn:2:50: root component c follows the extension additions"},
+		},
+		{
+			name: "markers",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+S ::= SEQUENCE { a INTEGER, ..., b INTEGER, ..., c INTEGER, ... }
+END`,
+			want: []string{"1.asn:2:61: more than two extension markers"},
+		},
+		{
+			name: "enumerated",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+E ::= ENUMERATED { a, ..., b, ... }
+END`,
+			want: []string{"1.asn:2:31: duplicate extension marker"},
+		},
+		{
+			name: "addition",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+S ::= SEQUENCE { a INTEGER, [[ 2: b INTEGER ]] }
+END`,
+			want: []string{"1.asn:2:29: extension addition group outside the extension"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			program, err := link(t, test.source)
+			if got := messages(t, err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			if nil == program {
+				return
+			}
+			var warnings []string
+			for _, module := range program.Modules {
+				warnings = append(warnings, messages(t, module.Warnings)...)
+			}
+			if !reflect.DeepEqual(warnings, test.warnings) {
+				t.Errorf("got warnings %q, want %q", warnings, test.warnings)
+			}
+		})
+	}
+}
+
+func TestCheckExtensibilityImplied(t *testing.T) {
+	program, err := link(t, `M DEFINITIONS AUTOMATIC TAGS EXTENSIBILITY IMPLIED ::= BEGIN
+IMPORTS Plain FROM N;
+Status ::= ENUMERATED { idle, busy }
+Report ::= SEQUENCE { status Status, ..., [[ 2: load INTEGER ]] }
+Event ::= CHOICE { report Report, reset NULL }
+Set ::= SET { a INTEGER }
+Fixed ::= INTEGER
+END`, `N DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Plain ::= SEQUENCE { a INTEGER }
+END`)
+	if nil != err {
+		t.Fatal(err)
+	}
+	extensible := map[string]bool{}
+	for _, module := range program.Modules {
+		for _, assignment := range module.Module.Assignments {
+			a, ok := assignment.(*TypeAssignment)
+			if !ok {
+				continue
+			}
+			switch typ := a.Type.(type) {
+			case *EnumeratedType:
+				extensible[a.Name] = typ.Extensible
+			default:
+				if list := componentList(typ); nil != list {
+					extensible[a.Name] = list.Extensible
+				}
+			}
+		}
+	}
+	want := map[string]bool{"Status": true, "Report": true, "Event": true, "Set": true, "Plain": false}
+	if !reflect.DeepEqual(extensible, want) {
+		t.Errorf("got %v, want %v", extensible, want)
+	}
+}
+
+func TestUnderlying(t *testing.T) {
+	set, err := ParseBytes("m.asn", []byte(`M DEFINITIONS EXPLICIT TAGS ::= BEGIN
+IMPORTS Remote FROM N;
+A ::= B (1..10)
+B ::= C (0..100)
+C ::= INTEGER
+Tagged ::= [1] IMPLICIT Inner
+Inner ::= [APPLICATION 2] INTEGER (0..7)
+Small INTEGER ::= { 1 | 2 }
+Picked ::= Small (1)
+Message ::= CHOICE { request A, response BOOLEAN }
+Selected ::= request < Message
+Far ::= Remote
+Next ::= Next2
+Next2 ::= Next
+END`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	module, _ := Check(set.Modules[0])
+	if nil == module {
+		t.Fatal("no checked module")
+	}
+	types := map[string]Type{}
+	for _, assignment := range set.Modules[0].Assignments {
+		if a, ok := assignment.(*TypeAssignment); ok {
+			types[a.Name] = a.Type
+		}
+	}
+	tests := []struct {
+		name        string
+		builtin     string
+		constraints []string
+		tags        []string
+		err         string
+	}{
+		{name: "A", builtin: "*asn1c_go.IntegerType", constraints: []string{"0..100", "1..10"}},
+		{name: "Tagged", builtin: "*asn1c_go.IntegerType", constraints: []string{"0..7"}, tags: []string{"[APPLICATION 2]", "[1] IMPLICIT"}},
+		{name: "Picked", builtin: "*asn1c_go.IntegerType", constraints: []string{"1 | 2", "1"}},
+		{name: "Selected", builtin: "*asn1c_go.IntegerType", constraints: []string{"0..100", "1..10"}},
+		{name: "Far", err: "m.asn:12:9: Remote is imported from N, which is not linked"},
+		{name: "Next", err: "m.asn:13:10: Next2 is defined in terms of itself"},
+	}
+	for _, test := range tests {
+		builtin, constraints, tags, err := module.Underlying(types[test.name])
+		if nil != err || len(test.err) != 0 {
+			if fmt.Sprint(err) != test.err {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		var texts []string
+		for _, constraint := range constraints {
+			texts = append(texts, constraintText(constraint))
+		}
+		var tagTexts []string
+		for _, tag := range tags {
+			text := strings.TrimSpace(fmt.Sprintf("[%s %s] %s", tag.Class, defaultText(tag.Number), tag.Mode))
+			tagTexts = append(tagTexts, strings.Replace(text, "[ ", "[", 1))
+		}
+		got := fmt.Sprintf("%T", builtin)
+		if got != test.builtin || !reflect.DeepEqual(texts, test.constraints) || !reflect.DeepEqual(tagTexts, test.tags) {
+			t.Errorf("%s: got %s %q %q, want %s %q %q", test.name, got, texts, tagTexts, test.builtin, test.constraints, test.tags)
+		}
+	}
+}
+
+func TestProgramUnderlying(t *testing.T) {
+	program, err := link(t, `M DEFINITIONS ::= BEGIN
+IMPORTS Remote FROM N;
+Far ::= Remote (1..5)
+END`, `N DEFINITIONS ::= BEGIN
+Remote ::= [APPLICATION 3] INTEGER (0..9)
+END`)
+	if nil != err {
+		t.Fatal(err)
+	}
+	far := program.Modules[0].Module.Lookup("Far").(*TypeAssignment)
+	builtin, constraints, tags, err := program.Underlying(far.Type)
+	if nil != err {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, constraint := range constraints {
+		texts = append(texts, constraintText(constraint))
+	}
+	if _, ok := builtin.(*IntegerType); !ok || !reflect.DeepEqual(texts, []string{"0..9", "1..5"}) || len(tags) != 1 || tags[0].Class != TagClassApplication {
+		t.Errorf("got %T %q %v", builtin, texts, tags)
+	}
+}
@@ -0,0 +1,448 @@
+package asn1c_go
+
+import (
+	"strings"
+)
+
+type FieldKind int
+
+const (
+	TypeField FieldKind = iota
+	FixedTypeValueField
+	VariableTypeValueField
+	FixedTypeValueSetField
+	VariableTypeValueSetField
+	ObjectField
+	ObjectSetField
+)
+
+func (k FieldKind) String() string {
+	switch k {
+	case TypeField:
+		return "type field"
+	case FixedTypeValueField:
+		return "fixed-type value field"
+	case VariableTypeValueField:
+		return "variable-type value field"
+	case FixedTypeValueSetField:
+		return "fixed-type value set field"
+	case VariableTypeValueSetField:
+		return "variable-type value set field"
+	case ObjectField:
+		return "object field"
+	case ObjectSetField:
+		return "object set field"
+	}
+	return "unknown field"
+}
+
+// Setting is whatever may be assigned to a field, only the member matching
+// the field kind being set.
+type Setting struct {
+	Position  Position           `json:"position"`
+	Type      Type               `json:"type"`
+	Value     Value              `json:"value"`
+	ValueSet  *ValueSet          `json:"valueSet"`
+	Object    *InformationObject `json:"object"`
+	ObjectSet *ObjectSet         `json:"objectSet"`
+}
+
+type FieldSpec struct {
+	Position  Position  `json:"position"`
+	Name      string    `json:"name"`
+	Kind      FieldKind `json:"kind"`
+	Type      Type      `json:"type"`
+	TypeField []string  `json:"typeField"`
+	Class     string    `json:"class"`
+	Unique    bool      `json:"unique"`
+	Optional  bool      `json:"optional"`
+	Default   *Setting  `json:"default"`
+}
+
+// SyntaxElement is one item of a WITH SYNTAX list: a literal word or comma,
+// a field name, or an optional group of further elements.
+type SyntaxElement struct {
+	Position Position         `json:"position"`
+	Literal  string           `json:"literal"`
+	Field    string           `json:"field"`
+	Group    []*SyntaxElement `json:"group"`
+}
+
+// ObjectClass is either a class definition or, when Reference is set, a
+// use of another class under a new name.
+type ObjectClass struct {
+	Position  Position         `json:"position"`
+	Reference string           `json:"reference"`
+	Fields    []*FieldSpec     `json:"fields"`
+	Syntax    []*SyntaxElement `json:"syntax"`
+}
+
+func (c *ObjectClass) Pos() Position { return c.Position }
+
+func (c *ObjectClass) Field(name string) *FieldSpec {
+	for _, field := range c.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// ObjectClassFieldType is the type of a class field, written as
+// CLASS.&field, Field holding the path through object fields.
+type ObjectClassFieldType struct {
+	TypeBase `json:"base"`
+	Class    string   `json:"class"`
+	Field    []string `json:"field"`
+}
+
+// builtinObjectClasses are the classes X.681 and X.680 define for every
+// module, written in their own notation.
+var builtinObjectClasses = map[string]*ObjectClass{
+	TypeIdentifier: mustParseObjectClass(`CLASS {
+		&id OBJECT IDENTIFIER UNIQUE,
+		&Type
+	} WITH SYNTAX { &Type IDENTIFIED BY &id }`),
+	AbstractSyntax: mustParseObjectClass(`CLASS {
+		&id OBJECT IDENTIFIER UNIQUE,
+		&Type,
+		&property BIT STRING { handles-invalid-encodings(0) } DEFAULT {}
+	} WITH SYNTAX { &Type IDENTIFIED BY &id [HAS PROPERTY &property] }`),
+}
+
+func mustParseObjectClass(src string) *ObjectClass {
+	tokens, err := Tokenize("", []byte(src))
+	if nil != err {
+		panic(err)
+	}
+	class, err := newParser(tokens).parseObjectClassDefinition()
+	if nil != err {
+		panic(err)
+	}
+	return class
+}
+
+// ObjectClass returns the definition of the class name refers to in m,
+// following class references, or nil when it is not defined in m.
+func (m *ModuleDefinition) ObjectClass(name string) *ObjectClass {
+	class, _ := m.objectClass(name)
+	return class
+}
+
+// objectClass follows class references within m. When they lead out of m
+// it returns the last name reached instead of a definition.
+func (m *ModuleDefinition) objectClass(name string) (*ObjectClass, string) {
+	for i := 0; i <= len(m.Assignments); i++ {
+		if class, ok := builtinObjectClasses[name]; ok {
+			return class, name
+		}
+		assignment, ok := m.Lookup(name).(*ObjectClassAssignment)
+		if !ok {
+			return nil, name
+		}
+		if len(assignment.Class.Reference) == 0 {
+			return assignment.Class, name
+		}
+		name = assignment.Class.Reference
+	}
+	return nil, name
+}
+
+// lookupObjectClass resolves the class name refers to in module, following
+// imports into the other modules.
+func lookupObjectClass(modules []*ModuleDefinition, module *ModuleDefinition, name string) *ObjectClass {
+	for i := 0; i <= len(modules) && nil != module; i++ {
+		class, last := module.objectClass(name)
+		if nil != class {
+			return class
+		}
+		imported := module.LookupImport(last)
+		if nil == imported {
+			return nil
+		}
+		module, name = nil, last
+		for _, candidate := range modules {
+			if candidate.Name == imported.Module {
+				module = candidate
+			}
+		}
+	}
+	return nil
+}
+
+// pendingAlias is an assignment of a reference not assigned in its module,
+// read as a type assignment until the reference is found to name a class.
+type pendingAlias struct {
+	assignment *TypeAssignment
+	module     *ModuleDefinition
+}
+
+// defineImportedClasses turns the pending aliases of classes imported from
+// one of modules into class assignments, and reports whether it turned any.
+// Others stay pending, an alias of an alias waiting for the latter.
+func (p *parser) defineImportedClasses(modules []*ModuleDefinition) bool {
+	var (
+		remaining []*pendingAlias
+		changed   bool
+	)
+	for _, alias := range *p.aliases {
+		a := alias.assignment
+		ref := a.Type.(*ReferencedType)
+		if nil == lookupObjectClass(modules, alias.module, ref.Name) {
+			remaining = append(remaining, alias)
+			continue
+		}
+		class := &ObjectClassAssignment{
+			Position: a.Position,
+			Name:     a.Name,
+			Class:    &ObjectClass{Position: ref.Position, Reference: ref.Name},
+			Doc:      a.Doc,
+			Comment:  a.Comment,
+		}
+		for i, assignment := range alias.module.Assignments {
+			if assignment == Assignment(a) {
+				alias.module.Assignments[i] = class
+			}
+		}
+		changed = true
+	}
+	*p.aliases = remaining
+	return changed
+}
+
+// IsObjectClassReference reports whether name is lexically usable as an
+// object class reference, which X.681 restricts to upper-case letters,
+// digits and hyphens.
+func IsObjectClassReference(name string) bool {
+	if len(name) == 0 || !(name[0] >= 'A' && name[0] <= 'Z') {
+		return false
+	}
+	return strings.ToUpper(name) == name
+}
+
+func (p *parser) isClassReference(name string) bool {
+	if name == TypeIdentifier || name == AbstractSyntax {
+		return true
+	}
+	if p.classes[name] {
+		return true
+	}
+	return !p.defined[name] && name != Any && IsObjectClassReference(name)
+}
+
+// isClassAt reports whether the n-th token ahead names an object class and
+// is not the start of a field reference such as CLASS.&field.
+func (p *parser) isClassAt(n int) bool {
+	tok := p.peek(n)
+	switch {
+	case tok.Kind == TokenKeyword:
+		if tok.Text != TypeIdentifier && tok.Text != AbstractSyntax {
+			return false
+		}
+	case tok.Kind != TokenTypeReference:
+		return false
+	}
+	return p.isClassReference(tok.Text) && !p.isAt(n+1, ".")
+}
+
+func (p *parser) parseObjectClassDefinition() (*ObjectClass, error) {
+	start, err := p.expect(Class)
+	if nil != err {
+		return nil, err
+	}
+	if _, err := p.expect("{"); nil != err {
+		return nil, err
+	}
+	class := &ObjectClass{Position: start.Position}
+	for {
+		field, err := p.parseFieldSpec()
+		if nil != err {
+			return nil, err
+		}
+		if nil != class.Field(field.Name) {
+			return nil, p.errorf(field.Position, "duplicate field %s in class", field.Name)
+		}
+		class.Fields = append(class.Fields, field)
+		if p.accept(",") {
+			continue
+		}
+		if _, err := p.expect("}"); nil != err {
+			return nil, err
+		}
+		break
+	}
+	if p.is(With) && p.isAt(1, Syntax) {
+		p.index += 2
+		class.Syntax, err = p.parseSyntaxList()
+		if nil != err {
+			return nil, err
+		}
+	}
+	return class, nil
+}
+
+func (p *parser) atFieldSpecEnd() bool {
+	return p.is(",") || p.is("}") || p.is(Optional) || p.is(Default)
+}
+
+func isFieldReference(tok Token) bool {
+	return tok.Kind == TokenTypeFieldReference || tok.Kind == TokenValueFieldReference
+}
+
+func (p *parser) parseFieldName() ([]string, error) {
+	var names []string
+	for {
+		tok := p.next()
+		if !isFieldReference(tok) {
+			return nil, p.errorf(tok.Position, "expected field name, found %s", tok)
+		}
+		names = append(names, tok.Text)
+		if !p.is(".") || !isFieldReference(p.peek(1)) {
+			return names, nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) parseFieldSpec() (*FieldSpec, error) {
+	var (
+		tok   = p.next()
+		field = &FieldSpec{Position: tok.Position, Name: tok.Text}
+		err   error
+	)
+	switch tok.Kind {
+	case TokenTypeFieldReference:
+		switch {
+		case p.atFieldSpecEnd():
+			field.Kind = TypeField
+		case isFieldReference(p.peek(0)):
+			field.Kind = VariableTypeValueSetField
+			field.TypeField, err = p.parseFieldName()
+		case p.isClassAt(0):
+			field.Kind = ObjectSetField
+			field.Class = p.next().Text
+		default:
+			field.Kind = FixedTypeValueSetField
+			field.Type, err = p.parseType()
+		}
+	case TokenValueFieldReference:
+		switch {
+		case isFieldReference(p.peek(0)):
+			field.Kind = VariableTypeValueField
+			field.TypeField, err = p.parseFieldName()
+		case p.isClassAt(0):
+			field.Kind = ObjectField
+			field.Class = p.next().Text
+		default:
+			field.Kind = FixedTypeValueField
+			field.Type, err = p.parseType()
+			if nil == err && p.accept(Unique) {
+				field.Unique = true
+			}
+		}
+	default:
+		return nil, p.errorf(tok.Position, "expected field name, found %s", tok)
+	}
+	if nil != err {
+		return nil, err
+	}
+	if p.accept(Optional) {
+		field.Optional = true
+	} else if p.is(Default) {
+		p.next()
+		field.Default, err = p.parseSetting(field)
+		if nil != err {
+			return nil, err
+		}
+	}
+	if field.Unique && field.Default != nil {
+		return nil, p.errorf(field.Position, "UNIQUE field %s cannot have a default", field.Name)
+	}
+	return field, nil
+}
+
+func (p *parser) parseObjectClassFieldType() (Type, error) {
+	class := p.next()
+	if _, err := p.expect("."); nil != err {
+		return nil, err
+	}
+	field, err := p.parseFieldName()
+	if nil != err {
+		return nil, err
+	}
+	return &ObjectClassFieldType{
+		TypeBase: TypeBase{Position: class.Position},
+		Class:    class.Text,
+		Field:    field,
+	}, nil
+}
+
+func (p *parser) parseSetting(field *FieldSpec) (*Setting, error) {
+	var (
+		setting = &Setting{Position: p.peek(0).Position}
+		err     error
+	)
+	switch field.Kind {
+	case TypeField:
+		setting.Type, err = p.parseType()
+	case FixedTypeValueField:
+		setting.Value, err = p.parseValueOf(field.Type)
+	case VariableTypeValueField:
+		setting.Value, err = p.parseValue()
+	case FixedTypeValueSetField, VariableTypeValueSetField:
+		setting.ValueSet, err = p.parseValueSet()
+	case ObjectField:
+		setting.Object, err = p.parseObject(field.Class)
+	case ObjectSetField:
+		setting.ObjectSet, err = p.parseObjectSet(field.Class)
+	}
+	if nil != err {
+		return nil, err
+	}
+	return setting, nil
+}
+
+func (p *parser) parseSyntaxList() ([]*SyntaxElement, error) {
+	if _, err := p.expect("{"); nil != err {
+		return nil, err
+	}
+	elements, err := p.parseSyntaxElements("}")
+	if nil != err {
+		return nil, err
+	}
+	if _, err := p.expect("}"); nil != err {
+		return nil, err
+	}
+	return elements, nil
+}
+
+func (p *parser) parseSyntaxElements(end string) ([]*SyntaxElement, error) {
+	var elements []*SyntaxElement
+	for !p.is(end) {
+		tok := p.peek(0)
+		switch {
+		case tok.Kind == TokenSymbol && tok.Text == "[":
+			p.next()
+			group, err := p.parseSyntaxElements("]")
+			if nil != err {
+				return nil, err
+			}
+			if _, err := p.expect("]"); nil != err {
+				return nil, err
+			}
+			if len(group) == 0 {
+				return nil, p.errorf(tok.Position, "empty optional group in syntax list")
+			}
+			elements = append(elements, &SyntaxElement{Position: tok.Position, Group: group})
+		case isFieldReference(tok):
+			p.next()
+			elements = append(elements, &SyntaxElement{Position: tok.Position, Field: tok.Text})
+		case tok.Kind == TokenTypeReference || tok.Kind == TokenKeyword || (tok.Kind == TokenSymbol && tok.Text == ","):
+			p.next()
+			elements = append(elements, &SyntaxElement{Position: tok.Position, Literal: tok.Text})
+		default:
+			return nil, p.errorf(tok.Position, "unexpected %s in syntax list", tok)
+		}
+	}
+	return elements, nil
+}
@@ -0,0 +1,74 @@
+package asn1c_go
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassAliases(t *testing.T) {
+	const imported = `N DEFINITIONS ::= BEGIN
+RRC-PDU ::= INTEGER
+BASE-CLASS ::= CLASS { &id INTEGER UNIQUE, &Type }
+END`
+	tests := []struct {
+		name   string
+		source string
+		want   map[string]string
+		errors []string
+	}{
+		{
+			name: "imported type",
+			source: `M DEFINITIONS ::= BEGIN
+IMPORTS RRC-PDU FROM N;
+MY-PDU ::= RRC-PDU
+T ::= SEQUENCE { pdu MY-PDU }
+v MY-PDU ::= 5
+END`,
+			want: map[string]string{"MY-PDU": "*asn1c_go.TypeAssignment", "v": "*asn1c_go.ValueAssignment"},
+		},
+		{
+			name: "imported class",
+			source: `M DEFINITIONS ::= BEGIN
+IMPORTS BASE-CLASS FROM N;
+MY-CLASS ::= BASE-CLASS
+OTHER ::= MY-CLASS
+object OTHER ::= { &id 1, &Type INTEGER }
+Objects MY-CLASS ::= { object | { &id 2, &Type BOOLEAN } }
+T ::= SEQUENCE { id MY-CLASS.&id ({Objects}) }
+END`,
+			want: map[string]string{
+				"MY-CLASS": "*asn1c_go.ObjectClassAssignment",
+				"OTHER":    "*asn1c_go.ObjectClassAssignment",
+				"object":   "*asn1c_go.ObjectAssignment",
+				"Objects":  "*asn1c_go.ObjectSetAssignment",
+			},
+		},
+		{
+			name: "class used as a type",
+			source: `M DEFINITIONS ::= BEGIN
+IMPORTS BASE-CLASS FROM N;
+MY-CLASS ::= BASE-CLASS
+T ::= SEQUENCE { a MY-CLASS, b MY-CLASS }
+END`,
+			want: map[string]string{"MY-CLASS": "*asn1c_go.ObjectClassAssignment"},
+			errors: []string{
+				"1.asn:4:20: MY-CLASS is not a type",
+				"1.asn:4:32: MY-CLASS is not a type",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			program, err := link(t, test.source, imported)
+			if got := messages(t, err); !reflect.DeepEqual(got, test.errors) {
+				t.Errorf("got errors %q, want %q", got, test.errors)
+			}
+			module := program.Module("M").Module
+			for name, want := range test.want {
+				if got := reflect.TypeOf(module.Lookup(name)).String(); got != want {
+					t.Errorf("%s is a %s, want %s", name, got, want)
+				}
+			}
+		})
+	}
+}
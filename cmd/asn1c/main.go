@@ -4,21 +4,107 @@ import (
 	"flag"
 	"fmt"
 	asn1c "github.com/thebagchi/asn1c-go"
+	"github.com/thebagchi/asn1c-go/codegen"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
+// paths collects the values of a flag given several times.
+type paths []string
+
+func (p *paths) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *paths) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
 func main() {
 	var (
 		filename = flag.String("file", "", "Abstract Syntax Notation 1 file")
+		dump     = flag.Bool("json", false, "print the modules as JSON")
+		format   = flag.Bool("print", false, "print the modules as formatted ASN.1")
+		output   = flag.String("go", "", "directory to write Go types for the modules to")
+		pkg      = flag.String("package", "", "package name of the Go types")
+		single   = flag.Bool("single", false, "write the Go types to one file")
+		tag      = flag.String("tag", "", "build constraint of the Go files")
+		tags     = flag.Bool("jsontags", false, "add JSON tags and marshalers to the Go types")
+		arrays   = flag.Bool("arrays", false, "make OCTET STRINGs of one size arrays in Go")
+		presence = flag.String("presence", "", "class field giving the presence of objects, &presence by default")
+		required = flag.String("mandatory", "", "item of the presence field of mandatory objects, mandatory by default")
+		includes paths
 	)
+	flag.Var(&includes, "include", "directory to look for imported modules in")
 	flag.Parse()
 	if len(*filename) == 0 {
 		fmt.Println("Error: ", "input asn1 file required ...")
 		os.Exit(0)
 	}
-	err := asn1c.Parse(*filename)
+	files := append([]string{*filename}, flag.Args()...)
+	program, err := asn1c.ParseAndLink(files, includes)
+	if errors, ok := err.(asn1c.ErrorList); ok {
+		for _, err := range errors {
+			fmt.Println("Error: ", err)
+		}
+		os.Exit(0)
+	}
 	if nil != err {
 		fmt.Println("Error: ", err)
 		os.Exit(0)
 	}
+	for _, module := range program.Modules {
+		for _, warning := range module.Warnings {
+			fmt.Fprintln(os.Stderr, "Warning: ", warning)
+		}
+	}
+	if len(*output) != 0 {
+		files, err := (&codegen.Generator{}).Generate(program, codegen.Options{
+			Package:    *pkg,
+			SingleFile: *single,
+			BuildTag:   *tag,
+			JSON:       *tags,
+			Arrays:     *arrays,
+			Presence:   *presence,
+			Mandatory:  *required,
+		})
+		if nil != err {
+			fmt.Println("Error: ", err)
+			os.Exit(0)
+		}
+		if err := os.MkdirAll(*output, 0755); nil != err {
+			fmt.Println("Error: ", err)
+			os.Exit(0)
+		}
+		for name, source := range files {
+			if err := ioutil.WriteFile(filepath.Join(*output, name), source, 0644); nil != err {
+				fmt.Println("Error: ", err)
+				os.Exit(0)
+			}
+		}
+		return
+	}
+	for _, module := range program.Modules {
+		if *dump {
+			if err := asn1c.EncodeJSON(os.Stdout, module.Module); nil != err {
+				fmt.Println("Error: ", err)
+				os.Exit(0)
+			}
+			continue
+		}
+		if *format {
+			if err := program.Fprint(os.Stdout, module.Module); nil != err {
+				fmt.Println("Error: ", err)
+				os.Exit(0)
+			}
+			continue
+		}
+		fmt.Println(module.Module.Name)
+		for _, assignment := range module.Module.Assignments {
+			fmt.Println("\t" + assignment.Reference())
+		}
+	}
 }
@@ -0,0 +1,44 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	asn1c "github.com/thebagchi/asn1c-go"
+)
+
+// choice writes the discriminators of t, the CHOICE named name: a constant
+// for each alternative, numbered from 1 in the order they are written,
+// root alternatives first, a Choice method telling which alternative is
+// set and a constructor for each, named after the type and the
+// alternative.
+func (g *generator) choice(t *asn1c.ChoiceType, name string) {
+	var (
+		b         = &g.file.body
+		fields    = g.fields[&t.ComponentList]
+		constants = make([]string, len(fields))
+	)
+	fmt.Fprintf(b, "\nconst (\n")
+	for i, field := range fields {
+		constants[i] = g.unique(name + "Choice" + field.name)
+		fmt.Fprintf(b, "%s = %d\n", constants[i], i+1)
+	}
+	fmt.Fprintf(b, ")\n")
+
+	fmt.Fprintf(b, "\n// Choice returns the constant of the alternative set in v, 0 when none\n// is.\n")
+	fmt.Fprintf(b, "func (v %s) Choice() int {\nswitch {\n", name)
+	for i, field := range fields {
+		fmt.Fprintf(b, "case nil != v.%s:\nreturn %s\n", field.name, constants[i])
+	}
+	fmt.Fprintf(b, "}\nreturn 0\n}\n")
+
+	for _, field := range fields {
+		constructor := g.unique("New" + name + field.name)
+		typ, value := g.goType(field.component.Type, name+goName(field.component.Name)), "&v"
+		if field.open || strings.HasPrefix(typ, "*") {
+			value = "v"
+		}
+		fmt.Fprintf(b, "\n// %s returns the %s with its %s alternative set to v.\n", constructor, name, field.component.Name)
+		fmt.Fprintf(b, "func %s(v %s) %s {\nreturn %s{%s: %s}\n}\n", constructor, typ, name, name, field.name, value)
+	}
+}
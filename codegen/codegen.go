@@ -0,0 +1,811 @@
+// Package codegen writes Go types for the types of the modules of a linked
+// program.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/build/constraint"
+	"go/format"
+	"go/token"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	asn1c "github.com/thebagchi/asn1c-go"
+)
+
+// Version is the version of the generator, written in the header of the
+// files it generates and raised whenever a change to it changes them.
+const Version = 1
+
+// Options controls what Generate writes.
+type Options struct {
+	// Package is the name of the package of the files, by default the name
+	// of the first module in lower case, letters and digits only.
+	Package string
+	// SingleFile puts everything in one file, named after the package,
+	// rather than a file for each module and one for what they share.
+	SingleFile bool
+	// BuildTag is the build constraint of the files, none when empty.
+	BuildTag string
+	// JSON adds JSON tags to the fields of structs, and MarshalJSON methods
+	// to BIT STRING, OCTET STRING and ENUMERATED types, those written
+	// within others declared by names of their own to have them.
+	JSON bool
+	// Arrays makes an OCTET STRING of one size only, by a constraint that
+	// is not extensible, an array of that many bytes rather than a slice.
+	// It is the one choice of the Go type of an ASN.1 type there is: no
+	// option maps types to Go types of the caller's, as Validate, the
+	// DEFAULT values and MarshalJSON are written for the generated ones.
+	Arrays bool
+	// Presence is the field of a class giving whether the objects of its
+	// sets must be present, &presence when empty, and Mandatory the item
+	// of the ENUMERATED type of the field saying they must, mandatory when
+	// empty. The table of a set whose class has no such field, or whose
+	// field is of another type or lacks the item, has no Missing method.
+	Presence  string
+	Mandatory string
+}
+
+// Generator writes the types of a program as Go.
+type Generator struct{}
+
+// Generate returns a Go file for each module of program, keyed by its name,
+// and one of what the others share. The files are of one package, formatted
+// and the same on every run. Each type assignment becomes a named Go type
+// with a Validate method, values and object sets of some types constants
+// and tables.
+func (g *Generator) Generate(program *asn1c.Program, opts Options) (map[string][]byte, error) {
+	name := opts.Package
+	if len(name) == 0 && len(program.Modules) != 0 {
+		name = packageName(program.Modules[0].Module.Name)
+	}
+	if !token.IsIdentifier(name) || token.IsKeyword(name) {
+		return nil, fmt.Errorf("invalid package name %q", name)
+	}
+	header := "// Code generated by asn1c-go (codegen version " + strconv.Itoa(Version) + ")%s. DO NOT EDIT.\n\n"
+	if len(opts.BuildTag) != 0 {
+		if _, err := constraint.Parse("//go:build " + opts.BuildTag); nil != err || strings.ContainsAny(opts.BuildTag, "\r\n") {
+			return nil, fmt.Errorf("invalid build tag %q", opts.BuildTag)
+		}
+		header += "//go:build " + opts.BuildTag + "\n\n"
+	}
+	gen := &generator{
+		program:   program,
+		names:     map[string]bool{},
+		named:     map[asn1c.Type]string{},
+		assigned:  map[asn1c.Assignment]string{},
+		fields:    map[*asn1c.ComponentList][]structField{},
+		items:     map[*asn1c.EnumeratedType]map[string]string{},
+		tables:    map[*asn1c.ObjectClass]*table{},
+		arrays:    opts.Arrays,
+		json:      opts.JSON,
+		presence:  opts.Presence,
+		mandatory: opts.Mandatory,
+	}
+	if len(gen.presence) == 0 {
+		gen.presence = "&presence"
+	}
+	if len(gen.mandatory) == 0 {
+		gen.mandatory = "mandatory"
+	}
+	for _, reserved := range []string{"Violation", "Violations", "ASN1Names"} {
+		gen.names[reserved] = true
+	}
+	gen.assign()
+	// The tables of object sets come after every type is declared, as they
+	// name the constants of the items of enumerated types.
+	modules := make([]*file, len(program.Modules))
+	for i, module := range program.Modules {
+		if !opts.SingleFile || i == 0 {
+			gen.file = &file{imports: map[string]bool{}}
+		}
+		gen.types(module)
+		modules[i] = gen.file
+	}
+	for i, module := range program.Modules {
+		gen.file = modules[i]
+		gen.objectSets(module)
+		gen.defaultValues()
+		gen.flush()
+	}
+	files := map[string][]byte{}
+	if len(program.Modules) == 0 {
+		return files, gen.errors.Err()
+	}
+	if opts.SingleFile {
+		gen.runtime()
+		var names []string
+		for _, module := range program.Modules {
+			names = append(names, module.Module.Name)
+		}
+		source, err := gen.file.source(fmt.Sprintf(header, " from "+strings.Join(names, ", ")), name)
+		if nil != err {
+			return nil, err
+		}
+		files[fileName(name)] = source
+		return files, gen.errors.Err()
+	}
+	for i, module := range program.Modules {
+		filename := fileName(module.Module.Name)
+		for i := 2; nil != files[filename]; i++ {
+			filename = fileName(module.Module.Name + "-" + strconv.Itoa(i))
+		}
+		source, err := modules[i].source(fmt.Sprintf(header, " from "+module.Module.Name), name)
+		if nil != err {
+			return nil, fmt.Errorf("%s: %v", module.Module.Name, err)
+		}
+		files[filename] = source
+	}
+	filename := "validate.go"
+	for i := 2; nil != files[filename]; i++ {
+		filename = "validate" + strconv.Itoa(i) + ".go"
+	}
+	gen.file = &file{imports: map[string]bool{}}
+	gen.runtime()
+	source, err := gen.file.source(fmt.Sprintf(header, ""), name)
+	if nil != err {
+		return nil, err
+	}
+	files[filename] = source
+	return files, gen.errors.Err()
+}
+
+type generator struct {
+	program  *asn1c.Program
+	names    map[string]bool
+	named    map[asn1c.Type]string
+	assigned map[asn1c.Assignment]string
+	fields   map[*asn1c.ComponentList][]structField
+	items    map[*asn1c.EnumeratedType]map[string]string
+	tables   map[*asn1c.ObjectClass]*table
+	file     *file
+	errors   asn1c.ErrorList
+	// big is set when a check compares with an integer beyond an int64.
+	big    bool
+	arrays bool
+	json   bool
+	// presence and mandatory are those of Options, defaults filled in.
+	presence  string
+	mandatory string
+}
+
+// file is the Go file of a module being written. Pending holds the types
+// met within others, written after them, and defaults the DEFAULT values
+// written after every type.
+type file struct {
+	body     bytes.Buffer
+	imports  map[string]bool
+	pending  []declaration
+	defaults []defaultValue
+}
+
+type declaration struct {
+	name string
+	typ  asn1c.Type
+	doc  string
+	// set is the set of a value set assignment.
+	set *asn1c.Constraint
+}
+
+// structField is a field of the struct of a SEQUENCE, SET or CHOICE.
+// Optional is set for OPTIONAL and DEFAULT components and extension
+// additions, and open for those held in an interface{}.
+type structField struct {
+	name      string
+	component *asn1c.ComponentType
+	optional  bool
+	open      bool
+}
+
+func (g *generator) errorf(pos asn1c.Position, format string, args ...interface{}) {
+	g.errors = append(g.errors, &asn1c.Error{Position: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// generated lists the assignments of module that become Go types.
+func generated(module *asn1c.CheckedModule) []asn1c.Assignment {
+	var assignments []asn1c.Assignment
+	for _, assignment := range module.Module.Assignments {
+		switch a := assignment.(type) {
+		case *asn1c.TypeAssignment:
+			if len(a.Parameters) == 0 {
+				assignments = append(assignments, a)
+			}
+		case *asn1c.ValueSetAssignment:
+			assignments = append(assignments, a)
+		}
+	}
+	return assignments
+}
+
+// assign names the types of every module before any is written, so that
+// references find them. An assignment whose name maps to the Go name of
+// one defined before it, or to a reserved name, gets that name followed by
+// the least number from 2 not taken, the modules and their assignments
+// taken in order so that the numbers are the same on every run. The
+// constants of values and the tables of object sets are named after the
+// types, told apart from them the same way.
+func (g *generator) assign() {
+	var clashing []asn1c.Assignment
+	for _, module := range g.program.Modules {
+		for _, assignment := range generated(module) {
+			name := goName(assignment.Reference())
+			if g.names[name] {
+				clashing = append(clashing, assignment)
+				continue
+			}
+			g.names[name] = true
+			g.assigned[assignment] = name
+		}
+	}
+	for _, assignment := range clashing {
+		g.assigned[assignment] = g.unique(goName(assignment.Reference()))
+	}
+	for _, module := range g.program.Modules {
+		for _, assignment := range g.constants(module) {
+			g.assigned[assignment] = g.unique(goName(assignment.Name))
+		}
+	}
+	for _, module := range g.program.Modules {
+		for _, assignment := range module.Module.Assignments {
+			if a, ok := assignment.(*asn1c.ObjectSetAssignment); ok && nil != g.table(a.Class) {
+				g.assigned[a] = g.unique(goName(a.Name))
+			}
+		}
+	}
+}
+
+// unique returns name, or name followed by the least number from 2 making
+// it a name not yet taken, and takes it.
+func (g *generator) unique(name string) string {
+	candidate := name
+	for i := 2; g.names[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	g.names[candidate] = true
+	return candidate
+}
+
+// types declares the types and constants of module.
+func (g *generator) types(module *asn1c.CheckedModule) {
+	g.values(module)
+	for _, assignment := range generated(module) {
+		name, ok := g.assigned[assignment]
+		if !ok {
+			continue
+		}
+		switch a := assignment.(type) {
+		case *asn1c.TypeAssignment:
+			g.named[a.Type] = name
+			g.declare(declaration{name: name, typ: a.Type, doc: note(a.Doc, a.Comment)})
+		case *asn1c.ValueSetAssignment:
+			set := &asn1c.Constraint{Position: a.Set.Position, ElementSetSpecs: a.Set.ElementSetSpecs}
+			g.declare(declaration{name: name, typ: a.Type, doc: note(a.Doc, a.Comment), set: set})
+		}
+		g.flush()
+	}
+}
+
+// flush declares the types met within others.
+func (g *generator) flush() {
+	for len(g.file.pending) != 0 {
+		next := g.file.pending[0]
+		g.file.pending = g.file.pending[1:]
+		g.declare(next)
+	}
+}
+
+// source returns the formatted Go source of f, in package pkg, after
+// header.
+func (f *file) source(header, pkg string) ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%spackage %s\n", header, pkg)
+	if len(f.imports) != 0 {
+		var paths []string
+		for path := range f.imports {
+			paths = append(paths, strconv.Quote(path))
+		}
+		sort.Strings(paths)
+		if len(paths) == 1 {
+			fmt.Fprintf(&out, "\nimport %s\n", paths[0])
+		} else {
+			fmt.Fprintf(&out, "\nimport (\n%s\n)\n", strings.Join(paths, "\n"))
+		}
+	}
+	out.Write(f.body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+func (g *generator) declare(d declaration) {
+	definition, t := g.definition(d.typ, d.name)
+	// Go declares no methods on a pointer type, so a type of its own embeds
+	// the *big.Int of an INTEGER beyond a uint64, missing when nil.
+	if definition == "*big.Int" {
+		definition = "struct {\n*big.Int\n}"
+	}
+	g.file.body.WriteString("\n")
+	comment(&g.file.body, d.doc)
+	fmt.Fprintf(&g.file.body, "type %s %s\n", d.name, definition)
+	switch t := t.(type) {
+	case *asn1c.SequenceType:
+		g.defaults(&t.ComponentList, d.name)
+	case *asn1c.SetType:
+		g.defaults(&t.ComponentList, d.name)
+	case *asn1c.ChoiceType:
+		g.choice(t, d.name)
+	case *asn1c.EnumeratedType:
+		g.enumerated(t, d.name)
+	case *asn1c.SequenceOfType, *asn1c.SetOfType:
+		g.bounded(d, definition)
+	case *asn1c.BitStringType:
+		g.bits(d)
+	case *asn1c.IntegerType:
+		if len(t.NamedNumbers) != 0 {
+			g.namedNumbers(t, d.name, definition)
+		}
+	}
+	if g.integerOf(d.typ) == "*big.Int" {
+		g.unmarshalJSON(d)
+	}
+	if g.json {
+		g.marshalJSON(d, definition, t)
+	}
+	g.validate(d, t)
+}
+
+// comment writes text as a Go comment.
+func comment(b *bytes.Buffer, text string) {
+	if len(text) == 0 {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+}
+
+// note returns the doc comment of an assignment: the text of the comments
+// above it followed by that of the comment on its last line.
+func note(doc, trailing string) string {
+	return strings.Trim(doc+"\n"+trailing, "\n")
+}
+
+// definition returns the Go type a type named name is defined as, and the
+// type it is the definition of: t, or the type t stands for when that is
+// written in place.
+func (g *generator) definition(t asn1c.Type, name string) (string, asn1c.Type) {
+	switch u := t.(type) {
+	case *asn1c.SequenceType:
+		return g.structure(&u.ComponentList, name, false), t
+	case *asn1c.SetType:
+		return g.structure(&u.ComponentList, name, false), t
+	case *asn1c.ChoiceType:
+		return g.structure(&u.ComponentList, name, true), t
+	case *asn1c.EnumeratedType:
+		return "int", t
+	case *asn1c.BitStringType, *asn1c.BuiltinType:
+		return g.primitive(t), t
+	case *asn1c.ReferencedType:
+		target, generated := g.target(u)
+		if nil == target || len(generated) != 0 {
+			return generated, t
+		}
+		if _, ok := g.named[target]; !ok && g.declared(target) {
+			g.named[target] = name
+			return g.definition(target, name)
+		}
+		return g.goType(target, name), target
+	}
+	return g.goType(t, name), t
+}
+
+// declared reports whether t is a type written within others that is
+// declared by a name of its own: a SEQUENCE, SET, CHOICE or ENUMERATED,
+// and with JSON a BIT STRING or OCTET STRING, so as to have the
+// MarshalJSON method of one.
+func (g *generator) declared(t asn1c.Type) bool {
+	switch t := t.(type) {
+	case *asn1c.SequenceType, *asn1c.SetType, *asn1c.ChoiceType, *asn1c.EnumeratedType:
+		return true
+	case *asn1c.BitStringType:
+		return g.json
+	case *asn1c.BuiltinType:
+		return g.json && t.Name == asn1c.OctetString
+	}
+	return false
+}
+
+// goType returns the Go type of t, written where a type named name is
+// given the names of the types it declares: a slice for a SEQUENCE OF or
+// SET OF, asn1.BitString for a BIT STRING, []byte or an array for an OCTET
+// STRING, an int for an ENUMERATED and the type integer returns for an
+// INTEGER. A reference is the name generated for the type it names, and an
+// instance of a parameterized type is written in place.
+func (g *generator) goType(t asn1c.Type, name string) string {
+	if g.declared(t) {
+		if declared, ok := g.named[t]; ok {
+			return declared
+		}
+		declared := g.unique(name)
+		g.named[t] = declared
+		g.file.pending = append(g.file.pending, declaration{name: declared, typ: t})
+		return declared
+	}
+	switch t := t.(type) {
+	case *asn1c.ReferencedType:
+		target, generated := g.target(t)
+		if nil == target {
+			return generated
+		}
+		return g.goType(target, name)
+	case *asn1c.SequenceOfType:
+		return "[]" + g.goType(t.Element, name+elementName(t.ElementName))
+	case *asn1c.SetOfType:
+		return "[]" + g.goType(t.Element, name+elementName(t.ElementName))
+	case *asn1c.IntegerType:
+		return g.integer(t)
+	case *asn1c.BitStringType, *asn1c.BuiltinType:
+		return g.primitive(t)
+	case *asn1c.SelectionType:
+		choice, _, _, err := g.program.Underlying(t.Type)
+		if nil != err {
+			g.errors = append(g.errors, err.(*asn1c.Error))
+			return "interface{}"
+		}
+		if list, ok := choice.(*asn1c.ChoiceType); ok {
+			for _, component := range components(&list.ComponentList) {
+				if component.Name == t.Alternative {
+					return g.goType(component.Type, name)
+				}
+			}
+		}
+		g.errorf(t.Position, "no alternative %s", t.Alternative)
+	case *asn1c.ObjectClassFieldType:
+		if field := g.field(t); nil != field && field.Kind == asn1c.FixedTypeValueField {
+			return g.goType(field.Type, name)
+		}
+		return "interface{}"
+	case *asn1c.AnyType:
+		return "interface{}"
+	default:
+		g.errorf(t.Base().Position, "no Go type for %T", t)
+	}
+	return "interface{}"
+}
+
+// primitive returns the Go type of t, a BIT STRING or a type named by a
+// keyword.
+func (g *generator) primitive(t asn1c.Type) string {
+	if _, ok := t.(*asn1c.BitStringType); ok {
+		g.file.imports["encoding/asn1"] = true
+		return "asn1.BitString"
+	}
+	if size := g.array(t); size >= 0 {
+		return "[" + strconv.FormatInt(size, 10) + "]byte"
+	}
+	return g.builtin(t.(*asn1c.BuiltinType))
+}
+
+func elementName(name string) string {
+	if len(name) == 0 {
+		return "Item"
+	}
+	return goName(name)
+}
+
+// target returns the type ref stands for when it is to be written in
+// place, as for a dummy reference or an instance of a parameterized type,
+// or else the name generated for the type it names.
+func (g *generator) target(ref *asn1c.ReferencedType) (asn1c.Type, string) {
+	t, name, err := g.follow(ref)
+	switch {
+	case nil != err:
+		if list, ok := err.(asn1c.ErrorList); ok {
+			g.errors = append(g.errors, list...)
+		} else {
+			g.errorf(ref.Position, "%v", err)
+		}
+		return nil, "interface{}"
+	case len(name) != 0:
+		return nil, name
+	}
+	return t, ""
+}
+
+// follow returns the type ref stands for and the name generated for the
+// type it names, if any, and what goes wrong instead of reporting it.
+func (g *generator) follow(ref *asn1c.ReferencedType) (asn1c.Type, string, error) {
+	reference := g.program.Reference(ref)
+	if nil == reference {
+		return nil, "", fmt.Errorf("undefined reference %s", ref.Name)
+	}
+	assignment := reference.Assignment
+	if nil != reference.Import {
+		definition := g.program.Imported(reference.Import, ref.Name)
+		if nil == definition {
+			return nil, "", fmt.Errorf("%s is imported from %s, which is not linked", ref.Name, reference.Import.Module)
+		}
+		assignment = definition.Assignment
+	}
+	name := g.assigned[assignment]
+	switch a := assignment.(type) {
+	case *asn1c.TypeAssignment:
+		if len(a.Parameters) == 0 {
+			return a.Type, name, nil
+		}
+		instance, err := g.program.Instantiate(ref)
+		if nil != err {
+			return nil, "", err
+		}
+		return instance, "", nil
+	case *asn1c.ValueSetAssignment:
+		return a.Type, name, nil
+	}
+	return nil, "", fmt.Errorf("%s is not a type", ref.Name)
+}
+
+// field returns the field of its class t is the type of.
+func (g *generator) field(t *asn1c.ObjectClassFieldType) *asn1c.FieldSpec {
+	if len(t.Field) != 1 {
+		return nil
+	}
+	if class := g.class(t.Class); nil != class {
+		return class.Field(t.Field[0])
+	}
+	return nil
+}
+
+// class returns the class named name in the first module defining it.
+func (g *generator) class(name string) *asn1c.ObjectClass {
+	for _, module := range g.program.Modules {
+		if class := module.Module.ObjectClass(name); nil != class {
+			return class
+		}
+	}
+	return nil
+}
+
+var (
+	minInt64  = big.NewInt(math.MinInt64)
+	maxInt64  = big.NewInt(math.MaxInt64)
+	maxUint64 = new(big.Int).SetUint64(math.MaxUint64)
+)
+
+// integer returns the Go type holding the values the effective constraint
+// of t allows, its root when it is extensible: an int64, a uint64, or a
+// *big.Int, nil when missing, for values beyond both.
+func (g *generator) integer(t asn1c.Type) string {
+	typ := g.integerKind(t)
+	if typ == "*big.Int" {
+		g.file.imports["math/big"] = true
+	}
+	return typ
+}
+
+// integerKind is integer, leaving the imports alone.
+func (g *generator) integerKind(t asn1c.Type) string {
+	lower, upper := g.program.Effective(t).Values.Bounds()
+	switch {
+	case nil == lower || nil == upper:
+	case lower.Cmp(minInt64) >= 0 && upper.Cmp(maxInt64) <= 0:
+		return "int64"
+	case lower.Sign() >= 0 && upper.Cmp(maxUint64) <= 0:
+		return "uint64"
+	}
+	return "*big.Int"
+}
+
+// builtin returns the Go type of a builtin type other than INTEGER and BIT
+// STRING. EXTERNAL, EMBEDDED PDV and CHARACTER STRING are held encoded.
+func (g *generator) builtin(t *asn1c.BuiltinType) string {
+	switch t.Name {
+	case asn1c.Boolean:
+		return "bool"
+	case asn1c.Null:
+		return "struct{}"
+	case asn1c.Real:
+		return "float64"
+	case asn1c.ObjectIdentifier, asn1c.RelativeOID:
+		g.file.imports["encoding/asn1"] = true
+		return "asn1.ObjectIdentifier"
+	case asn1c.UTCTime, asn1c.GeneralizedTime:
+		g.file.imports["time"] = true
+		return "time.Time"
+	case asn1c.OctetString, asn1c.Externel, asn1c.EmbeddedPDV, asn1c.CharacterString:
+		return "[]byte"
+	}
+	return "string"
+}
+
+// structure returns the struct a SEQUENCE, SET or CHOICE named name is,
+// its root components in order followed by the extension additions.
+// OPTIONAL and DEFAULT components and extension additions are pointers,
+// nil when absent, as is each alternative of a CHOICE, of which one is set.
+// Types written within others are named after where they are written.
+func (g *generator) structure(list *asn1c.ComponentList, name string, choice bool) string {
+	var (
+		b bytes.Buffer
+		// Validate is a method of every generated type, and Choice one of
+		// those of CHOICE types.
+		fields = map[string]bool{"Validate": true, "Choice": choice}
+	)
+	b.WriteString("struct {\n")
+	write := func(component *asn1c.ComponentType, optional bool) {
+		field := goName(component.Name)
+		for i := 2; fields[field]; i++ {
+			field = goName(component.Name) + strconv.Itoa(i)
+		}
+		fields[field] = true
+		typ := g.goType(component.Type, name+goName(component.Name))
+		optional = optional || component.Optional || nil != component.Default
+		g.fields[list] = append(g.fields[list], structField{
+			name:      field,
+			component: component,
+			optional:  optional,
+			open:      typ == "interface{}",
+		})
+		if (optional || choice) && typ != "interface{}" && !strings.HasPrefix(typ, "*") {
+			typ = "*" + typ
+		}
+		comment(&b, component.Doc)
+		switch {
+		case !g.json:
+			fmt.Fprintf(&b, "%s %s", field, typ)
+		case optional || choice:
+			fmt.Fprintf(&b, "%s %s `json:\"%s,omitempty\"`", field, typ, jsonName(component.Name))
+		default:
+			fmt.Fprintf(&b, "%s %s `json:\"%s\"`", field, typ, jsonName(component.Name))
+		}
+		if len(component.Comment) != 0 {
+			b.WriteString(" // " + strings.ReplaceAll(component.Comment, "\n", " "))
+		}
+		b.WriteString("\n")
+	}
+	for _, component := range g.root(list, 0) {
+		write(component, false)
+	}
+	for _, addition := range list.Additions {
+		for _, component := range addition.Components {
+			write(component, true)
+		}
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// root lists the root components of list, those COMPONENTS OF brings in
+// included.
+func (g *generator) root(list *asn1c.ComponentList, depth int) []*asn1c.ComponentType {
+	var all []*asn1c.ComponentType
+	for _, component := range list.RootComponents() {
+		if !component.ComponentsOf {
+			all = append(all, component)
+			continue
+		}
+		t, _, _, err := g.program.Underlying(component.Type)
+		if nil != err {
+			g.errors = append(g.errors, err.(*asn1c.Error))
+			continue
+		}
+		var included *asn1c.ComponentList
+		switch t := t.(type) {
+		case *asn1c.SequenceType:
+			included = &t.ComponentList
+		case *asn1c.SetType:
+			included = &t.ComponentList
+		}
+		if nil == included || depth > len(g.program.Modules)+len(g.assigned) {
+			g.errorf(component.Position, "COMPONENTS OF a type that is not a SEQUENCE or SET")
+			continue
+		}
+		all = append(all, g.root(included, depth+1)...)
+	}
+	return all
+}
+
+func components(list *asn1c.ComponentList) []*asn1c.ComponentType {
+	all := list.RootComponents()
+	for _, addition := range list.Additions {
+		all = append(all, addition.Components...)
+	}
+	return all
+}
+
+// NameKind is what a name made of an ASN.1 name by GoName is for.
+type NameKind int
+
+const (
+	// Exported is a Go identifier of a type, field, constant or variable.
+	Exported NameKind = iota
+	// Package is the name of a Go package.
+	Package
+	// File is the name of a Go file.
+	File
+)
+
+// GoName returns the name the generator makes of name, an ASN.1 reference
+// or identifier, for kind. Exported names drop hyphens and put the letters
+// after them, and the first, in upper case, with an X before a name not
+// starting with a letter: 5G-S-TMSI is X5GSTMSI. Package names are in
+// lower case, letters and digits only, with asn1 before a name not starting
+// with a letter or making a Go keyword. File names are in lower case, with
+// underscores for hyphens, and _asn1 after a name go build would take for
+// that of a test or of a file for one platform: Proto-Test is
+// proto_test_asn1.go. Generate tells clashing names of assignments,
+// and of what is generated for them such as constants, apart by a number
+// from 2, in the order of the modules and their assignments, so that they
+// are the same on every run.
+func GoName(name string, kind NameKind) string {
+	switch kind {
+	case Package:
+		return packageName(name)
+	case File:
+		return fileName(name)
+	}
+	return goName(name)
+}
+
+// goName is GoName for Exported names.
+func goName(name string) string {
+	var (
+		b     strings.Builder
+		upper = true
+	)
+	for _, r := range name {
+		if r == '-' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 || !unicode.IsLetter([]rune(b.String())[0]) {
+		return "X" + b.String()
+	}
+	return b.String()
+}
+
+// packageName is GoName for Package names.
+func packageName(module string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(module) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 || !unicode.IsLetter([]rune(b.String())[0]) || token.IsKeyword(b.String()) {
+		return "asn1" + b.String()
+	}
+	return b.String()
+}
+
+// buildSuffixes are the last parts of file names, after an underscore, that
+// go build reads as the file being a test or for one GOOS or GOARCH only,
+// with the values it knows whether they are supported or not.
+var buildSuffixes = map[string]bool{}
+
+func init() {
+	for _, suffix := range strings.Fields(`test
+		aix android darwin dragonfly freebsd hurd illumos ios js linux nacl
+		netbsd openbsd plan9 solaris wasip1 windows zos
+		386 amd64 amd64p32 arm armbe arm64 arm64be loong64 mips mipsle mips64
+		mips64le mips64p32 mips64p32le ppc ppc64 ppc64le riscv riscv64 s390
+		s390x sparc sparc64 wasm`) {
+		buildSuffixes[suffix] = true
+	}
+}
+
+// fileName is GoName for File names.
+func fileName(module string) string {
+	name := strings.ToLower(strings.ReplaceAll(module, "-", "_"))
+	if i := strings.LastIndex(name, "_"); i >= 0 && buildSuffixes[name[i+1:]] {
+		name += "_asn1"
+	}
+	return name + ".go"
+}
@@ -0,0 +1,752 @@
+package codegen
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	asn1c "github.com/thebagchi/asn1c-go"
+)
+
+// generate writes each source to a file of a temporary directory, links
+// them and returns the files generated from them with opts.
+func generate(t *testing.T, opts Options, sources ...string) map[string][]byte {
+	t.Helper()
+	var (
+		dir   = t.TempDir()
+		files []string
+	)
+	for i, source := range sources {
+		filename := filepath.Join(dir, fmt.Sprintf("%d.asn", i+1))
+		if err := ioutil.WriteFile(filename, []byte(source), 0644); nil != err {
+			t.Fatal(err)
+		}
+		files = append(files, filename)
+	}
+	return generateFiles(t, opts, files, nil)
+}
+
+// generateFiles links files, looking for imports in includes, and returns
+// the files generated from them with opts.
+func generateFiles(t *testing.T, opts Options, files, includes []string) map[string][]byte {
+	t.Helper()
+	program, err := asn1c.ParseAndLink(files, includes)
+	if nil != err {
+		t.Fatal(err)
+	}
+	generated, err := (&Generator{}).Generate(program, opts)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return generated
+}
+
+func TestGoName(t *testing.T) {
+	tests := []struct {
+		name string
+		kind NameKind
+		want string
+	}{
+		{"pDUSessionResourceSetupListSUReq", Exported, "PDUSessionResourceSetupListSUReq"},
+		{"id-Cause", Exported, "IdCause"},
+		{"UE-NGAP-ID-pair", Exported, "UENGAPIDPair"},
+		{"5G-S-TMSI", Exported, "X5GSTMSI"},
+		{"type", Exported, "Type"},
+		{"func", Exported, "Func"},
+		{"range", Exported, "Range"},
+		{"a", Exported, "A"},
+		{"already-Upper", Exported, "AlreadyUpper"},
+		{"trailing-", Exported, "Trailing"},
+		{"double--hyphen", Exported, "DoubleHyphen"},
+		{"ALL-CAPS", Exported, "ALLCAPS"},
+		{"x2-Setup", Exported, "X2Setup"},
+		{"9", Exported, "X9"},
+		{"ça-va", Exported, "ÇaVa"},
+		{"range", Package, "asn1range"},
+		{"X2AP-PDU", Package, "x2appdu"},
+		{"--", Package, "asn1"},
+		{"Type", File, "type.go"},
+		{"5G-Common", File, "5g_common.go"},
+		{"NGAP-PDU-Contents", Package, "ngappducontents"},
+		{"Type", Package, "asn1type"},
+		{"5G", Package, "asn15g"},
+		{"NGAP-PDU-Contents", File, "ngap_pdu_contents.go"},
+		{"Proto-Test", File, "proto_test_asn1.go"},
+		{"Proto-Linux", File, "proto_linux_asn1.go"},
+		{"Proto-Amd64", File, "proto_amd64_asn1.go"},
+		{"Test", File, "test.go"},
+		{"Latest", File, "latest.go"},
+	}
+	for _, test := range tests {
+		if got := GoName(test.name, test.kind); got != test.want {
+			t.Errorf("GoName(%q, %d) = %q, want %q", test.name, test.kind, got, test.want)
+		}
+	}
+}
+
+func TestNameClashes(t *testing.T) {
+	generated := generate(t, Options{Package: "gen"}, `M DEFINITIONS ::= BEGIN
+Foo-Bar ::= INTEGER (0..1)
+FooBar ::= BOOLEAN
+Violation ::= NULL
+fooBar INTEGER ::= 1
+END`, `N DEFINITIONS ::= BEGIN
+Foo-bar ::= IA5String
+FooBar2 ::= REAL
+END`)
+	var all string
+	for _, source := range generated {
+		all += string(source)
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"first defined keeps its name", "type FooBar int64\n"},
+		{"next takes the least number", "type FooBar3 bool\n"},
+		{"reserved name", "type Violation2 struct{}\n"},
+		{"other module", "type FooBar4 string\n"},
+		{"own name first", "type FooBar2 float64\n"},
+		{"constant", "FooBar5 = 1\n"},
+		{"names", `"FooBar3":    "M.FooBar",` + "\n"},
+	}
+	for _, test := range tests {
+		if !strings.Contains(all, test.want) {
+			t.Errorf("%s: no %q in\n%s", test.name, test.want, all)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	generated := generate(t, Options{Package: "gen", SingleFile: true}, `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+-- A record.
+Rec ::= SEQUENCE {
+	-- The first.
+	a BOOLEAN, -- trailing a
+	b NULL
+} -- after Rec
+v INTEGER ::= 3 -- after v
+END`)
+	var all string
+	for _, source := range generated {
+		all += string(source)
+	}
+	for _, want := range []string{
+		"// A record.\n// after Rec\ntype Rec struct {\n",
+		"\t// The first.\n\tA bool // trailing a\n",
+		"\t// after v\n\tV = 3\n",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("no %q in\n%s", want, all)
+		}
+	}
+}
+
+var ngap = []string{"../Samples/012/NGAP-PDU-Descriptions.asn1", "../Samples/012/NGAP-PDU-Contents.asn1"}
+
+func TestGenerateDeterministic(t *testing.T) {
+	for _, opts := range []Options{{}, {SingleFile: true, JSON: true, Arrays: true}} {
+		first := generateFiles(t, opts, ngap, []string{"../Samples/012"})
+		for i := 0; i < 5; i++ {
+			again := generateFiles(t, opts, ngap, []string{"../Samples/012"})
+			if len(again) != len(first) {
+				t.Fatalf("%d files, then %d", len(first), len(again))
+			}
+			for name, source := range first {
+				if !bytes.Equal(again[name], source) {
+					t.Fatalf("%s differs between runs", name)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateFormatted(t *testing.T) {
+	generated := generateFiles(t, Options{}, ngap, []string{"../Samples/012"})
+	if len(generated) != 7 {
+		t.Errorf("%d files, want one for each of the 6 modules and one shared", len(generated))
+	}
+	for name, source := range generated {
+		formatted, err := format.Source(source)
+		if nil != err {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(formatted, source) {
+			t.Errorf("%s is not formatted", name)
+		}
+		header := "// Code generated by asn1c-go (codegen version " + strconv.Itoa(Version) + ")"
+		if !bytes.HasPrefix(source, []byte(header)) {
+			t.Errorf("%s does not start with %q", name, header)
+		}
+	}
+}
+
+func TestGenerateLayouts(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		args []string
+	}{
+		{"per module", Options{}, []string{"vet", "./..."}},
+		{"per module, tagged", Options{BuildTag: "ngap && !purego"}, []string{"vet", "-tags", "ngap", "./..."}},
+		{"single file, tagged", Options{SingleFile: true, BuildTag: "ngap"}, []string{"vet", "-tags", "ngap", "./..."}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			generated := generateFiles(t, test.opts, ngap, []string{"../Samples/012"})
+			if len(test.opts.BuildTag) != 0 {
+				for name, source := range generated {
+					if !bytes.Contains(source, []byte("\n//go:build "+test.opts.BuildTag+"\n\npackage ")) {
+						t.Errorf("%s is not constrained by %q", name, test.opts.BuildTag)
+					}
+				}
+			}
+			goCommand(t, generated, test.args...)
+		})
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// vet runs go vet over the package of files, which compiles it.
+func vet(t *testing.T, files map[string][]byte) {
+	t.Helper()
+	goCommand(t, files, "vet", "./...")
+}
+
+// goCommand runs the go command with args in a module of files, and
+// returns what it writes to its standard output.
+func goCommand(t *testing.T, files map[string][]byte, args ...string) []byte {
+	t.Helper()
+	if _, err := exec.LookPath("go"); nil != err {
+		t.Skip("no go command to build the generated files with")
+	}
+	dir := t.TempDir()
+	files["go.mod"] = []byte("module generated\n\ngo 1.21\n")
+	for name, source := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), source, 0644); nil != err {
+			t.Fatal(err)
+		}
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if nil != err {
+		t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, stderr.Bytes())
+	}
+	return output
+}
+
+func TestGenerateGolden(t *testing.T) {
+	generated := generateFiles(t, Options{Package: "golden", SingleFile: true}, []string{"testdata/golden.asn1"}, nil)
+	got := generated["golden.go"]
+	golden := "testdata/golden.go.golden"
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); nil != err {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("golden.go differs from %s, which -update rewrites:\n%s", golden, got)
+	}
+	vet(t, map[string][]byte{"golden.go": got})
+}
+
+func TestGenerateBuildSuffixes(t *testing.T) {
+	generated := generate(t, Options{Package: "gen"}, `Proto-Test DEFINITIONS ::= BEGIN
+Tested ::= INTEGER
+END`, `Proto-Windows DEFINITIONS ::= BEGIN
+Windowed ::= BOOLEAN
+END`, `Proto-Linux DEFINITIONS ::= BEGIN
+IMPORTS Tested FROM Proto-Test Windowed FROM Proto-Windows;
+Both ::= SEQUENCE { tested Tested, windowed Windowed }
+END`)
+	for _, name := range []string{"proto_test_asn1.go", "proto_windows_asn1.go", "proto_linux_asn1.go"} {
+		if _, ok := generated[name]; !ok {
+			t.Errorf("no %s among the generated files", name)
+		}
+	}
+	vet(t, generated)
+
+	generated = generate(t, Options{Package: "x_test", SingleFile: true}, `M DEFINITIONS ::= BEGIN
+T ::= INTEGER
+END`)
+	if _, ok := generated["x_test_asn1.go"]; !ok {
+		t.Errorf("no x_test_asn1.go among the generated files")
+	}
+	vet(t, generated)
+}
+
+// dump marshals a message of the types generated from testdata/golden.asn1,
+// as it would be once decoded, with those written within others.
+const dump = `package main
+
+import (
+	"encoding/asn1"
+	"encoding/json"
+	"math/big"
+	"os"
+	"time"
+)
+
+func main() {
+	var (
+		payload = MessagePayload{0xde, 0xad}
+		name    = "x"
+	)
+	message := Message{
+		Id:      1,
+		Big:     2,
+		Huge:    big.NewInt(3),
+		Neg:     -4,
+		Flag:    true,
+		Payload: &payload,
+		Bits:    MessageBits{Bytes: []byte{0x80}, BitLength: 2},
+		Name:    &name,
+		Kind:    KindB,
+		Inner:   MessageInner{X: 0.5},
+		List:    []Item{ItemOne, ItemMax},
+		Nested:  []MessageNestedItem{{Z: asn1.ObjectIdentifier{1, 2, 3}}},
+		When:    time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Color:   MessageColorGreen,
+		Choice:  NewChoiceN(struct{}{}),
+	}
+	out, err := json.MarshalIndent(struct {
+		Message Message
+		Holder  Holder
+		Teid    Teid
+		Odd     Odd
+		Counter Counter
+	}{
+		message,
+		Holder{Teid: HolderTeid{1, 2, 3, 4}, Any: HolderAny{0xff}},
+		Teid{0xca, 0xfe, 0xba, 0xbe},
+		NewOdd([2]byte{0xab, 0xc0}),
+		Counter{big.NewInt(5)},
+	}, "", "\t")
+	if nil != err {
+		panic(err)
+	}
+	os.Stdout.Write(append(out, '\n'))
+}
+`
+
+func TestGenerateJSON(t *testing.T) {
+	generated := generateFiles(t, Options{Package: "main", SingleFile: true, JSON: true, Arrays: true}, []string{"testdata/golden.asn1"}, nil)
+	generated["dump.go"] = []byte(dump)
+	got := goCommand(t, generated, "run", ".")
+	golden := "testdata/message.json.golden"
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); nil != err {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the JSON of the message differs from %s, which -update rewrites:\n%s", golden, got)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	generated := generate(t, Options{Package: "gen", SingleFile: true}, `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Color ::= ENUMERATED { red, green }
+Counter ::= INTEGER (0..MAX)
+Point ::= SEQUENCE { x INTEGER (0..9), y INTEGER (0..9) OPTIONAL, color Color DEFAULT red }
+Defaults ::= SEQUENCE {
+	count		INTEGER (0..9) DEFAULT 5,
+	name		IA5String DEFAULT "x",
+	ratio		REAL DEFAULT 1.5,
+	half		REAL DEFAULT { mantissa 1, base 2, exponent -1 },
+	inf			REAL DEFAULT PLUS-INFINITY,
+	flags		BIT STRING DEFAULT '101'B,
+	none		BIT STRING DEFAULT {},
+	octets		OCTET STRING DEFAULT 'DEAD'H,
+	point		Point DEFAULT { x 1, y 2 },
+	origin		SEQUENCE { x INTEGER (0..9), c Color } DEFAULT { x 0, c green },
+	list		SEQUENCE OF INTEGER (0..9) DEFAULT { 1, 2, 3 },
+	empty		SEQUENCE OF Point DEFAULT {},
+	points		SEQUENCE OF Point DEFAULT { { x 1 }, { x 2, color green } },
+	choice		CHOICE { a INTEGER (0..9), b BOOLEAN } DEFAULT b: TRUE,
+	counter		Counter DEFAULT 7,
+	counters	SEQUENCE OF Counter DEFAULT { 1, 123456789012345678901234567890 },
+	id			OBJECT IDENTIFIER DEFAULT { 1 2 840 }
+}
+END`)
+	all := string(generated["gen.go"])
+	for _, want := range []string{
+		"\tPointColorDefault Color = ColorRed\n",
+		"\tDefaultsCountDefault   int64   = 5\n",
+		"\tDefaultsNameDefault    string  = \"x\"\n",
+		"\tDefaultsRatioDefault   float64 = 15e-1\n",
+		"\tDefaultsHalfDefault    float64 = 0x1p-1\n",
+		"\tDefaultsCounterDefault         = 7\n",
+		"func DefaultsInfDefault() float64 {\n\treturn math.Inf(1)\n}\n",
+		"func DefaultsFlagsDefault() asn1.BitString {\n\treturn asn1.BitString{Bytes: []byte{0xa0}, BitLength: 3}\n}\n",
+		"func DefaultsNoneDefault() asn1.BitString {\n\treturn asn1.BitString{}\n}\n",
+		"func DefaultsOctetsDefault() []byte {\n\treturn []byte{0xde, 0xad}\n}\n",
+		"func DefaultsPointDefault() Point {\n\treturn Point{\n\t\tX: 1,\n\t\tY: func() *int64 {\n\t\t\tvar v int64 = 2\n\t\t\treturn &v\n\t\t}(),\n\t}\n}\n",
+		"func DefaultsOriginDefault() DefaultsOrigin {\n\treturn DefaultsOrigin{\n\t\tX: 0,\n\t\tC: ColorGreen,\n\t}\n}\n",
+		"func DefaultsListDefault() []int64 {\n\treturn []int64{\n\t\t1,\n\t\t2,\n\t\t3,\n\t}\n}\n",
+		"func DefaultsEmptyDefault() []Point {\n\treturn []Point{}\n}\n",
+		"\t\t{\n\t\t\tX: 2,\n\t\t\tColor: func() *Color {\n\t\t\t\tvar v Color = ColorGreen\n",
+		"func DefaultsChoiceDefault() DefaultsChoice {\n\treturn DefaultsChoice{\n\t\tB: func() *bool {\n",
+		"\t\t{big.NewInt(1)},\n\t\t{func() *big.Int {\n\t\t\tn, _ := new(big.Int).SetString(\"123456789012345678901234567890\", 10)\n",
+		"func DefaultsIdDefault() asn1.ObjectIdentifier {\n\treturn asn1.ObjectIdentifier{1, 2, 840}\n}\n",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("no %q in\n%s", want, all)
+		}
+	}
+	vet(t, generated)
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "1.asn")
+	source := "M DEFINITIONS AUTOMATIC TAGS ::= BEGIN\nT ::= SEQUENCE { when GeneralizedTime DEFAULT \"20200102030405Z\" }\nEND"
+	if err := ioutil.WriteFile(filename, []byte(source), 0644); nil != err {
+		t.Fatal(err)
+	}
+	program, err := asn1c.ParseAndLink([]string{filename}, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	want := "1.asn:2:47: no Go value for the DEFAULT of when"
+	if _, err := (&Generator{}).Generate(program, Options{}); nil == err || !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("Generate: %v, want an error ending with %q", err, want)
+	}
+}
+
+// fixedSizes checks the types of one size generated from the module of
+// TestFixedSizes.
+const fixedSizes = `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(Bits32Size, NewBits32([4]byte{1, 2, 3, 4}).Validate())
+	fmt.Println(OddSize, NewOdd([2]byte{0xab, 0xc0}), NewOdd([2]byte{}).Validate())
+	fmt.Println(Odd{Bytes: []byte{0xab}, BitLength: 8}.Validate())
+	fmt.Println(Teid{1, 2, 3, 4}.Validate(), len(Teid{}))
+}
+`
+
+func TestFixedSizes(t *testing.T) {
+	source := `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Bits32 ::= BIT STRING (SIZE (32))
+Odd ::= BIT STRING (SIZE (12))
+Extensible ::= BIT STRING (SIZE (8, ...))
+Sizes ::= BIT STRING (SIZE (1..8))
+Teid ::= OCTET STRING (SIZE (4))
+Loose ::= OCTET STRING (SIZE (4, ...))
+END`
+	for _, arrays := range []bool{false, true} {
+		generated := generate(t, Options{Package: "main", SingleFile: true, Arrays: arrays}, source)
+		all := string(generated["main.go"])
+		want := []string{
+			"const Bits32Size = 32\n",
+			"func NewBits32(b [4]byte) Bits32 {\n\treturn Bits32{Bytes: b[:], BitLength: Bits32Size}\n}\n",
+			"const OddSize = 12\n",
+			"func NewOdd(b [2]byte) Odd {\n",
+			"type Loose []byte\n",
+		}
+		absent := []string{"ExtensibleSize", "NewExtensible", "SizesSize", "NewSizes"}
+		if arrays {
+			want = append(want, "type Teid [4]byte\n")
+		} else {
+			want = append(want, "type Teid []byte\n")
+		}
+		for _, w := range want {
+			if !strings.Contains(all, w) {
+				t.Errorf("arrays %v: no %q in\n%s", arrays, w, all)
+			}
+		}
+		for _, a := range absent {
+			if strings.Contains(all, a) {
+				t.Errorf("arrays %v: %q in\n%s", arrays, a, all)
+			}
+		}
+		if !arrays {
+			continue
+		}
+		generated["fixed.go"] = []byte(fixedSizes)
+		got := string(goCommand(t, generated, "run", "."))
+		want = []string{
+			"32 <nil>",
+			"12 {[171 192] 12} <nil>",
+			"Odd: size out of range 12",
+			"<nil> 4",
+		}
+		if got != strings.Join(want, "\n")+"\n" {
+			t.Errorf("got\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+		}
+	}
+}
+
+func TestBoundedLists(t *testing.T) {
+	generated := generate(t, Options{Package: "main", SingleFile: true}, `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+maxnoofItems INTEGER ::= 3
+Item ::= SEQUENCE { id INTEGER (0..9) }
+Items ::= SEQUENCE (SIZE (1..maxnoofItems)) OF Item
+Flags ::= SET (SIZE (2 | 5)) OF BOOLEAN
+Loose ::= SEQUENCE (SIZE (1..4, ...)) OF INTEGER
+Open ::= SEQUENCE (SIZE (1..MAX)) OF INTEGER
+Free ::= SEQUENCE OF INTEGER
+END`)
+	all := string(generated["main.go"])
+	for _, want := range []string{
+		"const ItemsMaxSize = 3\n",
+		"func (v *Items) Append(items ...Item) error {\n",
+		"const FlagsMaxSize = 5\n",
+		"func (v *Flags) Append(items ...bool) error {\n",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("no %q in\n%s", want, all)
+		}
+	}
+	for _, absent := range []string{"LooseMaxSize", "OpenMaxSize", "FreeMaxSize", "func (v *Loose) Append", "func (v *Open) Append", "func (v *Free) Append"} {
+		if strings.Contains(all, absent) {
+			t.Errorf("%q in\n%s", absent, all)
+		}
+	}
+	generated["bounded.go"] = []byte(boundedLists)
+	got := string(goCommand(t, generated, "run", "."))
+	want := "<nil> 2\nItems: size out of range 1..3 2\n<nil> 3\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+// boundedLists appends to the list of TestBoundedLists past its bound.
+const boundedLists = `package main
+
+import "fmt"
+
+func main() {
+	var items Items
+	fmt.Println(items.Append(Item{Id: 1}, Item{Id: 2}), len(items))
+	fmt.Println(items.Append(Item{Id: 3}, Item{Id: 4}), len(items))
+	fmt.Println(items.Append(Item{Id: 3}), len(items))
+}
+`
+
+func TestObjectPresence(t *testing.T) {
+	source := `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Presence ::= ENUMERATED { optional, conditional, mandatory }
+Need ::= ENUMERATED { required, optional }
+IES ::= CLASS { &id INTEGER (0..65535) UNIQUE, &presence Presence, &Value }
+WITH SYNTAX { ID &id PRESENCE &presence TYPE &Value }
+FIELDS ::= CLASS { &id INTEGER (0..65535) UNIQUE, &need Need, &Value }
+WITH SYNTAX { ID &id NEED &need TYPE &Value }
+Ies IES ::= { { ID 1 PRESENCE mandatory TYPE INTEGER } | { ID 2 PRESENCE optional TYPE BOOLEAN } }
+Fields FIELDS ::= { { ID 3 NEED required TYPE INTEGER } | { ID 4 NEED optional TYPE BOOLEAN } }
+END`
+	tests := []struct {
+		name string
+		opts Options
+		// table has the one Missing method, if any, and set is of its type.
+		table   string
+		set     string
+		missing string
+	}{
+		{name: "default", table: "IESSet", set: "Ies", missing: "[1] []\n"},
+		{name: "configured", opts: Options{Presence: "&need", Mandatory: "required"}, table: "FIELDSSet", set: "Fields", missing: "[3] []\n"},
+		{name: "absent", opts: Options{Presence: "&criticality"}},
+		{name: "item", opts: Options{Mandatory: "required"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.opts.Package, test.opts.SingleFile = "main", true
+			generated := generate(t, test.opts, source)
+			all := string(generated["main.go"])
+			for _, table := range []string{"IESSet", "FIELDSSet"} {
+				if want := table == test.table; strings.Contains(all, "func (s "+table+") Missing(") != want {
+					t.Errorf("%s has a Missing method: %v, want %v", table, !want, want)
+				}
+			}
+			if len(test.set) == 0 {
+				return
+			}
+			generated["missing.go"] = []byte(fmt.Sprintf(missingObjects, test.set))
+			if got := string(goCommand(t, generated, "run", ".")); got != test.missing {
+				t.Errorf("got %q, want %q", got, test.missing)
+			}
+		})
+	}
+}
+
+// missingObjects prints the mandatory objects missing from the object set
+// of TestObjectPresence, first with no keys, then with every key.
+const missingObjects = `package main
+
+import "fmt"
+
+func main() {
+	var keys []int64
+	for key := range %s {
+		keys = append(keys, key)
+	}
+	fmt.Println(%[1]s.Missing(nil), %[1]s.Missing(keys))
+}
+`
+
+func TestObjectTables(t *testing.T) {
+	generated := generate(t, Options{Package: "gen", SingleFile: true}, `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Criticality ::= ENUMERATED { reject, ignore, notify }
+Presence ::= ENUMERATED { optional, conditional, mandatory }
+id-Cause INTEGER ::= 15
+PROTOCOL-IES ::= CLASS { &id INTEGER (0..65535) UNIQUE, &criticality Criticality, &Value, &presence Presence }
+WITH SYNTAX { ID &id CRITICALITY &criticality TYPE &Value PRESENCE &presence }
+Cause ::= ENUMERATED { radio, transport }
+SetupIEs PROTOCOL-IES ::= {
+	{ ID id-Cause CRITICALITY ignore TYPE Cause PRESENCE mandatory } |
+	{ ID 10 CRITICALITY reject TYPE INTEGER (0..255) PRESENCE optional },
+	...,
+	{ ID 7 CRITICALITY notify TYPE OCTET STRING PRESENCE conditional }
+}
+ELEMENTARY-PROCEDURE ::= CLASS {
+	&InitiatingMessage,
+	&SuccessfulOutcome OPTIONAL,
+	&procedureCode INTEGER (0..255) UNIQUE,
+	&criticality Criticality DEFAULT ignore
+}
+WITH SYNTAX {
+	INITIATING MESSAGE &InitiatingMessage
+	[SUCCESSFUL OUTCOME &SuccessfulOutcome]
+	PROCEDURE CODE &procedureCode
+	[CRITICALITY &criticality]
+}
+SetupRequest ::= SEQUENCE { a INTEGER }
+SetupResponse ::= SEQUENCE { b INTEGER }
+ErrorIndication ::= SEQUENCE { c INTEGER }
+setup ELEMENTARY-PROCEDURE ::= { INITIATING MESSAGE SetupRequest SUCCESSFUL OUTCOME SetupResponse PROCEDURE CODE 21 CRITICALITY reject }
+errorIndication ELEMENTARY-PROCEDURE ::= { INITIATING MESSAGE ErrorIndication PROCEDURE CODE 9 }
+Procedures ELEMENTARY-PROCEDURE ::= { setup | errorIndication, ... }
+END`)
+	all := string(generated["gen.go"])
+	for _, want := range []string{
+		`
+// PROTOCOLIESSet holds objects of PROTOCOL-IES by their &id.
+type PROTOCOLIESSet map[int64]PROTOCOLIESObject
+`,
+		`
+var SetupIEs = PROTOCOLIESSet{
+	IdCause: {
+		Id:          IdCause,
+		Criticality: CriticalityIgnore,
+		Value:       func() interface{} { return new(Cause) },
+		ValueType:   "Cause",
+		Presence:    PresenceMandatory,
+	},
+	10: {
+		Id:          10,
+		Criticality: CriticalityReject,
+		Value:       func() interface{} { return new(int64) },
+		ValueType:   "int64",
+		Presence:    PresenceOptional,
+	},
+	7: {
+		Id:          7,
+		Criticality: CriticalityNotify,
+		Value:       func() interface{} { return new([]byte) },
+		ValueType:   "[]byte",
+		Presence:    PresenceConditional,
+	},
+}
+
+// SetupIEsObjects lists the objects of SetupIEs in order.
+var SetupIEsObjects = []PROTOCOLIESObject{
+	SetupIEs[IdCause],
+	SetupIEs[10],
+	SetupIEs[7],
+}
+`,
+		`
+var Procedures = ELEMENTARYPROCEDURESet{
+	21: {
+		InitiatingMessage:     func() interface{} { return new(SetupRequest) },
+		InitiatingMessageType: "SetupRequest",
+		SuccessfulOutcome:     func() interface{} { return new(SetupResponse) },
+		SuccessfulOutcomeType: "SetupResponse",
+		ProcedureCode:         21,
+		Criticality:           CriticalityReject,
+	},
+	9: {
+		InitiatingMessage:     func() interface{} { return new(ErrorIndication) },
+		InitiatingMessageType: "ErrorIndication",
+		ProcedureCode:         9,
+		Criticality:           CriticalityIgnore,
+	},
+}
+`,
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("no %q in\n%s", want, all)
+		}
+	}
+	vet(t, generated)
+}
+
+func TestBigIntegers(t *testing.T) {
+	generated := generate(t, Options{Package: "main", SingleFile: true}, `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Counter ::= INTEGER (0..MAX)
+Small ::= Counter (1..5)
+Counters ::= SEQUENCE { first Counter, rest SEQUENCE OF Small, maybe Counter OPTIONAL }
+END`)
+	all := string(generated["main.go"])
+	for _, want := range []string{
+		"type Counter struct {\n\t*big.Int\n}\n",
+		"type Small Counter\n",
+		"\tFirst Counter\n\tRest  []Small\n\tMaybe *Counter\n",
+		"func (v Counter) Validate() error {\n",
+		"func (v *Counter) UnmarshalJSON(b []byte) error {\n",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("no %q in\n%s", want, all)
+		}
+	}
+	generated["big.go"] = []byte(bigIntegers)
+	got := string(goCommand(t, generated, "run", "."))
+	want := []string{
+		"<nil>",
+		"Counters.First: missing; Counters.Rest[0]: value out of range 1..5; Counters.Rest[1]: missing",
+		"<nil> 7 <nil>",
+	}
+	if got != strings.Join(want, "\n")+"\n" {
+		t.Errorf("got\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+// bigIntegers validates values of the types of TestBigIntegers and decodes
+// one from JSON.
+const bigIntegers = `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+func main() {
+	fmt.Println(Counters{First: Counter{big.NewInt(1)}, Rest: []Small{{big.NewInt(5)}}}.Validate())
+	fmt.Println(Counters{Rest: []Small{{big.NewInt(6)}, {}}}.Validate())
+	var counters Counters
+	err := json.Unmarshal([]byte(` + "`" + `{"First": 7, "Maybe": null}` + "`" + `), &counters)
+	fmt.Println(err, counters.First, counters.Maybe)
+}
+`
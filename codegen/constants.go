@@ -0,0 +1,89 @@
+package codegen
+
+import (
+	"fmt"
+	"math/big"
+
+	asn1c "github.com/thebagchi/asn1c-go"
+)
+
+// constants lists the value assignments of module that become Go
+// constants: those of a value of an INTEGER type.
+func (g *generator) constants(module *asn1c.CheckedModule) []*asn1c.ValueAssignment {
+	var assignments []*asn1c.ValueAssignment
+	for _, assignment := range module.Module.Assignments {
+		a, ok := assignment.(*asn1c.ValueAssignment)
+		if !ok {
+			continue
+		}
+		if t, _, _, err := g.program.Underlying(a.Type); nil == err {
+			if _, ok := t.(*asn1c.IntegerType); ok {
+				assignments = append(assignments, a)
+			}
+		}
+	}
+	return assignments
+}
+
+// values writes the constants of the INTEGER values assigned in module,
+// of the type generated for the type of the value when it names one.
+func (g *generator) values(module *asn1c.CheckedModule) {
+	assignments := g.constants(module)
+	if len(assignments) == 0 {
+		return
+	}
+	b := &g.file.body
+	b.WriteString("\nconst (\n")
+	for _, a := range assignments {
+		name, ok := g.assigned[a]
+		if !ok {
+			continue
+		}
+		value, ok := g.program.ResolveValue(a.Value).(*asn1c.IntegerValue)
+		if !ok {
+			g.errorf(a.Position, "%s is not an integer", a.Name)
+			continue
+		}
+		typ := ""
+		if ref, ok := a.Type.(*asn1c.ReferencedType); ok {
+			if target, generated := g.target(ref); nil == target && generated != "interface{}" {
+				typ = generated
+			}
+		}
+		comment(b, note(a.Doc, a.Comment))
+		b.WriteString(constant(name, typ, g.integerKind(a.Type), value.Value))
+	}
+	b.WriteString(")\n")
+}
+
+// namedNumbers writes the constants of the named numbers of t, the INTEGER
+// named name and generated as typ.
+func (g *generator) namedNumbers(t *asn1c.IntegerType, name, typ string) {
+	b := &g.file.body
+	b.WriteString("\nconst (\n")
+	for _, number := range t.NamedNumbers {
+		value, ok := g.program.ResolveValue(number.Value).(*asn1c.IntegerValue)
+		if !ok {
+			g.errorf(number.Position, "%s is not an integer", number.Name)
+			continue
+		}
+		b.WriteString(constant(g.unique(name+goName(number.Name)), name, typ, value.Value))
+	}
+	b.WriteString(")\n")
+}
+
+// constant returns the spec of the constant name holding n, of the Go type
+// typ, which is generated as kind, when it is not empty. Numbers a constant
+// of typ cannot hold are written as untyped constants, a uint64 when they
+// fit one and their decimal digits when they do not.
+func constant(name, typ, kind string, n *big.Int) string {
+	switch {
+	case typ != "" && kind == "int64" && n.IsInt64(), typ != "" && kind == "uint64" && n.IsUint64():
+		return fmt.Sprintf("%s %s = %s\n", name, typ, n)
+	case n.IsInt64():
+		return fmt.Sprintf("%s = %s\n", name, n)
+	case n.IsUint64():
+		return fmt.Sprintf("%s uint64 = %s\n", name, n)
+	}
+	return fmt.Sprintf("%s = %q\n", name, n.String())
+}
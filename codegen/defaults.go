@@ -0,0 +1,397 @@
+package codegen
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	asn1c "github.com/thebagchi/asn1c-go"
+)
+
+// defaultValue is the DEFAULT value of a component that makes no constant,
+// written once every type is declared.
+type defaultValue struct {
+	name      string
+	component *asn1c.ComponentType
+	// of is the name goType is given for the type of the component.
+	of string
+}
+
+// defaults writes the DEFAULT value of each component of list, the
+// SEQUENCE or SET named name, named after the type and the field with
+// Default added. INTEGER, REAL, BOOLEAN, ENUMERATED and character string
+// values make constants, untyped for an INTEGER held in a *big.Int, whose
+// field is nil when the default applies. The values of other types, such as
+// BIT STRING, OCTET STRING and constructed types, hold slices and pointers:
+// they make functions returning a new one, to compare with a field
+// structurally, as reflect.DeepEqual does, and are written by
+// defaultValues. A DEFAULT with no Go value is reported.
+func (g *generator) defaults(list *asn1c.ComponentList, name string) {
+	var specs []string
+	for _, field := range g.fields[list] {
+		component := field.component
+		if nil == component.Default {
+			continue
+		}
+		if field.open {
+			g.errorf(component.Default.Pos(), "no Go value for the DEFAULT of %s, an open type", component.Name)
+			continue
+		}
+		value := g.literal(component.Type, component.Default)
+		if len(value) == 0 {
+			g.file.defaults = append(g.file.defaults, defaultValue{
+				name:      g.unique(name + field.name + "Default"),
+				component: component,
+				of:        name + goName(component.Name),
+			})
+			continue
+		}
+		typ := g.goType(component.Type, name+goName(component.Name))
+		if g.integerOf(component.Type) == "*big.Int" {
+			typ = ""
+		}
+		specs = append(specs, fmt.Sprintf("%s %s = %s\n", g.unique(name+field.name+"Default"), typ, value))
+	}
+	if len(specs) == 0 {
+		return
+	}
+	b := &g.file.body
+	b.WriteString("\nconst (\n")
+	for _, spec := range specs {
+		b.WriteString(spec)
+	}
+	b.WriteString(")\n")
+}
+
+// defaultValues writes the functions returning the DEFAULT values that
+// make no constants, once every type is declared, as the values may be
+// made of any of them.
+func (g *generator) defaultValues() {
+	b := &g.file.body
+	for _, d := range g.file.defaults {
+		component := d.component
+		value, ok := g.value(component.Type, component.Default, d.of)
+		if !ok {
+			g.errorf(component.Default.Pos(), "no Go value for the DEFAULT of %s", component.Name)
+			continue
+		}
+		fmt.Fprintf(b, "\n// %s returns the DEFAULT value of %s.\n", d.name, component.Name)
+		fmt.Fprintf(b, "func %s() %s {\nreturn %s\n}\n", d.name, g.goType(component.Type, d.of), value)
+	}
+	g.file.defaults = nil
+}
+
+// literal returns the Go constant of v, a value of t, empty when there is
+// none.
+func (g *generator) literal(t asn1c.Type, v asn1c.Value) string {
+	base, _, _, err := g.program.Underlying(t)
+	if nil != err {
+		return ""
+	}
+	identifier := ""
+	if ref, ok := v.(*asn1c.ReferencedValue); ok && len(ref.Module) == 0 {
+		identifier = ref.Name
+	}
+	switch u := base.(type) {
+	case *asn1c.IntegerType:
+		for _, number := range u.NamedNumbers {
+			if number.Name == identifier {
+				v = number.Value
+			}
+		}
+		value, ok := g.program.ResolveValue(v).(*asn1c.IntegerValue)
+		if !ok {
+			return ""
+		}
+		switch g.integerOf(t) {
+		case "int64":
+			if value.Value.IsInt64() {
+				return value.Value.String()
+			}
+		case "uint64":
+			if value.Value.IsUint64() {
+				return value.Value.String()
+			}
+		case "*big.Int":
+			return value.Value.String()
+		}
+	case *asn1c.EnumeratedType:
+		if constant, ok := g.items[u][identifier]; ok {
+			return constant
+		}
+		numbers := g.program.Enumerate(u)
+		for i, item := range append(append([]*asn1c.NamedNumber(nil), u.Items...), u.Additions...) {
+			if item.Name == identifier && i < len(numbers) && numbers[i].IsInt64() {
+				return numbers[i].String()
+			}
+		}
+	case *asn1c.BuiltinType:
+		switch value := g.program.ResolveValue(v).(type) {
+		case *asn1c.BooleanValue:
+			if u.Name == asn1c.Boolean {
+				return strconv.FormatBool(value.Value)
+			}
+		case *asn1c.StringValue:
+			if characters(u.Name) {
+				return strconv.Quote(value.Value)
+			}
+		case *asn1c.IntegerValue:
+			if u.Name == asn1c.Real {
+				return realLiteral(value.Value.String())
+			}
+		case *asn1c.RealValue:
+			if u.Name != asn1c.Real || len(value.Special) != 0 {
+				return ""
+			}
+			if value.Base == 2 {
+				sign := ""
+				if value.Mantissa.Sign() < 0 {
+					sign = "-"
+				}
+				return realLiteral(fmt.Sprintf("%s0x%sp%d", sign, new(big.Int).Abs(value.Mantissa).Text(16), value.Exponent))
+			}
+			return realLiteral(fmt.Sprintf("%se%d", value.Mantissa, value.Exponent))
+		}
+	}
+	return ""
+}
+
+// realLiteral returns literal, the Go constant of a REAL, empty when it is
+// beyond a float64.
+func realLiteral(literal string) string {
+	f, _, err := big.ParseFloat(literal, 0, 64, big.ToNearestEven)
+	if nil != err {
+		return ""
+	}
+	if x, _ := f.Float64(); math.IsInf(x, 0) {
+		return ""
+	}
+	return literal
+}
+
+// value returns the Go expression of v, a value of t, given name by goType
+// when declared within others. Ok is false when it has none.
+func (g *generator) value(t asn1c.Type, v asn1c.Value, name string) (expression string, ok bool) {
+	typ := g.goType(t, name)
+	if literal := g.literal(t, v); len(literal) != 0 {
+		if g.integerOf(t) != "*big.Int" {
+			return literal, true
+		}
+		g.file.imports["math/big"] = true
+		n, _ := new(big.Int).SetString(literal, 10)
+		expression = "big.NewInt(" + literal + ")"
+		if !n.IsInt64() {
+			expression = fmt.Sprintf("func() *big.Int {\nn, _ := new(big.Int).SetString(%q, 10)\nreturn n\n}()", literal)
+		}
+		if typ != "*big.Int" {
+			expression = typ + "{" + expression + "}"
+		}
+		return expression, true
+	}
+	base, _, _, err := g.program.Underlying(t)
+	if nil != err {
+		return "", false
+	}
+	// Types declared by names of their own name the types within them.
+	if !strings.HasPrefix(typ, "[") {
+		name = typ
+	}
+	v = g.program.ResolveValue(v)
+	switch u := base.(type) {
+	case *asn1c.BuiltinType:
+		return g.builtinValue(u, v, typ)
+	case *asn1c.BitStringType:
+		switch value := v.(type) {
+		case *asn1c.BitStringValue:
+			return fmt.Sprintf("%s{Bytes: []byte{%s}, BitLength: %d}", typ, byteList(value.Bytes), value.Length), true
+		case *asn1c.OctetStringValue:
+			return fmt.Sprintf("%s{Bytes: []byte{%s}, BitLength: %d}", typ, byteList(value.Bytes), value.Length), true
+		case *asn1c.SequenceValue:
+			if len(value.Components) == 0 {
+				return typ + "{}", true
+			}
+		}
+	case *asn1c.SequenceType:
+		return g.structValue(&u.ComponentList, false, v, typ)
+	case *asn1c.SetType:
+		return g.structValue(&u.ComponentList, false, v, typ)
+	case *asn1c.ChoiceType:
+		value, ok := v.(*asn1c.ChoiceValue)
+		if !ok {
+			return "", false
+		}
+		return g.structValue(&u.ComponentList, true, &asn1c.SequenceValue{Components: []*asn1c.NamedValue{{Name: value.Name, Value: value.Value}}}, typ)
+	case *asn1c.SequenceOfType:
+		return g.listValue(u.Element, v, typ, name+elementName(u.ElementName))
+	case *asn1c.SetOfType:
+		return g.listValue(u.Element, v, typ, name+elementName(u.ElementName))
+	}
+	return "", false
+}
+
+// builtinValue returns the Go expression of v, a value of t, of the Go type
+// typ, for the types literal makes no constants of.
+func (g *generator) builtinValue(t *asn1c.BuiltinType, v asn1c.Value, typ string) (string, bool) {
+	switch t.Name {
+	case asn1c.Null:
+		return typ + "{}", true
+	case asn1c.Real:
+		value, ok := v.(*asn1c.RealValue)
+		if !ok {
+			return "", false
+		}
+		g.file.imports["math"] = true
+		switch value.Special {
+		case asn1c.PlusInfinity:
+			return "math.Inf(1)", true
+		case asn1c.MinusInfinity:
+			return "math.Inf(-1)", true
+		case asn1c.NotANumber:
+			return "math.NaN()", true
+		}
+	case asn1c.OctetString:
+		var bytes []byte
+		switch value := v.(type) {
+		case *asn1c.OctetStringValue:
+			bytes = value.Bytes
+		case *asn1c.BitStringValue:
+			bytes = value.Bytes
+		case *asn1c.SequenceValue:
+			if len(value.Components) != 0 {
+				return "", false
+			}
+		default:
+			return "", false
+		}
+		if size := g.array(t); size >= 0 && int64(len(bytes)) > size {
+			return "", false
+		}
+		return typ + "{" + byteList(bytes) + "}", true
+	case asn1c.ObjectIdentifier, asn1c.RelativeOID:
+		value, ok := v.(*asn1c.ObjectIdentifierValue)
+		if !ok {
+			return "", false
+		}
+		var arcs []string
+		for _, component := range value.Components {
+			arc, ok := g.program.ResolveValue(component.Value).(*asn1c.IntegerValue)
+			if nil == component.Value || !ok || !arc.Value.IsInt64() {
+				return "", false
+			}
+			arcs = append(arcs, arc.Value.String())
+		}
+		return typ + "{" + strings.Join(arcs, ", ") + "}", true
+	}
+	return "", false
+}
+
+// structValue returns the Go expression of v, a value of the SEQUENCE, SET
+// or CHOICE, as choice tells, of list, of the Go type typ. The fields held
+// through pointers point to values of their own.
+func (g *generator) structValue(list *asn1c.ComponentList, choice bool, v asn1c.Value, typ string) (string, bool) {
+	components, ok := namedValues(v)
+	if !ok {
+		return "", false
+	}
+	var fields []string
+	for _, named := range components {
+		var field *structField
+		for i := range g.fields[list] {
+			if g.fields[list][i].component.Name == named.Name {
+				field = &g.fields[list][i]
+			}
+		}
+		if nil == field || field.open {
+			return "", false
+		}
+		of := typ + goName(field.component.Name)
+		value, ok := g.value(field.component.Type, named.Value, of)
+		if !ok {
+			return "", false
+		}
+		ftyp := g.goType(field.component.Type, of)
+		if (field.optional || choice) && !strings.HasPrefix(ftyp, "*") {
+			value = fmt.Sprintf("func() *%s {\nvar v %s = %s\nreturn &v\n}()", ftyp, ftyp, value)
+		}
+		fields = append(fields, field.name+": "+value)
+	}
+	if len(fields) == 0 {
+		return typ + "{}", true
+	}
+	return typ + "{\n" + strings.Join(fields, ",\n") + ",\n}", true
+}
+
+// listValue returns the Go expression of v, a value of a SEQUENCE OF or
+// SET OF element, of the Go type typ, its elements given name by goType.
+func (g *generator) listValue(element asn1c.Type, v asn1c.Value, typ, name string) (string, bool) {
+	var elements []asn1c.Value
+	switch value := v.(type) {
+	case *asn1c.SequenceOfValue:
+		elements = value.Elements
+	case *asn1c.SequenceValue:
+		if len(value.Components) != 0 {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	var (
+		values []string
+		etyp   = g.goType(element, name)
+	)
+	for _, e := range elements {
+		value, ok := g.value(element, e, name)
+		if !ok {
+			return "", false
+		}
+		if strings.HasPrefix(value, etyp+"{") {
+			value = value[len(etyp):]
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return typ + "{}", true
+	}
+	return typ + "{\n" + strings.Join(values, ",\n") + ",\n}", true
+}
+
+// namedValues returns the components of v, a SEQUENCE or SET value, which
+// may have been parsed as an object identifier: "{ a 1 }" names one
+// component as well as two arcs.
+func namedValues(v asn1c.Value) ([]*asn1c.NamedValue, bool) {
+	switch value := v.(type) {
+	case *asn1c.SequenceValue:
+		return value.Components, true
+	case *asn1c.ObjectIdentifierValue:
+		if len(value.Components)%2 != 0 {
+			return nil, false
+		}
+		var components []*asn1c.NamedValue
+		for i := 0; i < len(value.Components); i += 2 {
+			name, arc := value.Components[i], value.Components[i+1]
+			if nil != name.Value {
+				return nil, false
+			}
+			named := &asn1c.NamedValue{Position: name.Position, Name: name.Name, Value: arc.Value}
+			if nil == arc.Value {
+				named.Value = &asn1c.ReferencedValue{Position: arc.Position, Name: arc.Name}
+			} else if len(arc.Name) != 0 {
+				return nil, false
+			}
+			components = append(components, named)
+		}
+		return components, true
+	}
+	return nil, false
+}
+
+// byteList returns the elements of a composite literal of b.
+func byteList(b []byte) string {
+	bytes := make([]string, len(b))
+	for i, c := range b {
+		bytes[i] = fmt.Sprintf("0x%02x", c)
+	}
+	return strings.Join(bytes, ", ")
+}
@@ -0,0 +1,99 @@
+package codegen
+
+import (
+	"fmt"
+	"math/big"
+
+	asn1c "github.com/thebagchi/asn1c-go"
+)
+
+// enumerated writes the constants of t, the ENUMERATED named name: one for
+// each item, root items first, named after the type and the item and
+// holding the number X.680 gives the item. Items whose names map to the
+// same Go name are told apart by a number, in the order they are written.
+// String and a lookup by name go from constants to the names of the items
+// and back, and Index gives the enumeration index X.691 encodes.
+func (g *generator) enumerated(t *asn1c.EnumeratedType, name string) {
+	var (
+		items   = append(append([]*asn1c.NamedNumber(nil), t.Items...), t.Additions...)
+		numbers = g.program.Enumerate(t)
+	)
+	if len(numbers) != len(items) {
+		g.errorf(t.Position, "the items of %s cannot be numbered", name)
+		return
+	}
+	var (
+		constants = make([]string, len(items))
+		seen      = map[string]bool{}
+		unique    = make([]bool, len(items))
+	)
+	for i, item := range items {
+		if !numbers[i].IsInt64() {
+			g.errorf(item.Position, "%s is numbered %s, beyond an int", item.Name, numbers[i])
+			return
+		}
+		constants[i] = g.unique(name + goName(item.Name))
+		if nil == g.items[t] {
+			g.items[t] = map[string]string{}
+		}
+		g.items[t][item.Name] = constants[i]
+		unique[i] = !seen[numbers[i].String()]
+		seen[numbers[i].String()] = true
+	}
+	b := &g.file.body
+	fmt.Fprintf(b, "\nconst (\n")
+	for i := range items {
+		fmt.Fprintf(b, "%s %s = %s\n", constants[i], name, numbers[i])
+	}
+	fmt.Fprintf(b, ")\n")
+
+	g.file.imports["strconv"] = true
+	fmt.Fprintf(b, "\n// String returns the name of the item e is in ASN.1.\n")
+	fmt.Fprintf(b, "func (e %s) String() string {\nswitch e {\n", name)
+	for i, item := range items {
+		if unique[i] {
+			fmt.Fprintf(b, "case %s:\nreturn %q\n", constants[i], item.Name)
+		}
+	}
+	fmt.Fprintf(b, "}\nreturn %q + strconv.Itoa(int(e)) + \")\"\n}\n", name+"(")
+
+	lookup := g.unique(name + "FromString")
+	fmt.Fprintf(b, "\n// %s returns the item of %s named name in ASN.1.\n", lookup, name)
+	fmt.Fprintf(b, "func %s(name string) (%s, bool) {\nswitch name {\n", lookup, name)
+	for i, item := range items {
+		fmt.Fprintf(b, "case %q:\nreturn %s, true\n", item.Name, constants[i])
+	}
+	fmt.Fprintf(b, "}\nreturn 0, false\n}\n")
+
+	root := numbers[:len(t.Items)]
+	fmt.Fprintf(b, "\n// Index returns the position of e among the root items, or among the\n")
+	fmt.Fprintf(b, "// extension additions when extension is set, in the order of their\n")
+	fmt.Fprintf(b, "// numbers, which X.691 encodes. Ok is false when e is no item.\n")
+	fmt.Fprintf(b, "func (e %s) Index() (index int, extension bool, ok bool) {\nswitch e {\n", name)
+	for i := range items {
+		if !unique[i] {
+			continue
+		}
+		if i < len(root) {
+			fmt.Fprintf(b, "case %s:\nreturn %d, false, true\n", constants[i], rank(root, numbers[i]))
+		} else {
+			fmt.Fprintf(b, "case %s:\nreturn %d, true, true\n", constants[i], rank(numbers[len(root):], numbers[i]))
+		}
+	}
+	fmt.Fprintf(b, "}\nreturn 0, false, false\n}\n")
+}
+
+// rank counts the distinct numbers of set below n.
+func rank(set []*big.Int, n *big.Int) int {
+	var (
+		count int
+		seen  = map[string]bool{}
+	)
+	for _, m := range set {
+		if m.Cmp(n) < 0 && !seen[m.String()] {
+			seen[m.String()] = true
+			count++
+		}
+	}
+	return count
+}
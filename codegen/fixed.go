@@ -0,0 +1,48 @@
+package codegen
+
+import (
+	"fmt"
+	"math"
+
+	asn1c "github.com/thebagchi/asn1c-go"
+)
+
+// fixed returns the one size the effective constraint of t allows, -1
+// when it allows more or is extensible.
+func (g *generator) fixed(t asn1c.Type) int64 {
+	n := g.program.Effective(t)
+	if n.SizeExtensible || len(n.Size) != 1 {
+		return -1
+	}
+	r := n.Size[0]
+	if nil == r.Lower || nil == r.Upper || r.Lower.Cmp(r.Upper) != 0 || !r.Upper.IsInt64() || r.Upper.Int64() > math.MaxInt32 {
+		return -1
+	}
+	return r.Upper.Int64()
+}
+
+// array returns the length of the array of bytes t is generated as, -1
+// when it is not an OCTET STRING generated as an array.
+func (g *generator) array(t asn1c.Type) int64 {
+	if u, ok := t.(*asn1c.BuiltinType); !ok || !g.arrays || u.Name != asn1c.OctetString {
+		return -1
+	}
+	return g.fixed(t)
+}
+
+// bits writes, for d, a BIT STRING of one size only, the constant of its
+// size and a constructor taking just enough bytes to hold it.
+func (g *generator) bits(d declaration) {
+	size := g.fixed(d.typ)
+	if size < 0 {
+		return
+	}
+	var (
+		b           = &g.file.body
+		constant    = g.unique(d.name + "Size")
+		constructor = g.unique("New" + d.name)
+	)
+	fmt.Fprintf(b, "\n// %s is the number of bits of %s.\nconst %s = %d\n", constant, d.name, constant, size)
+	fmt.Fprintf(b, "\n// %s returns the %s of the first %s bits of b.\n", constructor, d.name, constant)
+	fmt.Fprintf(b, "func %s(b [%d]byte) %s {\nreturn %s{Bytes: b[:], BitLength: %s}\n}\n", constructor, (size+7)/8, d.name, d.name, constant)
+}
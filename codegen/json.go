@@ -0,0 +1,68 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	asn1c "github.com/thebagchi/asn1c-go"
+)
+
+// jsonName returns the name of the JSON member of a component named name:
+// its Go name with the first letter in lower case.
+func jsonName(name string) string {
+	runes := []rune(goName(name))
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// marshalJSON writes, for d, defined as definition, the MarshalJSON method
+// of a BIT STRING, an object of its bits in hexadecimal and their number,
+// of an OCTET STRING, its bytes in hexadecimal, and of an ENUMERATED, the
+// name of its item. A type naming one of those marshals as it does.
+func (g *generator) marshalJSON(d declaration, definition string, t asn1c.Type) {
+	var (
+		b    = &g.file.body
+		body string
+	)
+	switch u := t.(type) {
+	case *asn1c.BitStringType:
+		g.file.imports["encoding/hex"] = true
+		body = "return json.Marshal(struct {\nValue string `json:\"value\"`\nLength int `json:\"length\"`\n}{\"0x\" + hex.EncodeToString(v.Bytes), v.BitLength})\n"
+	case *asn1c.BuiltinType:
+		if !strings.HasSuffix(definition, "]byte") {
+			return
+		}
+		g.file.imports["encoding/hex"] = true
+		body = "return json.Marshal(hex.EncodeToString(v[:]))\n"
+	case *asn1c.EnumeratedType:
+		body = "return json.Marshal(v.String())\n"
+	case *asn1c.ReferencedType:
+		base, _, _, err := g.program.Underlying(u)
+		if nil != err || definition == "interface{}" {
+			return
+		}
+		switch base := base.(type) {
+		case *asn1c.BitStringType, *asn1c.EnumeratedType:
+		case *asn1c.BuiltinType:
+			if base.Name != asn1c.OctetString {
+				return
+			}
+		default:
+			return
+		}
+		body = fmt.Sprintf("return json.Marshal(%s(v))\n", definition)
+	default:
+		return
+	}
+	g.file.imports["encoding/json"] = true
+	fmt.Fprintf(b, "\n// MarshalJSON returns the JSON of v.\nfunc (v %s) MarshalJSON() ([]byte, error) {\n%s}\n", d.name, body)
+}
+
+// unmarshalJSON writes, for d, a type embedding the *big.Int of an
+// INTEGER, the UnmarshalJSON method setting it, which the method big.Int
+// would otherwise promote cannot do while it is nil.
+func (g *generator) unmarshalJSON(d declaration) {
+	fmt.Fprintf(&g.file.body, "\n// UnmarshalJSON sets v to the integer of b, leaving it alone for null.\nfunc (v *%s) UnmarshalJSON(b []byte) error {\n", d.name)
+	g.file.body.WriteString("if string(b) == \"null\" {\nreturn nil\n}\nn := new(big.Int)\nif err := n.UnmarshalJSON(b); nil != err {\nreturn err\n}\nv.Int = n\nreturn nil\n}\n")
+}
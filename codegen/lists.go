@@ -0,0 +1,39 @@
+package codegen
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// bounded writes, for d, a SEQUENCE OF or SET OF defined as the slice
+// definition, the constant of the upper bound of its size and an Append
+// method keeping to it, when its effective constraint has one that is not
+// extensible.
+func (g *generator) bounded(d declaration, definition string) {
+	if !strings.HasPrefix(definition, "[]") {
+		return
+	}
+	n := g.program.Effective(d.typ)
+	if n.SizeExtensible || len(n.Size) == 0 {
+		return
+	}
+	var upper int64
+	for _, r := range n.Size {
+		if nil == r.Upper || !r.Upper.IsInt64() || r.Upper.Int64() > math.MaxInt32 {
+			return
+		}
+		if r.Upper.Int64() > upper {
+			upper = r.Upper.Int64()
+		}
+	}
+	var (
+		b     = &g.file.body
+		bound = g.unique(d.name + "MaxSize")
+	)
+	fmt.Fprintf(b, "\n// %s is the upper bound of the size of %s.\nconst %s = %d\n", bound, d.name, bound, upper)
+	fmt.Fprintf(b, "\n// Append appends items to v, unless that makes it longer than %s.\n", bound)
+	fmt.Fprintf(b, "func (v *%s) Append(items ...%s) error {\n", d.name, definition[2:])
+	fmt.Fprintf(b, "if len(*v)+len(items) > %s {\nreturn Violations{{Path: %q, Message: %q}}\n}\n", bound, d.name, "size out of range "+ranges(n.Size))
+	fmt.Fprintf(b, "*v = append(*v, items...)\nreturn nil\n}\n")
+}
@@ -0,0 +1,329 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	asn1c "github.com/thebagchi/asn1c-go"
+)
+
+// table is how the object sets of a class are generated: a map, named
+// set, from the value of the UNIQUE field key of each object to a struct,
+// named object, of its fields. Presence is the constant of the item of
+// the presence field saying an object is mandatory, when the class has
+// such a field. The set of a class keyed by a procedure code, such as the
+// ELEMENTARY-PROCEDURE of the *-PDU-Descriptions modules, is the map from
+// procedure codes to the types of the PDUs; no other is generated.
+type table struct {
+	class    *asn1c.ObjectClass
+	name     string
+	object   string
+	set      string
+	key      string
+	fields   []tableField
+	presence string
+	declared bool
+}
+
+// tableField is a field of the struct of the objects of a class: one of
+// its fixed-type value fields, or a type field, which holds a function
+// returning a new value of the type and, in the field set typeName, the
+// name of the Go type.
+type tableField struct {
+	name     string
+	spec     *asn1c.FieldSpec
+	typeName bool
+}
+
+// table returns the table of the class named name, nil when its object
+// sets are not generated: when it has no UNIQUE fixed-type value field of
+// an INTEGER, ENUMERATED or character string type.
+func (g *generator) table(name string) *table {
+	class := g.class(name)
+	if nil == class {
+		return nil
+	}
+	if t, ok := g.tables[class]; ok {
+		return t
+	}
+	g.tables[class] = nil
+	var key *asn1c.FieldSpec
+	for _, field := range class.Fields {
+		if field.Unique && field.Kind == asn1c.FixedTypeValueField && g.constant(field.Type) {
+			key = field
+		}
+	}
+	if nil == key {
+		return nil
+	}
+	t := &table{class: class, name: name}
+	names := map[string]bool{}
+	add := func(name string, field *asn1c.FieldSpec, typeName bool) {
+		unique := name
+		for i := 2; names[unique]; i++ {
+			unique = name + strconv.Itoa(i)
+		}
+		names[unique] = true
+		t.fields = append(t.fields, tableField{name: unique, spec: field, typeName: typeName})
+	}
+	for _, field := range class.Fields {
+		name := goName(strings.TrimPrefix(field.Name, "&"))
+		switch {
+		case field.Kind == asn1c.TypeField:
+			add(name, field, false)
+			add(name+"Type", field, true)
+		case field.Kind == asn1c.FixedTypeValueField && g.constant(field.Type):
+			add(name, field, false)
+		}
+	}
+	t.object = g.unique(goName(name) + "Object")
+	t.set = g.unique(goName(name) + "Set")
+	g.tables[class] = t
+	for _, field := range t.fields {
+		if field.spec == key {
+			t.key = field.name
+		}
+	}
+	return t
+}
+
+// constant reports whether the values of t make Go constants and keys.
+func (g *generator) constant(t asn1c.Type) bool {
+	base, _, _, err := g.program.Underlying(t)
+	if nil != err {
+		return false
+	}
+	switch u := base.(type) {
+	case *asn1c.IntegerType:
+		kind := g.integerOf(t)
+		return kind == "int64" || kind == "uint64"
+	case *asn1c.EnumeratedType:
+		return true
+	case *asn1c.BuiltinType:
+		return u.Name == asn1c.Boolean || characters(u.Name)
+	}
+	return false
+}
+
+// declareTable writes the types of t, the struct of an object and the map
+// of a set of them, with a Missing method when the class has the presence
+// field of the options, of an ENUMERATED type with their mandatory item.
+func (g *generator) declareTable(t *table) {
+	t.declared = true
+	b := &g.file.body
+	var key *asn1c.FieldSpec
+	fmt.Fprintf(b, "\n// %s is an object of %s.\ntype %s struct {\n", t.object, t.name, t.object)
+	for _, field := range t.fields {
+		switch {
+		case field.typeName:
+			fmt.Fprintf(b, "// %s is the name of the Go type of %s.\n%s string\n", field.name, field.spec.Name, field.name)
+			continue
+		case field.spec.Kind == asn1c.TypeField:
+			fmt.Fprintf(b, "// %s returns a new value of the type of %s.\n%s func() interface{}\n", field.name, field.spec.Name, field.name)
+			continue
+		}
+		fmt.Fprintf(b, "%s %s\n", field.name, g.goType(field.spec.Type, t.object+field.name))
+		if field.name == t.key {
+			key = field.spec
+		}
+	}
+	keyType := g.goType(key.Type, t.object+t.key)
+	fmt.Fprintf(b, "}\n\n// %s holds objects of %s by their %s.\n", t.set, t.name, key.Name)
+	fmt.Fprintf(b, "type %s map[%s]%s\n", t.set, keyType, t.object)
+	presence := t.class.Field(g.presence)
+	if nil == presence || presence.Kind != asn1c.FixedTypeValueField {
+		return
+	}
+	enumerated, _, _, err := g.program.Underlying(presence.Type)
+	if nil != err {
+		return
+	}
+	if u, ok := enumerated.(*asn1c.EnumeratedType); ok {
+		t.presence = g.items[u][g.mandatory]
+	}
+	var field string
+	for _, f := range t.fields {
+		if f.spec == presence {
+			field = f.name
+		}
+	}
+	if len(t.presence) == 0 || len(field) == 0 {
+		return
+	}
+	g.file.imports["sort"] = true
+	fmt.Fprintf(b, "\n// Missing returns the keys of the mandatory objects of s that are not\n// among keys, in increasing order.\n")
+	fmt.Fprintf(b, "func (s %s) Missing(keys []%s) []%s {\n", t.set, keyType, keyType)
+	fmt.Fprintf(b, "present := map[%s]bool{}\nfor _, key := range keys {\npresent[key] = true\n}\n", keyType)
+	fmt.Fprintf(b, "var missing []%s\nfor key, object := range s {\n", keyType)
+	fmt.Fprintf(b, "if object.%s == %s && !present[key] {\nmissing = append(missing, key)\n}\n}\n", field, t.presence)
+	fmt.Fprintf(b, "sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })\nreturn missing\n}\n")
+}
+
+// objectSets writes the tables of the object sets of module, root objects
+// and extension additions alike, and the lists of their objects in the
+// order they are written in.
+func (g *generator) objectSets(module *asn1c.CheckedModule) {
+	for _, assignment := range module.Module.Assignments {
+		a, ok := assignment.(*asn1c.ObjectSetAssignment)
+		if !ok {
+			continue
+		}
+		name, ok := g.assigned[a]
+		if !ok {
+			continue
+		}
+		t := g.table(a.Class)
+		if !t.declared {
+			g.declareTable(t)
+		}
+		b := &g.file.body
+		b.WriteString("\n")
+		comment(b, note(a.Doc, a.Comment))
+		fmt.Fprintf(b, "var %s = %s{\n", name, t.set)
+		var (
+			keys  = map[string]bool{}
+			order []string
+		)
+		for _, object := range g.objects(&a.Set.ElementSetSpecs, 0) {
+			if key := g.object(t, object, name, keys); len(key) != 0 {
+				order = append(order, key)
+			}
+		}
+		b.WriteString("}\n")
+		list := g.unique(name + "Objects")
+		fmt.Fprintf(b, "\n// %s lists the objects of %s in order.\nvar %s = []%s{\n", list, name, list, t.object)
+		for _, key := range order {
+			fmt.Fprintf(b, "%s[%s],\n", name, key)
+		}
+		b.WriteString("}\n")
+	}
+}
+
+// object writes the entry of object in the table of the set named name,
+// unless keys holds its key already, and returns its key, empty when it is
+// not written.
+func (g *generator) object(t *table, object *asn1c.InformationObject, name string, keys map[string]bool) string {
+	var (
+		entries []string
+		key     string
+	)
+	for _, field := range t.fields {
+		setting := object.Field(field.spec.Name)
+		if nil == setting {
+			setting = field.spec.Default
+		}
+		if nil == setting {
+			continue
+		}
+		if field.spec.Kind == asn1c.TypeField {
+			if nil == setting.Type {
+				continue
+			}
+			typ := g.goType(setting.Type, name+goName(strings.TrimPrefix(field.spec.Name, "&")))
+			if field.typeName {
+				entries = append(entries, fmt.Sprintf("%s: %q,\n", field.name, typ))
+			} else {
+				entries = append(entries, fmt.Sprintf("%s: func() interface{} { return new(%s) },\n", field.name, typ))
+			}
+			continue
+		}
+		value := g.literal(field.spec.Type, setting.Value)
+		if len(value) == 0 {
+			g.errorf(object.Position, "the value of %s has no Go constant", field.spec.Name)
+			return ""
+		}
+		if constant := g.valueConstant(setting.Value); len(constant) != 0 {
+			value = constant
+		}
+		if field.name == t.key {
+			resolved := g.literal(field.spec.Type, setting.Value)
+			if keys[resolved] {
+				return ""
+			}
+			keys[resolved], key = true, value
+		}
+		entries = append(entries, fmt.Sprintf("%s: %s,\n", field.name, value))
+	}
+	if len(key) == 0 {
+		g.errorf(object.Position, "object without %s", t.key)
+		return ""
+	}
+	fmt.Fprintf(&g.file.body, "%s: {\n%s},\n", key, strings.Join(entries, ""))
+	return key
+}
+
+// valueConstant returns the constant generated for the value v refers to,
+// empty when it is not a reference to one.
+func (g *generator) valueConstant(v asn1c.Value) string {
+	ref, ok := v.(*asn1c.ReferencedValue)
+	if !ok {
+		return ""
+	}
+	if a, ok := g.referenced(ref, ref.Name).(*asn1c.ValueAssignment); ok {
+		return g.assigned[a]
+	}
+	return ""
+}
+
+// objects lists the objects of specs, following references to objects and
+// object sets.
+func (g *generator) objects(specs *asn1c.ElementSetSpecs, depth int) []*asn1c.InformationObject {
+	var (
+		objects []*asn1c.InformationObject
+		walk    func(asn1c.Element)
+	)
+	walk = func(element asn1c.Element) {
+		switch e := element.(type) {
+		case *asn1c.UnionElement:
+			for _, element := range e.Elements {
+				walk(element)
+			}
+		case *asn1c.ObjectElement:
+			if object := g.defined(e.Object, depth); nil != object {
+				objects = append(objects, object)
+			}
+		case *asn1c.ObjectSetElement:
+			if a, ok := g.referenced(e, e.Name).(*asn1c.ObjectSetAssignment); ok && depth <= len(g.assigned) {
+				objects = append(objects, g.objects(&a.Set.ElementSetSpecs, depth+1)...)
+			}
+		default:
+			g.errorf(element.Pos(), "the objects of a set other than a union cannot be listed")
+		}
+	}
+	if nil != specs.Root {
+		walk(specs.Root)
+	}
+	if nil != specs.Additional {
+		walk(specs.Additional)
+	}
+	return objects
+}
+
+// defined follows object through references to the object it names.
+func (g *generator) defined(object *asn1c.InformationObject, depth int) *asn1c.InformationObject {
+	for ; len(object.Reference) != 0; depth++ {
+		a, ok := g.referenced(object, object.Reference).(*asn1c.ObjectAssignment)
+		if !ok || depth > len(g.assigned) {
+			return nil
+		}
+		object = a.Object
+	}
+	return object
+}
+
+// referenced returns the assignment n, a reference to name, refers to,
+// following imports.
+func (g *generator) referenced(n asn1c.Node, name string) asn1c.Assignment {
+	reference := g.program.Reference(n)
+	switch {
+	case nil == reference:
+		return nil
+	case nil != reference.Import:
+		if definition := g.program.Imported(reference.Import, name); nil != definition {
+			return definition.Assignment
+		}
+		return nil
+	}
+	return reference.Assignment
+}
@@ -0,0 +1,514 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	asn1c "github.com/thebagchi/asn1c-go"
+)
+
+// validate writes the Validate method of d, t being the type it is the
+// definition of, and validate, which checks a value at path for Validate
+// and for the types holding one. The checks are those of the effective
+// constraints: values, sizes and permitted alphabets, the items of an
+// ENUMERATED without extension marker, open type components present and
+// one alternative of a CHOICE set. Extensible constraints are not checked,
+// as values beyond their root are valid.
+func (g *generator) validate(d declaration, t asn1c.Type) {
+	if g.open(d.typ, 0) {
+		return
+	}
+	var body bytes.Buffer
+	x := "v"
+	if g.boxed(t) {
+		// v embeds the *big.Int its value is held in.
+		x = "*v.Int"
+	}
+	switch u := t.(type) {
+	case *asn1c.SequenceType:
+		g.components(&body, &u.ComponentList)
+	case *asn1c.SetType:
+		g.components(&body, &u.ComponentList)
+	case *asn1c.ChoiceType:
+		g.alternatives(&body, &u.ComponentList)
+	case *asn1c.EnumeratedType:
+		if !u.Extensible {
+			fmt.Fprintf(&body, "if _, _, ok := v.Index(); !ok {\n%s}\n", violation("path", "unknown item"))
+		}
+	case *asn1c.ReferencedType:
+		// v is of the type named, which validate is not called on as that
+		// would call itself.
+		if _, name, err := g.follow(u); nil == err && len(name) != 0 {
+			fmt.Fprintf(&body, "%s(v).validate(path, violations)\n", name)
+			for _, c := range u.Constraints {
+				body.WriteString(g.constrain("v", u, g.program.Normalize(c), "path"))
+			}
+		}
+	default:
+		body.WriteString(g.check(x, t, "path", 0))
+	}
+	if nil != d.set {
+		body.WriteString(g.constrain(x, d.typ, g.program.Normalize(d.set), "path"))
+	}
+	code := body.String()
+	if x != "v" {
+		code = guarded("v.Int", "path", code)
+	}
+	b := &g.file.body
+	fmt.Fprintf(b, "\n// Validate returns the Violations of the constraints v breaks, nil when\n// it breaks none.\n")
+	fmt.Fprintf(b, "func (v %s) Validate() error {\nvar violations Violations\n", d.name)
+	fmt.Fprintf(b, "v.validate(%q, &violations)\nif len(violations) != 0 {\nreturn violations\n}\nreturn nil\n}\n", d.name)
+	fmt.Fprintf(b, "\nfunc (v %s) validate(path string, violations *Violations) {\n%s}\n", d.name, code)
+}
+
+// components writes the checks of the fields of the struct of list, a
+// SEQUENCE or SET.
+func (g *generator) components(b *bytes.Buffer, list *asn1c.ComponentList) {
+	for _, field := range g.fields[list] {
+		path := "path+" + strconv.Quote("."+field.name)
+		switch {
+		case field.open:
+			if !field.optional {
+				fmt.Fprintf(b, "if nil == v.%s {\n%s}\n", field.name, violation(path, "missing"))
+			}
+		case field.optional:
+			if code := g.check("*v."+field.name, field.component.Type, path, 0); len(code) != 0 {
+				fmt.Fprintf(b, "if nil != v.%s {\n%s}\n", field.name, code)
+			}
+		case g.boxed(field.component.Type):
+			b.WriteString(g.pointed("v."+field.name, field.component.Type, path, 0))
+		default:
+			b.WriteString(g.check("v."+field.name, field.component.Type, path, 0))
+		}
+	}
+}
+
+// alternatives writes the checks of the fields of the struct of list, a
+// CHOICE, of which one is to be set.
+func (g *generator) alternatives(b *bytes.Buffer, list *asn1c.ComponentList) {
+	b.WriteString("var alternatives int\n")
+	for _, field := range g.fields[list] {
+		fmt.Fprintf(b, "if nil != v.%s {\nalternatives++\n", field.name)
+		if !field.open {
+			b.WriteString(g.check("*v."+field.name, field.component.Type, "path+"+strconv.Quote("."+field.name), 0))
+		}
+		b.WriteString("}\n")
+	}
+	fmt.Fprintf(b, "switch {\ncase alternatives == 0:\n%s", violation("path", "no alternative set"))
+	fmt.Fprintf(b, "case alternatives > 1:\n%s}\n", violation("path", "more than one alternative set"))
+}
+
+// check returns the code checking x, a value of t, at path, depth being the
+// number of loops over the elements of lists it is within.
+func (g *generator) check(x string, t asn1c.Type, path string, depth int) string {
+	if depth > len(g.assigned)+1 {
+		return ""
+	}
+	switch u := t.(type) {
+	case *asn1c.ReferencedType:
+		target, name, err := g.follow(u)
+		if nil != err || g.open(u, 0) {
+			return ""
+		}
+		var code string
+		if len(name) != 0 {
+			code = fmt.Sprintf("%s.validate(%s, violations)\n", selector(x), path)
+		} else {
+			code = g.check(x, target, path, depth+1)
+		}
+		for _, c := range u.Constraints {
+			code += g.constrain(x, u, g.program.Normalize(c), path)
+		}
+		return code
+	case *asn1c.SequenceType, *asn1c.SetType, *asn1c.ChoiceType, *asn1c.EnumeratedType:
+		return fmt.Sprintf("%s.validate(%s, violations)\n", selector(x), path)
+	case *asn1c.SequenceOfType:
+		return g.constrain(x, t, g.program.Effective(t), path) + g.elements(x, u.Element, path, depth)
+	case *asn1c.SetOfType:
+		return g.constrain(x, t, g.program.Effective(t), path) + g.elements(x, u.Element, path, depth)
+	case *asn1c.IntegerType, *asn1c.BitStringType, *asn1c.BuiltinType:
+		return g.constrain(x, t, g.program.Effective(t), path)
+	case *asn1c.SelectionType:
+		if alternative := g.selected(u); nil != alternative {
+			return g.check(x, alternative, path, depth+1)
+		}
+	case *asn1c.ObjectClassFieldType:
+		if field := g.field(u); nil != field && field.Kind == asn1c.FixedTypeValueField {
+			return g.check(x, field.Type, path, depth+1)
+		}
+	}
+	return ""
+}
+
+// elements returns the code checking the elements of the list x, of type
+// t.
+func (g *generator) elements(x string, t asn1c.Type, path string, depth int) string {
+	i := "i" + strconv.Itoa(strings.Count(x, "[i")+1)
+	index := `+"["+strconv.Itoa(` + i + `)+"]"`
+	if strings.HasSuffix(path, `"`) {
+		index = `["+strconv.Itoa(` + i + `)+"]"`
+		path = path[:len(path)-1]
+	}
+	code := g.check(paren(x)+"["+i+"]", t, path+index, depth+1)
+	if g.boxed(t) {
+		code = g.pointed(paren(x)+"["+i+"]", t, path+index, depth+1)
+	}
+	if len(code) == 0 {
+		return ""
+	}
+	g.file.imports["strconv"] = true
+	return fmt.Sprintf("for %s := range %s {\n%s}\n", i, x, code)
+}
+
+// pointed returns the code checking x, a pointer to a value of t which is
+// missing when nil, at path.
+func (g *generator) pointed(x string, t asn1c.Type, path string, depth int) string {
+	return guarded(x, path, g.check("*"+x, t, path, depth))
+}
+
+// guarded returns code, run when the pointer x is not nil, reporting x
+// missing at path when it is.
+func guarded(x, path, code string) string {
+	guard := fmt.Sprintf("if nil == %s {\n%s}", x, violation(path, "missing"))
+	if len(code) != 0 {
+		guard += " else {\n" + code + "}"
+	}
+	return guard + "\n"
+}
+
+// constrain returns the code checking x, a value of t, against n.
+func (g *generator) constrain(x string, t asn1c.Type, n *asn1c.NormalizedConstraint, path string) string {
+	base, _, _, err := g.program.Underlying(t)
+	if nil != err {
+		return ""
+	}
+	var (
+		b    strings.Builder
+		size string
+	)
+	switch u := base.(type) {
+	case *asn1c.IntegerType:
+		if kind := g.integerOf(t); len(kind) != 0 && !n.Extensible {
+			var set string
+			if kind == "*big.Int" && !g.boxed(t) {
+				// x embeds the *big.Int its value is held in, which its
+				// validate reports missing when nil.
+				set = "nil != " + selector(x) + ".Int && "
+				x = "*" + selector(x) + ".Int"
+			}
+			if condition := g.outside(x, kind, n.Values); len(condition) != 0 {
+				if len(set) != 0 {
+					condition = set + "(" + condition + ")"
+				}
+				fmt.Fprintf(&b, "if %s {\n%s}\n", condition, violation(path, "value out of range "+ranges(n.Values)))
+			}
+		}
+	case *asn1c.BitStringType:
+		size = selector(x) + ".BitLength"
+	case *asn1c.SequenceOfType, *asn1c.SetOfType:
+		size = "len(" + x + ")"
+	case *asn1c.BuiltinType:
+		switch {
+		case u.Name == asn1c.OctetString && g.array(t) < 0:
+			size = "len(" + x + ")"
+		case characters(u.Name):
+			size = "utf8.RuneCountInString(string(" + x + "))"
+			if condition := g.outside("r", "rune", n.Alphabet); len(condition) != 0 {
+				fmt.Fprintf(&b, "for _, r := range string(%s) {\nif %s {\n%sbreak\n}\n}\n", x, condition, violation(path, "character out of the permitted alphabet"))
+			}
+		}
+	}
+	if len(size) != 0 && !n.SizeExtensible {
+		if condition := g.outside(size, "int", n.Size); len(condition) != 0 {
+			if strings.HasPrefix(size, "utf8.") {
+				g.file.imports["unicode/utf8"] = true
+			}
+			fmt.Fprintf(&b, "if %s {\n%s}\n", condition, violation(path, "size out of range "+ranges(n.Size)))
+		}
+	}
+	return b.String()
+}
+
+// characters reports whether the builtin type named name is a character
+// string type, held in a string.
+func characters(name string) bool {
+	switch name {
+	case asn1c.Boolean, asn1c.Null, asn1c.Real, asn1c.ObjectIdentifier, asn1c.RelativeOID,
+		asn1c.UTCTime, asn1c.GeneralizedTime, asn1c.OctetString, asn1c.Externel,
+		asn1c.EmbeddedPDV, asn1c.CharacterString:
+		return false
+	}
+	return true
+}
+
+var limits = map[string][2]*big.Int{
+	"int64":  {minInt64, maxInt64},
+	"uint64": {new(big.Int), maxUint64},
+	"int":    {new(big.Int), big.NewInt(math.MaxInt32)},
+	"rune":   {big.NewInt(math.MinInt32), big.NewInt(math.MaxInt32)},
+}
+
+// outside returns the condition of x, of the Go type kind, being out of s,
+// empty when it cannot be. Bounds beyond those of kind are left out: an
+// int is taken to hold the sizes up to those of 32 bits.
+func (g *generator) outside(x, kind string, s asn1c.RangeSet) string {
+	var alternatives []asn1c.Range
+	limit, bounded := limits[kind]
+	for _, r := range s {
+		if bounded {
+			if (nil != r.Lower && r.Lower.Cmp(limit[1]) > 0) || (nil != r.Upper && r.Upper.Cmp(limit[0]) < 0) {
+				continue
+			}
+			if nil != r.Lower && r.Lower.Cmp(limit[0]) <= 0 {
+				r.Lower = nil
+			}
+			if nil != r.Upper && r.Upper.Cmp(limit[1]) >= 0 {
+				r.Upper = nil
+			}
+		}
+		if nil == r.Lower && nil == r.Upper {
+			return ""
+		}
+		alternatives = append(alternatives, r)
+	}
+	if len(alternatives) == 0 {
+		return "true"
+	}
+	if len(alternatives) == 1 {
+		return strings.Join(g.compare(x, kind, alternatives[0], "<", ">", "!="), " || ")
+	}
+	var conditions []string
+	for _, r := range alternatives {
+		parts := g.compare(x, kind, r, ">=", "<=", "==")
+		if len(parts) == 1 {
+			conditions = append(conditions, parts[0])
+		} else {
+			conditions = append(conditions, "("+strings.Join(parts, " && ")+")")
+		}
+	}
+	return "!(" + strings.Join(conditions, " || ") + ")"
+}
+
+// compare returns the comparisons of x, of the Go type kind, with the
+// bounds of r, by lower and upper, or with the one integer of r by single.
+func (g *generator) compare(x, kind string, r asn1c.Range, lower, upper, single string) []string {
+	if nil != r.Lower && nil != r.Upper && r.Lower.Cmp(r.Upper) == 0 && kind != "*big.Int" {
+		r.Lower, upper = nil, single
+	}
+	var parts []string
+	for _, bound := range []struct {
+		n  *big.Int
+		op string
+	}{{r.Lower, lower}, {r.Upper, upper}} {
+		switch {
+		case nil == bound.n:
+		case kind == "rune" && strconv.IsPrint(rune(bound.n.Int64())):
+			parts = append(parts, x+" "+bound.op+" "+strconv.QuoteRune(rune(bound.n.Int64())))
+		case kind != "*big.Int":
+			parts = append(parts, x+" "+bound.op+" "+bound.n.String())
+		default:
+			g.file.imports["math/big"] = true
+			n := "big.NewInt(" + bound.n.String() + ")"
+			if !bound.n.IsInt64() {
+				g.big = true
+				n = "bigInt(" + strconv.Quote(bound.n.String()) + ")"
+			}
+			// x is the big.Int a *big.Int points to.
+			parts = append(parts, selector(x)+".Cmp("+n+") "+bound.op+" 0")
+		}
+	}
+	return parts
+}
+
+// ranges writes s as ASN.1 does, for the messages of violations.
+func ranges(s asn1c.RangeSet) string {
+	var parts []string
+	for _, r := range s {
+		lower, upper := "MIN", "MAX"
+		if nil != r.Lower {
+			lower = r.Lower.String()
+		}
+		if nil != r.Upper {
+			upper = r.Upper.String()
+		}
+		if lower == upper {
+			parts = append(parts, lower)
+		} else {
+			parts = append(parts, lower+".."+upper)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+func violation(path, message string) string {
+	return fmt.Sprintf("violations.add(%s, %q)\n", path, message)
+}
+
+// paren puts x in parentheses when it is a pointer indirection, for an
+// index to apply to what it points to.
+func paren(x string) string {
+	if strings.HasPrefix(x, "*") {
+		return "(" + x + ")"
+	}
+	return x
+}
+
+// selector returns x for a selector to apply to, the pointer of a pointer
+// indirection, which the selector goes through.
+func selector(x string) string {
+	return strings.TrimPrefix(x, "*")
+}
+
+// integerOf returns the Go type the INTEGER values of t are held in, found
+// where the type they are of is declared, empty when there is none.
+func (g *generator) integerOf(t asn1c.Type) string {
+	for depth := 0; depth <= len(g.assigned); depth++ {
+		switch u := t.(type) {
+		case *asn1c.ReferencedType:
+			target, _, err := g.follow(u)
+			if nil != err {
+				return ""
+			}
+			t = target
+		case *asn1c.SelectionType:
+			t = g.selected(u)
+		case *asn1c.ObjectClassFieldType:
+			field := g.field(u)
+			if nil == field || field.Kind != asn1c.FixedTypeValueField {
+				return ""
+			}
+			t = field.Type
+		case *asn1c.IntegerType:
+			return g.integerKind(u)
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// boxed reports whether the values of t are held in a *big.Int, nil when
+// missing, rather than in a type declared by a name embedding one.
+func (g *generator) boxed(t asn1c.Type) bool {
+	for depth := 0; depth <= len(g.assigned); depth++ {
+		switch u := t.(type) {
+		case *asn1c.ReferencedType:
+			target, name, err := g.follow(u)
+			if nil != err || len(name) != 0 {
+				return false
+			}
+			t = target
+		case *asn1c.SelectionType:
+			t = g.selected(u)
+		case *asn1c.ObjectClassFieldType:
+			field := g.field(u)
+			if nil == field || field.Kind != asn1c.FixedTypeValueField {
+				return false
+			}
+			t = field.Type
+		case *asn1c.IntegerType:
+			return g.integerKind(u) == "*big.Int"
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// open reports whether the values of t are held in an interface{}.
+func (g *generator) open(t asn1c.Type, depth int) bool {
+	if depth > len(g.assigned) {
+		return true
+	}
+	switch u := t.(type) {
+	case *asn1c.ReferencedType:
+		target, _, err := g.follow(u)
+		return nil != err || g.open(target, depth+1)
+	case *asn1c.AnyType:
+		return true
+	case *asn1c.ObjectClassFieldType:
+		field := g.field(u)
+		return nil == field || field.Kind != asn1c.FixedTypeValueField || g.open(field.Type, depth+1)
+	case *asn1c.SelectionType:
+		alternative := g.selected(u)
+		return nil == alternative || g.open(alternative, depth+1)
+	}
+	return false
+}
+
+// selected returns the type of the alternative t selects, nil when there
+// is none.
+func (g *generator) selected(t *asn1c.SelectionType) asn1c.Type {
+	choice, _, _, err := g.program.Underlying(t.Type)
+	if nil != err {
+		return nil
+	}
+	if list, ok := choice.(*asn1c.ChoiceType); ok {
+		for _, component := range components(&list.ComponentList) {
+			if component.Name == t.Alternative {
+				return component.Type
+			}
+		}
+	}
+	return nil
+}
+
+// runtime declares the Violations Validate returns, and ASN1Names, the
+// references of the Go names.
+func (g *generator) runtime() {
+	b := &g.file.body
+	g.file.imports["strings"] = true
+	if g.big {
+		g.file.imports["math/big"] = true
+	}
+	b.WriteString(`
+// Violation is a constraint a value breaks, with the path of the field of
+// the value breaking it.
+type Violation struct {
+	Path    string
+	Message string
+}
+
+func (v Violation) Error() string {
+	return v.Path + ": " + v.Message
+}
+
+// Violations lists the constraints a value breaks.
+type Violations []Violation
+
+func (v Violations) Error() string {
+	messages := make([]string, len(v))
+	for i, violation := range v {
+		messages[i] = violation.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (v *Violations) add(path, message string) {
+	*v = append(*v, Violation{Path: path, Message: message})
+}
+`)
+	b.WriteString("\n// ASN1Names maps the Go names of the assignments of the modules to their\n// references, qualified by the names of their modules.\n")
+	b.WriteString("var ASN1Names = map[string]string{\n")
+	for _, module := range g.program.Modules {
+		for _, assignment := range module.Module.Assignments {
+			if name, ok := g.assigned[assignment]; ok {
+				fmt.Fprintf(b, "%q: %q,\n", name, module.Module.Name+"."+assignment.Reference())
+			}
+		}
+	}
+	b.WriteString("}\n")
+	if g.big {
+		b.WriteString(`
+// bigInt returns the integer written in decimal as s.
+func bigInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+`)
+	}
+}
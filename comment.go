@@ -0,0 +1,110 @@
+package asn1c_go
+
+import (
+	"sort"
+	"strings"
+)
+
+// leadingComment returns the text of the comments on the lines right above
+// tok, the next token. A blank line ends them, and so does a comment that
+// follows another token on its line.
+func (p *parser) leadingComment(tok Token) string {
+	var (
+		i        = p.commentBefore(tok)
+		line     = tok.Position.Line
+		previous = 0
+		texts    []string
+	)
+	if p.index > 0 {
+		previous = p.tokens[p.index-1].Position.Line
+	}
+	for ; i >= 0; i-- {
+		comment := p.comments[i]
+		end := comment.Position.Line + strings.Count(comment.Text, "\n")
+		if comment.Position.Line <= previous || (end != line-1 && (len(texts) == 0 || end != line)) {
+			break
+		}
+		texts = append([]string{commentText(comment.Text)}, texts...)
+		line = comment.Position.Line
+	}
+	return strings.Join(texts, "\n")
+}
+
+// trailingComment returns the text of the comment following the last token
+// read on its line, after a comma if there is one.
+func (p *parser) trailingComment() string {
+	if p.index == 0 {
+		return ""
+	}
+	var (
+		last = p.tokens[p.index-1]
+		next = p.peek(0)
+	)
+	if p.is(",") {
+		next = p.peek(1)
+	}
+	i := p.commentBefore(next)
+	if i < 0 || p.comments[i].Position.Offset < last.Position.Offset {
+		return ""
+	}
+	for i > 0 && p.comments[i-1].Position.Offset > last.Position.Offset {
+		i--
+	}
+	if p.comments[i].Position.Line != last.Position.Line {
+		return ""
+	}
+	return commentText(p.comments[i].Text)
+}
+
+// innerComment returns the text of the comments between the tokens from and
+// to, one per line.
+func (p *parser) innerComment(from, to Token) string {
+	var texts []string
+	for i := p.commentBefore(to); i >= 0 && p.comments[i].Position.Offset > from.Position.Offset; i-- {
+		texts = append([]string{commentText(p.comments[i].Text)}, texts...)
+	}
+	return strings.Join(texts, "\n")
+}
+
+// commentBefore returns the index of the last comment ahead of tok, or -1.
+func (p *parser) commentBefore(tok Token) int {
+	if tok.Kind == TokenEOF && !tok.Position.IsValid() {
+		return len(p.comments) - 1
+	}
+	return sort.Search(len(p.comments), func(i int) bool {
+		return p.comments[i].Position.Offset >= tok.Position.Offset
+	}) - 1
+}
+
+// commentText strips a comment of its delimiters and the spaces around its
+// lines.
+func commentText(comment string) string {
+	if strings.HasPrefix(comment, "--") {
+		comment = strings.TrimSuffix(comment[2:], "--")
+	} else {
+		comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+	}
+	lines := strings.Split(strings.TrimSpace(comment), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// document sets the Doc and Comment of assignment.
+func document(assignment Assignment, doc, comment string) {
+	switch a := assignment.(type) {
+	case *TypeAssignment:
+		a.Doc, a.Comment = doc, comment
+	case *ValueAssignment:
+		a.Doc, a.Comment = doc, comment
+	case *ValueSetAssignment:
+		a.Doc, a.Comment = doc, comment
+	case *ObjectClassAssignment:
+		a.Doc, a.Comment = doc, comment
+	case *ObjectAssignment:
+		a.Doc, a.Comment = doc, comment
+	case *ObjectSetAssignment:
+		a.Doc, a.Comment = doc, comment
+	}
+}
@@ -0,0 +1,54 @@
+package asn1c_go
+
+import "testing"
+
+func TestComments(t *testing.T) {
+	set, err := ParseBytes("c.asn", []byte(`Comments DEFINITIONS ::= BEGIN
+-- Not attached, a blank line follows
+
+-- Criticality of the IE --
+-- second line
+Criticality ::= ENUMERATED { reject, ignore }
+Rec ::= SEQUENCE { -- after the brace
+
+	-- Leading for a
+	a INTEGER, -- trailing a
+	b BOOLEAN /* trailing b */,
+	/* block
+	   doc */
+	c NULL -- trailing c
+} -- after Rec
+v INTEGER ::= 3 -- after v
+-- Above w, below v
+
+w INTEGER ::= 4
+END
+`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	var (
+		module = set.Modules[0]
+		rec    = module.Lookup("Rec").(*TypeAssignment)
+		list   = rec.Type.(*SequenceType).Components
+	)
+	tests := []struct {
+		name         string
+		doc, comment string
+		wantDoc      string
+		wantComment  string
+	}{
+		{"assignment", module.Lookup("Criticality").(*TypeAssignment).Doc, "", "Criticality of the IE\nsecond line", ""},
+		{"structured", rec.Doc, rec.Comment, "", "after Rec"},
+		{"past a blank line", list[0].Doc, list[0].Comment, "Leading for a", "trailing a"},
+		{"before a comma", list[1].Doc, list[1].Comment, "", "trailing b"},
+		{"block", list[2].Doc, list[2].Comment, "block\ndoc", "trailing c"},
+		{"value", module.Lookup("v").(*ValueAssignment).Doc, module.Lookup("v").(*ValueAssignment).Comment, "", "after v"},
+		{"blank line", module.Lookup("w").(*ValueAssignment).Doc, module.Lookup("w").(*ValueAssignment).Comment, "", ""},
+	}
+	for _, test := range tests {
+		if test.doc != test.wantDoc || test.comment != test.wantComment {
+			t.Errorf("%s: got %q and %q, want %q and %q", test.name, test.doc, test.comment, test.wantDoc, test.wantComment)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package asn1c_go
+
+import (
+	"math/big"
+)
+
+// typedValue is a value written for a type, in a value assignment or as a
+// DEFAULT, checked against it once all values are resolved.
+type typedValue struct {
+	value Value
+	typ   Type
+}
+
+// checkConformance reports the values of value assignments and DEFAULTs
+// that are not values of their types: a value of another kind, such as a
+// BOOLEAN written for an INTEGER, an integer outside the constraints on
+// its type, or an object identifier built on a value that is not one.
+// Values are only judged where references can be followed, and extensible
+// constraints, which later versions may widen, admit every value.
+func (c *checker) checkConformance() {
+	for _, typed := range c.typed {
+		c.conforms(typed.value, typed.typ)
+	}
+}
+
+func (c *checker) conforms(v Value, t Type) {
+	governor := c.governor(t)
+	if _, enumerated := governor.(*EnumeratedType); enumerated {
+		if _, ok := v.(*ReferencedValue); !ok {
+			c.errorf(v.Pos(), "ENUMERATED value expected for the type at %s", governor.Pos())
+		}
+		return
+	}
+	resolved := c.valueOf(v)
+	if nil == resolved || nil == governor {
+		return
+	}
+	if name, ok := c.kindOf(resolved, governor); !ok {
+		c.errorf(v.Pos(), "%s value expected for the type at %s", name, governor.Pos())
+		return
+	}
+	if value, ok := resolved.(*IntegerValue); ok {
+		for _, constraint := range c.constraints(t) {
+			if constraint.Extensible || nil == constraint.Root {
+				continue
+			}
+			if admitted, known := c.admits(constraint.Root, value.Value); known && !admitted {
+				c.errorf(v.Pos(), "value %s is outside the constraint at %s", value.Value, constraint.Position)
+				return
+			}
+		}
+		return
+	}
+	// The values a reference leads to are checked where they are written.
+	switch value := v.(type) {
+	case *ObjectIdentifierValue:
+		c.objectIdentifierConforms(value)
+	case *SequenceValue:
+		list := componentList(governor)
+		if nil == list {
+			return
+		}
+		for _, component := range value.Components {
+			if t := componentType(list, component.Name); nil != t {
+				c.conforms(component.Value, t)
+			}
+		}
+	case *SequenceOfValue:
+		var element Type
+		switch g := governor.(type) {
+		case *SequenceOfType:
+			element = g.Element
+		case *SetOfType:
+			element = g.Element
+		}
+		if nil == element {
+			return
+		}
+		for _, e := range value.Elements {
+			c.conforms(e, element)
+		}
+	case *ChoiceValue:
+		if choice, ok := governor.(*ChoiceType); ok {
+			if t := componentType(&choice.ComponentList, value.Name); nil != t {
+				c.conforms(value.Value, t)
+			}
+		}
+	}
+}
+
+// kindOf reports whether v is of the kind of values governor has, with the
+// name of the type when it is not. Kinds the braced notations cannot tell
+// apart, and types not listed, accept any value.
+func (c *checker) kindOf(v Value, governor Type) (string, bool) {
+	switch g := governor.(type) {
+	case *IntegerType:
+		_, ok := v.(*IntegerValue)
+		return Integer, ok
+	case *BuiltinType:
+		switch g.Name {
+		case Boolean:
+			_, ok := v.(*BooleanValue)
+			return g.Name, ok
+		case Null:
+			_, ok := v.(*NullValue)
+			return g.Name, ok
+		case ObjectIdentifier, RelativeOID:
+			_, ok := v.(*ObjectIdentifierValue)
+			return g.Name, ok
+		case Real:
+			switch v.(type) {
+			case *RealValue, *IntegerValue, *SequenceValue:
+				return g.Name, true
+			}
+			return g.Name, false
+		}
+	case *SequenceType, *SetType, *SequenceOfType, *SetOfType:
+		switch v.(type) {
+		case *SequenceValue, *SequenceOfValue, *ObjectIdentifierValue:
+			return Sequence, true
+		}
+		return Sequence, false
+	case *ChoiceType:
+		_, ok := v.(*ChoiceValue)
+		return Choice, ok
+	}
+	return "", true
+}
+
+// objectIdentifierConforms reports an object identifier whose components
+// name values of the wrong type. The first may name an object identifier or
+// an integer, the others only integers.
+func (c *checker) objectIdentifierConforms(v *ObjectIdentifierValue) {
+	for i, component := range v.Components {
+		if nil != component.Value {
+			continue
+		}
+		reference := c.reference(component)
+		if nil == reference {
+			continue
+		}
+		a, ok := c.target(reference, component.Name).(*ValueAssignment)
+		if !ok {
+			continue
+		}
+		switch g := c.governor(a.Type).(type) {
+		case *IntegerType:
+			continue
+		case *BuiltinType:
+			if i == 0 && (g.Name == ObjectIdentifier || g.Name == RelativeOID) {
+				continue
+			}
+		case nil, *ReferencedType:
+			continue
+		}
+		if i == 0 {
+			c.errorf(component.Position, "%s is not an object identifier, its type is at %s", component.Name, a.Type.Pos())
+		} else {
+			c.errorf(component.Position, "%s is not an integer, its type is at %s", component.Name, a.Type.Pos())
+		}
+	}
+}
+
+// valueOf follows v through value references, into other modules when
+// they are linked.
+func (c *checker) valueOf(v Value) Value {
+	if nil != c.program {
+		return c.program.ResolveValue(v)
+	}
+	return c.checked.ResolveValue(v)
+}
+
+// constraints lists the constraints on t and on the types its references
+// lead to, each of which a value of t satisfies.
+func (c *checker) constraints(t Type) []*Constraint {
+	var all []*Constraint
+	for i := 0; nil != t && i <= len(c.module.Assignments); i++ {
+		all = append(all, t.Base().Constraints...)
+		ref, ok := t.(*ReferencedType)
+		if !ok {
+			break
+		}
+		reference := c.reference(ref)
+		if nil == reference || nil != reference.Parameter {
+			break
+		}
+		t = c.follow(ref, reference)
+	}
+	return all
+}
+
+// admits reports whether the integer n is in the set e describes, and
+// whether that could be told at all.
+func (c *checker) admits(e Element, n *big.Int) (admitted, known bool) {
+	switch e := e.(type) {
+	case *UnionElement:
+		known = true
+		for _, element := range e.Elements {
+			in, ok := c.admits(element, n)
+			if ok && in {
+				return true, true
+			}
+			known = known && ok
+		}
+		return false, known
+	case *IntersectionElement:
+		admitted, known = true, true
+		for _, element := range e.Elements {
+			in, ok := c.admits(element, n)
+			if ok && !in {
+				return false, true
+			}
+			known = known && ok
+		}
+		return admitted, known
+	case *ExclusionElement:
+		except, told := c.admits(e.Except, n)
+		if told && except {
+			return false, true
+		}
+		if nil == e.Element {
+			return true, told
+		}
+		in, ok := c.admits(e.Element, n)
+		return in, ok && (told || !in)
+	case *ValueElement:
+		if value, ok := c.valueOf(e.Value).(*IntegerValue); ok {
+			return value.Value.Cmp(n) == 0, true
+		}
+	case *RangeElement:
+		lower, ok := c.endpoint(e.Lower)
+		if !ok {
+			return false, false
+		}
+		upper, ok := c.endpoint(e.Upper)
+		if !ok {
+			return false, false
+		}
+		if nil != lower && (n.Cmp(lower) < 0 || e.Lower.Open && n.Cmp(lower) == 0) {
+			return false, true
+		}
+		if nil != upper && (n.Cmp(upper) > 0 || e.Upper.Open && n.Cmp(upper) == 0) {
+			return false, true
+		}
+		return true, true
+	case *TypeElement:
+		for _, constraint := range c.constraints(e.Type) {
+			if constraint.Extensible || nil == constraint.Root {
+				continue
+			}
+			if in, ok := c.admits(constraint.Root, n); !ok || !in {
+				return in, ok
+			}
+		}
+		_, integer := c.governor(e.Type).(*IntegerType)
+		return integer, integer
+	}
+	return false, false
+}
+
+// endpoint returns the number a range endpoint stands for, nil for MIN and
+// MAX.
+func (c *checker) endpoint(endpoint *RangeEndpoint) (*big.Int, bool) {
+	if nil == endpoint.Value {
+		return nil, true
+	}
+	value, ok := c.valueOf(endpoint.Value).(*IntegerValue)
+	if !ok {
+		return nil, false
+	}
+	return value.Value, true
+}
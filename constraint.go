@@ -0,0 +1,553 @@
+package asn1c_go
+
+import (
+	"math/big"
+	"sort"
+	"unicode/utf8"
+)
+
+// Range is the integers from Lower to Upper, both included. A nil bound
+// leaves that side of the range unbounded.
+type Range struct {
+	Lower *big.Int
+	Upper *big.Int
+}
+
+// RangeSet is a set of integers held as sorted ranges that neither overlap
+// nor touch. An empty RangeSet holds no integers.
+type RangeSet []Range
+
+func unbounded() RangeSet {
+	return RangeSet{{}}
+}
+
+// Unbounded reports whether s holds every integer.
+func (s RangeSet) Unbounded() bool {
+	return len(s) == 1 && nil == s[0].Lower && nil == s[0].Upper
+}
+
+// Contiguous reports whether s is a single range, such as PER encodes
+// with a lower and an upper bound.
+func (s RangeSet) Contiguous() bool {
+	return len(s) == 1
+}
+
+// Bounds returns the least and the greatest integers of s, nil on a side
+// s is unbounded on or when s is empty.
+func (s RangeSet) Bounds() (lower, upper *big.Int) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	return s[0].Lower, s[len(s)-1].Upper
+}
+
+// Contains reports whether n is in s.
+func (s RangeSet) Contains(n *big.Int) bool {
+	i := sort.Search(len(s), func(i int) bool {
+		return nil == s[i].Upper || s[i].Upper.Cmp(n) >= 0
+	})
+	return i < len(s) && (nil == s[i].Lower || s[i].Lower.Cmp(n) <= 0)
+}
+
+// Runes lists the characters whose codes s holds, in order. It returns nil
+// when s reaches below zero or beyond the last character.
+func (s RangeSet) Runes() []rune {
+	lower, upper := s.Bounds()
+	if len(s) == 0 || nil == lower || nil == upper || lower.Sign() < 0 || upper.Cmp(big.NewInt(utf8.MaxRune)) > 0 {
+		return nil
+	}
+	var runes []rune
+	for _, r := range s {
+		for c := r.Lower.Int64(); c <= r.Upper.Int64(); c++ {
+			runes = append(runes, rune(c))
+		}
+	}
+	return runes
+}
+
+func (s RangeSet) equal(other RangeSet) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for i := range s {
+		if !sameBound(s[i].Lower, other[i].Lower) || !sameBound(s[i].Upper, other[i].Upper) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameBound(a, b *big.Int) bool {
+	if nil == a || nil == b {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+// makeRangeSet sorts ranges and merges those that overlap or touch,
+// dropping empty ones.
+func makeRangeSet(ranges []Range) RangeSet {
+	var sorted []Range
+	for _, r := range ranges {
+		if nil == r.Lower || nil == r.Upper || r.Lower.Cmp(r.Upper) <= 0 {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Lower, sorted[j].Lower
+		return nil == a && nil != b || nil != a && nil != b && a.Cmp(b) < 0
+	})
+	s := RangeSet{}
+	for _, r := range sorted {
+		if len(s) == 0 {
+			s = append(s, r)
+			continue
+		}
+		last := &s[len(s)-1]
+		if nil == last.Upper {
+			break
+		}
+		if nil != r.Lower && r.Lower.Cmp(new(big.Int).Add(last.Upper, big.NewInt(1))) > 0 {
+			s = append(s, r)
+			continue
+		}
+		if nil == r.Upper || r.Upper.Cmp(last.Upper) > 0 {
+			last.Upper = r.Upper
+		}
+	}
+	return s
+}
+
+func (s RangeSet) union(other RangeSet) RangeSet {
+	return makeRangeSet(append(append([]Range(nil), s...), other...))
+}
+
+func (s RangeSet) intersect(other RangeSet) RangeSet {
+	var ranges []Range
+	for _, a := range s {
+		for _, b := range other {
+			r := a
+			if nil == r.Lower || nil != b.Lower && b.Lower.Cmp(r.Lower) > 0 {
+				r.Lower = b.Lower
+			}
+			if nil == r.Upper || nil != b.Upper && b.Upper.Cmp(r.Upper) < 0 {
+				r.Upper = b.Upper
+			}
+			ranges = append(ranges, r)
+		}
+	}
+	return makeRangeSet(ranges)
+}
+
+func (s RangeSet) complement() RangeSet {
+	var (
+		ranges []Range
+		lower  *big.Int
+	)
+	for _, r := range s {
+		if nil != r.Lower {
+			ranges = append(ranges, Range{Lower: lower, Upper: new(big.Int).Sub(r.Lower, big.NewInt(1))})
+		}
+		if nil == r.Upper {
+			return makeRangeSet(ranges)
+		}
+		lower = new(big.Int).Add(r.Upper, big.NewInt(1))
+	}
+	return makeRangeSet(append(ranges, Range{Lower: lower}))
+}
+
+func (s RangeSet) subtract(other RangeSet) RangeSet {
+	return s.intersect(other.complement())
+}
+
+// NormalizedConstraint is a constraint reduced to sets of integers: the
+// values it permits, the sizes it permits and the codes of the characters
+// of its permitted alphabet, each unbounded when the constraint leaves it
+// free. Extensible is set when the constraint is extensible and
+// SizeExtensible when a size constraint is.
+//
+// Parts that cannot be evaluated, such as user-defined constraints or
+// references into modules not linked, and combinations the sets cannot
+// hold, such as a union of a size and an alphabet, are widened so that the
+// sets hold a superset of what the constraint permits, and Exact is then
+// false. An extensible permitted alphabet, which X.691 does not let PER
+// see, is widened likewise.
+type NormalizedConstraint struct {
+	Values         RangeSet
+	Size           RangeSet
+	Alphabet       RangeSet
+	Extensible     bool
+	SizeExtensible bool
+	Exact          bool
+}
+
+func everything() *NormalizedConstraint {
+	return &NormalizedConstraint{Values: unbounded(), Size: unbounded(), Alphabet: unbounded(), Exact: true}
+}
+
+func (n *NormalizedConstraint) sets() []*RangeSet {
+	return []*RangeSet{&n.Values, &n.Size, &n.Alphabet}
+}
+
+// empty reports whether n permits nothing at all.
+func (n *NormalizedConstraint) empty() bool {
+	for _, s := range n.sets() {
+		if len(*s) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize reduces c, a constraint written in the module, to sets of
+// integers.
+func (m *CheckedModule) Normalize(c *Constraint) *NormalizedConstraint {
+	n := &normalizer{
+		resolve:  m.ResolveValue,
+		follow:   func(ref *ReferencedType) Assignment { return m.assignment(ref) },
+		visiting: map[Assignment]bool{},
+	}
+	return n.constraint(c, false)
+}
+
+// Normalize reduces c, a constraint written in one of the modules, to sets
+// of integers, following references through imports.
+func (p *Program) Normalize(c *Constraint) *NormalizedConstraint {
+	n := &normalizer{
+		resolve:  p.ResolveValue,
+		follow:   p.assignment,
+		visiting: map[Assignment]bool{},
+	}
+	return n.constraint(c, false)
+}
+
+// Effective normalizes the constraints on t and on the types its
+// references lead to, which the values of t satisfy together.
+func (m *CheckedModule) Effective(t Type) *NormalizedConstraint {
+	n := &normalizer{
+		resolve:  m.ResolveValue,
+		follow:   func(ref *ReferencedType) Assignment { return m.assignment(ref) },
+		visiting: map[Assignment]bool{},
+	}
+	return n.typ(t, false)
+}
+
+// Effective is that of CheckedModule, following references through
+// imports and into instances of parameterized types.
+func (p *Program) Effective(t Type) *NormalizedConstraint {
+	n := &normalizer{
+		resolve:  p.ResolveValue,
+		follow:   p.assignment,
+		visiting: map[Assignment]bool{},
+	}
+	return n.typ(t, false)
+}
+
+type normalizer struct {
+	resolve  func(Value) Value
+	follow   func(*ReferencedType) Assignment
+	visiting map[Assignment]bool
+}
+
+// constraint normalizes c. Within a permitted alphabet, chars is set and
+// the values are the codes of characters.
+func (n *normalizer) constraint(c *Constraint, chars bool) *NormalizedConstraint {
+	result := n.specs(c.ElementSetSpecs, chars)
+	result.Extensible = c.Extensible
+	return result
+}
+
+// specs normalizes the root of specs, the set PER encodes within bounds.
+func (n *normalizer) specs(specs ElementSetSpecs, chars bool) *NormalizedConstraint {
+	if nil == specs.Root {
+		return everything()
+	}
+	return n.element(specs.Root, chars)
+}
+
+func (n *normalizer) element(e Element, chars bool) *NormalizedConstraint {
+	switch e := e.(type) {
+	case *UnionElement:
+		var result *NormalizedConstraint
+		for _, element := range e.Elements {
+			next := n.element(element, chars)
+			if nil == result {
+				result = next
+			} else {
+				result = union(result, next)
+			}
+		}
+		return result
+	case *IntersectionElement:
+		result := everything()
+		for _, element := range e.Elements {
+			result = intersect(result, n.element(element, chars))
+		}
+		return result
+	case *ExclusionElement:
+		result := everything()
+		if nil != e.Element {
+			result = n.element(e.Element, chars)
+		}
+		return exclude(result, n.element(e.Except, chars))
+	case *ValueElement:
+		if values, ok := n.value(e.Value, chars); ok {
+			return only(values)
+		}
+	case *RangeElement:
+		lower, ok := n.endpoint(e.Lower, chars)
+		if !ok {
+			break
+		}
+		upper, ok := n.endpoint(e.Upper, chars)
+		if !ok {
+			break
+		}
+		if nil != lower && e.Lower.Open {
+			lower.Add(lower, big.NewInt(1))
+		}
+		if nil != upper && e.Upper.Open {
+			upper.Sub(upper, big.NewInt(1))
+		}
+		return only(makeRangeSet([]Range{{Lower: lower, Upper: upper}}))
+	case *SizeElement:
+		if chars {
+			break
+		}
+		inner := n.constraint(e.Constraint, false)
+		result := everything()
+		result.Size = inner.Values
+		result.SizeExtensible = inner.Extensible
+		result.Exact = inner.Exact && inner.Size.Unbounded() && inner.Alphabet.Unbounded()
+		return result
+	case *AlphabetElement:
+		if chars {
+			break
+		}
+		inner := n.constraint(e.Constraint, true)
+		if inner.Extensible {
+			break
+		}
+		result := everything()
+		result.Alphabet = inner.Values
+		result.Exact = inner.Exact
+		return result
+	case *TypeElement:
+		return n.typ(e.Type, chars)
+	}
+	result := everything()
+	result.Exact = false
+	return result
+}
+
+// typ normalizes the constraints on t and on the types its references
+// lead to, which the values of t satisfy together. It is extensible when
+// the constraint applied last is.
+func (n *normalizer) typ(t Type, chars bool) *NormalizedConstraint {
+	var (
+		result              = everything()
+		extensible, decided bool
+	)
+	for nil != t {
+		constraints := t.Base().Constraints
+		for _, c := range constraints {
+			result = intersect(result, n.specs(c.ElementSetSpecs, chars))
+		}
+		if !decided && len(constraints) != 0 {
+			extensible, decided = constraints[len(constraints)-1].Extensible, true
+		}
+		ref, ok := t.(*ReferencedType)
+		if !ok {
+			break
+		}
+		assignment := n.follow(ref)
+		if nil == assignment || n.visiting[assignment] {
+			result.Exact = false
+			break
+		}
+		n.visiting[assignment] = true
+		defer delete(n.visiting, assignment)
+		switch a := assignment.(type) {
+		case *TypeAssignment:
+			t = a.Type
+		case *ValueSetAssignment:
+			result = intersect(result, n.specs(a.Set.ElementSetSpecs, chars))
+			if !decided {
+				extensible, decided = a.Set.Extensible, true
+			}
+			t = a.Type
+		default:
+			result.Exact = false
+			t = nil
+		}
+	}
+	result.Extensible = extensible
+	return result
+}
+
+// value returns the integers v stands for: itself, or the codes of the
+// characters of a string within a permitted alphabet.
+func (n *normalizer) value(v Value, chars bool) (RangeSet, bool) {
+	switch v := n.resolve(v).(type) {
+	case *IntegerValue:
+		if !chars {
+			return RangeSet{{Lower: v.Value, Upper: v.Value}}, true
+		}
+	case *StringValue:
+		if chars {
+			var ranges []Range
+			for _, r := range v.Value {
+				ranges = append(ranges, Range{Lower: big.NewInt(int64(r)), Upper: big.NewInt(int64(r))})
+			}
+			return makeRangeSet(ranges), true
+		}
+	}
+	return nil, false
+}
+
+// endpoint returns a fresh copy of the integer a range endpoint stands
+// for, nil for MIN and MAX. Within a permitted alphabet it is the code of a
+// single character.
+func (n *normalizer) endpoint(endpoint *RangeEndpoint, chars bool) (*big.Int, bool) {
+	if nil == endpoint.Value {
+		return nil, true
+	}
+	switch v := n.resolve(endpoint.Value).(type) {
+	case *IntegerValue:
+		if !chars {
+			return new(big.Int).Set(v.Value), true
+		}
+	case *StringValue:
+		if chars && utf8.RuneCountInString(v.Value) == 1 {
+			r, _ := utf8.DecodeRuneInString(v.Value)
+			return big.NewInt(int64(r)), true
+		}
+	}
+	return nil, false
+}
+
+func only(values RangeSet) *NormalizedConstraint {
+	result := everything()
+	result.Values = values
+	return result
+}
+
+// union joins a and b set by set, which is exact when they differ in one
+// set at most.
+func union(a, b *NormalizedConstraint) *NormalizedConstraint {
+	switch {
+	case a.Exact && a.empty():
+		return b
+	case b.Exact && b.empty():
+		return a
+	}
+	var (
+		result    = &NormalizedConstraint{SizeExtensible: a.SizeExtensible || b.SizeExtensible}
+		differing = 0
+	)
+	x, y, sets := a.sets(), b.sets(), result.sets()
+	for i := range sets {
+		if !x[i].equal(*y[i]) {
+			differing++
+		}
+		*sets[i] = x[i].union(*y[i])
+	}
+	result.Exact = a.Exact && b.Exact && differing <= 1
+	return result
+}
+
+func intersect(a, b *NormalizedConstraint) *NormalizedConstraint {
+	result := &NormalizedConstraint{
+		SizeExtensible: a.SizeExtensible || b.SizeExtensible,
+		Exact:          a.Exact && b.Exact,
+	}
+	x, y, sets := a.sets(), b.sets(), result.sets()
+	for i := range sets {
+		*sets[i] = x[i].intersect(*y[i])
+	}
+	return result
+}
+
+// exclude takes b out of a, which the sets can only hold when b is known
+// exactly and constrains a single set.
+func exclude(a, b *NormalizedConstraint) *NormalizedConstraint {
+	result := *a
+	if b.Exact && b.empty() {
+		return &result
+	}
+	var constrained []int
+	for i, s := range b.sets() {
+		if !s.Unbounded() {
+			constrained = append(constrained, i)
+		}
+	}
+	switch {
+	case !b.Exact || len(constrained) > 1:
+		result.Exact = false
+	case len(constrained) == 0:
+		result.Values = RangeSet{}
+	default:
+		set := result.sets()[constrained[0]]
+		*set = set.subtract(*b.sets()[constrained[0]])
+	}
+	return &result
+}
+
+// Constraint writes n back as a constraint, which normalizes to the same
+// sets.
+func (n *NormalizedConstraint) Constraint() *Constraint {
+	var elements []Element
+	if !n.Values.Unbounded() {
+		elements = append(elements, rangeSetElement(n.Values, false))
+	}
+	if !n.Size.Unbounded() {
+		elements = append(elements, &SizeElement{Constraint: &Constraint{
+			ElementSetSpecs: ElementSetSpecs{Root: rangeSetElement(n.Size, false), Extensible: n.SizeExtensible},
+		}})
+	}
+	if !n.Alphabet.Unbounded() {
+		elements = append(elements, &AlphabetElement{Constraint: &Constraint{
+			ElementSetSpecs: ElementSetSpecs{Root: rangeSetElement(n.Alphabet, true)},
+		}})
+	}
+	c := &Constraint{ElementSetSpecs: ElementSetSpecs{Extensible: n.Extensible}}
+	switch len(elements) {
+	case 0:
+		c.Root = rangeSetElement(unbounded(), false)
+	case 1:
+		c.Root = elements[0]
+	default:
+		c.Root = &IntersectionElement{Elements: elements}
+	}
+	return c
+}
+
+// rangeSetElement writes s as a union of single values and ranges, of
+// characters when chars is set.
+func rangeSetElement(s RangeSet, chars bool) Element {
+	bound := func(n *big.Int, limit string) *RangeEndpoint {
+		switch {
+		case nil == n:
+			return &RangeEndpoint{Limit: limit}
+		case chars:
+			return &RangeEndpoint{Value: &StringValue{Value: string(rune(n.Int64()))}}
+		}
+		return &RangeEndpoint{Value: &IntegerValue{Value: n}}
+	}
+	if len(s) == 0 {
+		// An empty range, as no value notation stands for nothing.
+		return &RangeElement{Lower: bound(big.NewInt(1), Min), Upper: bound(big.NewInt(0), Max)}
+	}
+	var elements []Element
+	for _, r := range s {
+		if nil != r.Lower && nil != r.Upper && r.Lower.Cmp(r.Upper) == 0 {
+			elements = append(elements, &ValueElement{Value: bound(r.Lower, "").Value})
+			continue
+		}
+		elements = append(elements, &RangeElement{Lower: bound(r.Lower, Min), Upper: bound(r.Upper, Max)})
+	}
+	if len(elements) == 1 {
+		return elements[0]
+	}
+	return &UnionElement{Elements: elements}
+}
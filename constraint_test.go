@@ -0,0 +1,175 @@
+package asn1c_go
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// point is a value, a size and a character code, what a constraint on a
+// string or an integer permits or not.
+type point struct{ value, size, char int64 }
+
+func integer(n int64) Value {
+	return &IntegerValue{Value: big.NewInt(n)}
+}
+
+// randomElement returns a random constraint over small integers, of sizes
+// and alphabets too when mixed.
+func randomElement(r *rand.Rand, depth int, mixed bool) Element {
+	choice := r.Intn(8)
+	if depth > 3 {
+		choice = r.Intn(2)
+	}
+	elements := func() []Element {
+		var elements []Element
+		for i := 0; i < 1+r.Intn(3); i++ {
+			elements = append(elements, randomElement(r, depth+1, mixed))
+		}
+		return elements
+	}
+	inner := func() *Constraint {
+		return &Constraint{ElementSetSpecs: ElementSetSpecs{Root: randomElement(r, depth+1, false)}}
+	}
+	switch {
+	case choice == 1:
+		endpoint := func(limit string) *RangeEndpoint {
+			if r.Intn(5) == 0 {
+				return &RangeEndpoint{Limit: limit, Open: r.Intn(2) == 0}
+			}
+			return &RangeEndpoint{Value: integer(int64(r.Intn(17) - 8)), Open: r.Intn(3) == 0}
+		}
+		return &RangeElement{Lower: endpoint(Min), Upper: endpoint(Max)}
+	case choice == 2, choice == 3:
+		return &UnionElement{Elements: elements()}
+	case choice == 4:
+		return &IntersectionElement{Elements: elements()}
+	case choice == 5:
+		e := &ExclusionElement{Except: randomElement(r, depth+1, mixed)}
+		if r.Intn(3) != 0 {
+			e.Element = randomElement(r, depth+1, mixed)
+		}
+		return e
+	case choice == 6 && mixed:
+		return &SizeElement{Constraint: inner()}
+	case choice == 7 && mixed:
+		return &AlphabetElement{Constraint: inner()}
+	}
+	return &ValueElement{Value: integer(int64(r.Intn(17) - 8))}
+}
+
+// permits reports whether e permits p, straight from its definition.
+func permits(e Element, p point) bool {
+	value := func(v Value) int64 { return v.(*IntegerValue).Value.Int64() }
+	switch e := e.(type) {
+	case *ValueElement:
+		return p.value == value(e.Value)
+	case *RangeElement:
+		if nil != e.Lower.Value {
+			lower := value(e.Lower.Value)
+			if p.value < lower || (e.Lower.Open && p.value == lower) {
+				return false
+			}
+		}
+		if nil != e.Upper.Value {
+			upper := value(e.Upper.Value)
+			if p.value > upper || (e.Upper.Open && p.value == upper) {
+				return false
+			}
+		}
+		return true
+	case *UnionElement:
+		for _, element := range e.Elements {
+			if permits(element, p) {
+				return true
+			}
+		}
+		return false
+	case *IntersectionElement:
+		for _, element := range e.Elements {
+			if !permits(element, p) {
+				return false
+			}
+		}
+		return true
+	case *ExclusionElement:
+		return (nil == e.Element || permits(e.Element, p)) && !permits(e.Except, p)
+	case *SizeElement:
+		return permits(e.Constraint.Root, point{value: p.size})
+	case *AlphabetElement:
+		return permits(e.Constraint.Root, point{value: p.char})
+	}
+	panic("unexpected element")
+}
+
+func TestNormalizeProperties(t *testing.T) {
+	var (
+		module = &CheckedModule{Module: &ModuleDefinition{}, References: map[Node]*Reference{}}
+		r      = rand.New(rand.NewSource(1))
+	)
+	for i := 0; i < 5000; i++ {
+		mixed := i%2 == 1
+		e := randomElement(r, 0, mixed)
+		n := module.Normalize(&Constraint{ElementSetSpecs: ElementSetSpecs{Root: e}})
+		if !mixed && !n.Exact {
+			t.Fatalf("%d: a constraint on values alone is not exact", i)
+		}
+		for value := int64(-12); value <= 12; value++ {
+			for size := int64(-10); size <= 10; size += 2 {
+				for char := int64(-10); char <= 10; char += 3 {
+					if !mixed && (size != 0 || char != 0) {
+						continue
+					}
+					var (
+						p    = point{value, size, char}
+						want = permits(e, p)
+						got  = n.Values.Contains(big.NewInt(value)) && n.Size.Contains(big.NewInt(size)) && n.Alphabet.Contains(big.NewInt(char))
+					)
+					if (want && !got) || (n.Exact && want != got) {
+						t.Fatalf("%d: %+v permitted %v, normalized %v, exact %v", i, p, want, got, n.Exact)
+					}
+				}
+			}
+		}
+		again := module.Normalize(n.Constraint())
+		if !again.Values.equal(n.Values) || !again.Size.equal(n.Size) || !again.Alphabet.equal(n.Alphabet) || !again.Exact {
+			t.Fatalf("%d: normalized %+v, then %+v", i, n, again)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	set, err := ParseBytes("m.asn", []byte(`M DEFINITIONS ::= BEGIN
+Small ::= INTEGER (0..10)
+T ::= INTEGER (INCLUDES Small EXCEPT 5 | 20, ...)
+S ::= IA5String (SIZE (1..4 | 8, ...) ^ FROM ("A".."Z" | "abc"))
+U ::= IA5String (SIZE (1..4) | FROM ("A"))
+V ::= OCTET STRING (CONSTRAINED BY {})
+END`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	module, err := Check(set.Modules[0])
+	if nil != err {
+		t.Fatal(err)
+	}
+	normalize := func(name string) *NormalizedConstraint {
+		return module.Normalize(module.Module.Lookup(name).(*TypeAssignment).Type.Base().Constraints[0])
+	}
+	if n := normalize("T"); len(n.Values) != 3 || !n.Extensible || !n.Exact {
+		t.Errorf("T: got %+v", n)
+	}
+	n := normalize("S")
+	if lower, upper := n.Size.Bounds(); n.Size.Contiguous() || lower.Int64() != 1 || upper.Int64() != 8 || !n.SizeExtensible {
+		t.Errorf("S: got sizes %v", n.Size)
+	}
+	if got := string(n.Alphabet.Runes()); got != "ABCDEFGHIJKLMNOPQRSTUVWXYZabc" {
+		t.Errorf("S: got alphabet %q", got)
+	}
+	if n := normalize("U"); n.Exact {
+		t.Errorf("U: a union of a size and an alphabet is exact")
+	}
+	if n := normalize("V"); n.Exact || !n.Values.Unbounded() {
+		t.Errorf("V: got %+v", n)
+	}
+}
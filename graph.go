@@ -0,0 +1,107 @@
+package asn1c_go
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DependencyGraph is the graph of the modules of a program, with an edge
+// from each module to each module it imports from. Order lists the modules
+// each after those it imports from, but for modules importing from each
+// other, which ASN.1 allows and Cycles groups.
+type DependencyGraph struct {
+	Modules []*CheckedModule
+	Imports map[*CheckedModule][]*CheckedModule
+	Order   []*CheckedModule
+	Cycles  [][]*CheckedModule
+}
+
+// DependencyGraph builds the graph of the imports between the modules.
+// Imports from modules that are not part of the program are left out.
+func (p *Program) DependencyGraph() *DependencyGraph {
+	g := &DependencyGraph{
+		Modules: p.Modules,
+		Imports: map[*CheckedModule][]*CheckedModule{},
+	}
+	index := map[*CheckedModule]int{}
+	for i, module := range p.Modules {
+		index[module] = i
+	}
+	for _, module := range p.Modules {
+		seen := map[*CheckedModule]bool{}
+		for _, imported := range module.Module.Imports {
+			from, _ := p.from(imported)
+			if nil == from || seen[from] {
+				continue
+			}
+			seen[from] = true
+			g.Imports[module] = append(g.Imports[module], from)
+		}
+	}
+	sccs := stronglyConnected(len(p.Modules), func(i int) []int {
+		var next []int
+		for _, from := range g.Imports[p.Modules[i]] {
+			next = append(next, index[from])
+		}
+		return next
+	})
+	for _, scc := range sccs {
+		sort.Ints(scc)
+		var modules []*CheckedModule
+		for _, i := range scc {
+			modules = append(modules, p.Modules[i])
+		}
+		g.Order = append(g.Order, modules...)
+		if len(modules) > 1 || g.imports(modules[0], modules[0]) {
+			g.Cycles = append(g.Cycles, modules)
+		}
+	}
+	return g
+}
+
+func (g *DependencyGraph) imports(module, from *CheckedModule) bool {
+	for _, m := range g.Imports[module] {
+		if m == from {
+			return true
+		}
+	}
+	return false
+}
+
+// cyclic reports whether the import of from by module closes a cycle.
+func (g *DependencyGraph) cyclic(module, from *CheckedModule) bool {
+	for _, cycle := range g.Cycles {
+		var a, b bool
+		for _, m := range cycle {
+			a = a || m == module
+			b = b || m == from
+		}
+		if a && b {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteDOT writes the graph in the DOT language of Graphviz, drawing the
+// imports that close a cycle in red.
+func (g *DependencyGraph) WriteDOT(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph modules {")
+	for _, module := range g.Modules {
+		fmt.Fprintf(b, "\t%q;\n", module.Module.Name)
+	}
+	for _, module := range g.Modules {
+		for _, from := range g.Imports[module] {
+			fmt.Fprintf(b, "\t%q -> %q", module.Module.Name, from.Module.Name)
+			if g.cyclic(module, from) {
+				fmt.Fprint(b, " [color=red]")
+			}
+			fmt.Fprintln(b, ";")
+		}
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
@@ -0,0 +1,432 @@
+package asn1c_go
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// instanceDepth bounds the nesting of instances, which a parameterized type
+// passing ever larger actual parameters on to itself would never end.
+const instanceDepth = 64
+
+// binding is what the dummy references of a parameterized type stand for in
+// one of its instances, with keys telling the actual parameters apart.
+type binding struct {
+	references map[*Parameter]*Reference
+	keys       map[*Parameter]string
+}
+
+// Instantiate returns the type ref, a reference to a parameterized type,
+// stands for: a copy of the parameterized type whose dummy references stand
+// for the actual parameters of ref. The copy is checked and resolves like
+// the types of the modules. Reference maps a dummy reference in it to an
+// assignment of the actual parameter, and ResolveType follows ref, and the
+// references to parameterized types in the copy, to their instances, so that
+// nested parameterized types are instantiated too. Instances are cached, the
+// references written alike in a module sharing one.
+func (p *Program) Instantiate(ref *ReferencedType) (Type, error) {
+	if err := p.instantiate(ref, p.holder(ref), nil, nil, 0); nil != err {
+		return nil, err
+	}
+	return p.instances[ref].Type, nil
+}
+
+// instantiate instantiates ref, written in module where the dummies of
+// parameters are bound as given.
+func (p *Program) instantiate(ref *ReferencedType, module *CheckedModule, parameters []*Parameter, bound *binding, depth int) error {
+	if nil != p.instances[ref] {
+		return nil
+	}
+	definition := p.definition(ref)
+	if nil == module || nil == definition {
+		return &Error{Position: ref.Position, Message: "undefined reference " + ref.Name}
+	}
+	a, ok := definition.Assignment.(*TypeAssignment)
+	switch {
+	case !ok || len(a.Parameters) == 0:
+		return &Error{Position: ref.Position, Message: ref.Name + " is not a parameterized type"}
+	case len(a.Parameters) != len(ref.Parameters):
+		return &Error{Position: ref.Position, Message: fmt.Sprintf("%s takes %d parameters, not %d", ref.Name, len(a.Parameters), len(ref.Parameters))}
+	case depth > instanceDepth:
+		return &Error{Position: ref.Position, Message: "instances of " + ref.Name + " nest too deeply"}
+	}
+	actuals, err := p.actualParameters(ref, a, definition.Module, module, parameters, bound)
+	if nil != err {
+		return err
+	}
+	for _, actual := range actuals {
+		for _, nested := range parameterized(actual) {
+			if err := p.instantiate(nested, module, parameters, bound, depth+1); nil != err {
+				return err
+			}
+		}
+	}
+	b := &binding{references: map[*Parameter]*Reference{}, keys: map[*Parameter]string{}}
+	keys := []string{definition.Module.Module.Name + "." + a.Name}
+	for i, dummy := range a.Parameters {
+		b.references[dummy] = &Reference{Assignment: bind(dummy, actuals[i])}
+		b.keys[dummy] = actualKey(ref.Parameters[i].Tokens, module.Module, parameters, bound)
+		keys = append(keys, b.keys[dummy])
+	}
+	key := strings.Join(keys, "\x00")
+	if instance := p.keyed[key]; nil != instance {
+		p.instances[ref] = instance
+		return nil
+	}
+	if nil == p.instances {
+		p.instances = map[*ReferencedType]*TypeAssignment{}
+		p.keyed = map[string]*TypeAssignment{}
+	}
+	// The instance is cached before its body is copied, so that a type
+	// passing its own parameters on to itself finds it.
+	instance := &TypeAssignment{Position: a.Position, Name: a.Name, Doc: a.Doc, Comment: a.Comment}
+	p.instances[ref] = instance
+	p.keyed[key] = instance
+	copies := copier{}
+	instance.Type = copies.copy(reflect.ValueOf(a.Type)).Interface().(Type)
+	references := definition.Module.References
+	for original, copied := range copies {
+		n, ok := original.(Node)
+		if !ok || nil == references[n] {
+			continue
+		}
+		reference := references[n]
+		if nil != reference.Parameter && nil != b.references[reference.Parameter] {
+			reference = b.references[reference.Parameter]
+		}
+		references[copied.Interface().(Node)] = reference
+	}
+	for _, nested := range parameterized(instance.Type) {
+		if err := p.instantiate(nested, definition.Module, a.Parameters, b, depth+1); nil != err {
+			delete(p.instances, ref)
+			delete(p.keyed, key)
+			return err
+		}
+	}
+	// The copy is checked like the types of the modules, the dummies now
+	// standing for the actual parameters.
+	c := &checker{module: definition.Module.Module, checked: definition.Module, program: p, parameters: a.Parameters, bound: b.references}
+	c.checkType(instance.Type)
+	c.applyTags()
+	c.values = true
+	c.checkType(instance.Type)
+	c.orderComponents()
+	c.checkBounds()
+	c.checkNumbers()
+	c.checkConformance()
+	return c.errors.Err()
+}
+
+// holder returns the module whose references include n.
+func (p *Program) holder(n Node) *CheckedModule {
+	for _, module := range p.Modules {
+		if nil != module.References[n] {
+			return module
+		}
+	}
+	return nil
+}
+
+// definition returns the assignment ref names together with the module
+// holding it, following imports, or nil when it names none.
+func (p *Program) definition(ref *ReferencedType) *Definition {
+	module := p.holder(ref)
+	if nil == module {
+		return nil
+	}
+	reference := module.References[ref]
+	switch {
+	case nil != reference.Import:
+		return p.Imported(reference.Import, ref.Name)
+	case nil == reference.Assignment:
+		return nil
+	}
+	return &Definition{Module: module, Assignment: reference.Assignment}
+}
+
+// actualParameters reads the actual parameters of ref, written in module,
+// as what the dummies of a take, and resolves their references there.
+func (p *Program) actualParameters(ref *ReferencedType, a *TypeAssignment, defining, module *CheckedModule, parameters []*Parameter, bound *binding) ([]Node, error) {
+	scope := p.parser(module.Module)
+	actuals := make([]Node, 0, len(a.Parameters))
+	for i, dummy := range a.Parameters {
+		sub := scope.fork(ref.Parameters[i].Tokens)
+		actual, err := sub.parseActualParameter(dummy)
+		if nil != err {
+			return nil, err
+		}
+		if tok := sub.peek(0); tok.Kind != TokenEOF {
+			return nil, sub.errorf(tok.Position, "unexpected %s in actual parameter", tok)
+		}
+		actuals = append(actuals, actual)
+	}
+	scope.defineImportedObjects(p.definitions())
+	if err := scope.errors.Err(); nil != err {
+		return nil, err
+	}
+	c := &checker{module: module.Module, checked: module, program: p, parameters: parameters}
+	if nil != bound {
+		c.bound = bound.references
+	}
+	for _, values := range []bool{false, true} {
+		c.values = values
+		for i, dummy := range a.Parameters {
+			switch actual := actuals[i].(type) {
+			case *ValueSet:
+				c.checkElements(actual.ElementSetSpecs, dummy.Governor)
+			case *InformationObject:
+				c.checkObject(actual, lookupObjectClass(p.definitions(), defining.Module, dummy.Class))
+			case *ObjectSet:
+				c.checkObjectSet(actual, lookupObjectClass(p.definitions(), defining.Module, dummy.Class))
+			case Value:
+				c.checkValue(actual, dummy.Governor)
+			case Type:
+				c.checkType(actual)
+			}
+		}
+	}
+	c.applyTags()
+	c.orderComponents()
+	return actuals, c.errors.Err()
+}
+
+// parser returns a parser in module knowing, like the one that read it,
+// which of the names it defines and imports are object classes.
+func (p *Program) parser(module *ModuleDefinition) *parser {
+	q := newParser(nil)
+	q.module = module
+	for _, assignment := range module.Assignments {
+		if _, ok := assignment.(*ObjectClassAssignment); ok {
+			q.classes[assignment.Reference()] = true
+		} else {
+			q.defined[assignment.Reference()] = true
+		}
+	}
+	for _, imported := range module.Imports {
+		for _, symbol := range imported.Symbols {
+			if nil != lookupObjectClass(p.definitions(), module, symbol.Name) {
+				q.classes[symbol.Name] = true
+			} else {
+				q.defined[symbol.Name] = true
+			}
+		}
+	}
+	return q
+}
+
+// parseActualParameter reads an actual parameter for dummy: an object set
+// or object for a dummy of a class, a value set or value for one with a
+// governor, and a type otherwise.
+func (p *parser) parseActualParameter(dummy *Parameter) (Node, error) {
+	set := unicode.IsUpper([]rune(dummy.Name)[0])
+	switch {
+	case len(dummy.Class) != 0 && set:
+		return p.parseObjectSet(dummy.Class)
+	case len(dummy.Class) != 0:
+		return p.parseObject(dummy.Class)
+	case nil != dummy.Governor && set:
+		return p.parseValueSet()
+	case nil != dummy.Governor:
+		return p.parseValueOf(dummy.Governor)
+	}
+	return p.parseType()
+}
+
+// bind makes an assignment of actual to dummy, for the dummy references of
+// an instance to stand for.
+func bind(dummy *Parameter, actual Node) Assignment {
+	switch actual := actual.(type) {
+	case *ValueSet:
+		return &ValueSetAssignment{Position: dummy.Position, Name: dummy.Name, Type: dummy.Governor, Set: actual}
+	case *InformationObject:
+		return &ObjectAssignment{Position: dummy.Position, Name: dummy.Name, Class: dummy.Class, Object: actual}
+	case *ObjectSet:
+		return &ObjectSetAssignment{Position: dummy.Position, Name: dummy.Name, Class: dummy.Class, Set: actual}
+	case Value:
+		return &ValueAssignment{Position: dummy.Position, Name: dummy.Name, Type: dummy.Governor, Value: actual}
+	}
+	return &TypeAssignment{Position: dummy.Position, Name: dummy.Name, Type: actual.(Type)}
+}
+
+// actualKey identifies an actual parameter written in module by its tokens,
+// the dummies among them replaced by the keys of what they are bound to. A
+// dummy passed on alone keeps its key, so that an instance passing its own
+// parameters on finds itself.
+func actualKey(tokens []Token, module *ModuleDefinition, parameters []*Parameter, bound *binding) string {
+	dummy := func(i int) *Parameter {
+		if nil == bound || (i > 0 && tokens[i-1].Text == ".") {
+			return nil
+		}
+		for _, parameter := range parameters {
+			if parameter.Name == tokens[i].Text {
+				return parameter
+			}
+		}
+		return nil
+	}
+	if len(tokens) == 1 && nil != dummy(0) {
+		return bound.keys[dummy(0)]
+	}
+	texts := make([]string, len(tokens))
+	for i, tok := range tokens {
+		texts[i] = tok.Text
+		if parameter := dummy(i); nil != parameter {
+			texts[i] = "(" + bound.keys[parameter] + ")"
+		}
+	}
+	return strings.Join(texts, " ") + "@" + module.Name
+}
+
+// parameterized lists the references to parameterized types within n, in
+// the order they are written.
+func parameterized(n Node) []*ReferencedType {
+	var refs []*ReferencedType
+	eachNode(n, func(n Node) {
+		if ref, ok := n.(*ReferencedType); ok && len(ref.Parameters) != 0 {
+			refs = append(refs, ref)
+		}
+	})
+	return refs
+}
+
+// structured lists the SEQUENCE, SET and CHOICE types within t.
+func structured(t Type) []Type {
+	var types []Type
+	eachNode(t, func(n Node) {
+		switch n := n.(type) {
+		case *SequenceType, *SetType, *ChoiceType:
+			types = append(types, n.(Type))
+		}
+	})
+	return types
+}
+
+// syntaxPackage is the package of the syntax tree, whose nodes are copied
+// and walked; values of other packages, such as numbers, are left alone.
+var syntaxPackage = reflect.TypeOf(Position{}).PkgPath()
+
+func syntaxPointer(v reflect.Value) bool {
+	return v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct && v.Elem().Type().PkgPath() == syntaxPackage
+}
+
+// eachNode calls f with n and each node beneath it, once each.
+func eachNode(n Node, f func(Node)) {
+	seen := map[interface{}]bool{}
+	var walk func(reflect.Value)
+	walk = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Ptr:
+			if !syntaxPointer(v) || seen[v.Interface()] {
+				return
+			}
+			seen[v.Interface()] = true
+			if n, ok := v.Interface().(Node); ok {
+				f(n)
+			}
+			walk(v.Elem())
+		case reflect.Interface:
+			if !v.IsNil() {
+				walk(v.Elem())
+			}
+		case reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				walk(v.Index(i))
+			}
+		case reflect.Struct:
+			if v.Type().PkgPath() != syntaxPackage {
+				return
+			}
+			for i := 0; i < v.NumField(); i++ {
+				if len(v.Type().Field(i).PkgPath) == 0 {
+					walk(v.Field(i))
+				}
+			}
+		}
+	}
+	walk(reflect.ValueOf(n))
+}
+
+// copier makes deep copies of syntax trees, mapping each node copied to its
+// copy. A node met twice is copied once.
+type copier map[interface{}]reflect.Value
+
+func (c copier) copy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !syntaxPointer(v) {
+			return v
+		}
+		if copied, ok := c[v.Interface()]; ok {
+			return copied
+		}
+		copied := reflect.New(v.Elem().Type())
+		c[v.Interface()] = copied
+		copied.Elem().Set(c.copy(v.Elem()))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(c.copy(v.Elem()))
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(c.copy(v.Index(i)))
+		}
+		return copied
+	case reflect.Struct:
+		if v.Type().PkgPath() != syntaxPackage {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if field := copied.Field(i); field.CanSet() {
+				field.Set(c.copy(v.Field(i)))
+			}
+		}
+		return copied
+	}
+	return v
+}
+
+// instantiate instantiates the references to parameterized types written
+// outside parameterized assignments, returning the errors of those that
+// cannot be and of the instances. References whose parameterized type is not
+// linked are left alone.
+func (l *linker) instantiate() ErrorList {
+	var (
+		p      = l.program
+		errors ErrorList
+	)
+	for _, module := range p.Modules {
+		for _, assignment := range module.Module.Assignments {
+			if a, ok := assignment.(*TypeAssignment); ok && len(a.Parameters) != 0 {
+				continue
+			}
+			for _, ref := range parameterized(assignment) {
+				if nil == p.definition(ref) {
+					continue
+				}
+				_, err := p.Instantiate(ref)
+				switch err := err.(type) {
+				case nil:
+				case ErrorList:
+					errors = append(errors, err...)
+				case *Error:
+					errors = append(errors, err)
+				default:
+					errors = append(errors, &Error{Position: ref.Position, Message: err.Error()})
+				}
+			}
+		}
+	}
+	return errors
+}
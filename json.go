@@ -0,0 +1,271 @@
+package asn1c_go
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+)
+
+// JSONVersion is the version of the JSON rendering of modules, raised
+// whenever a change to the AST changes the rendering.
+const JSONVersion = 4
+
+// jsonKinds are the node types that stand behind the Type, Value, Element
+// and Assignment interfaces, by the kind they are rendered with.
+var jsonKinds = map[string]reflect.Type{
+	"typeAssignment":        reflect.TypeOf((*TypeAssignment)(nil)),
+	"valueAssignment":       reflect.TypeOf((*ValueAssignment)(nil)),
+	"valueSetAssignment":    reflect.TypeOf((*ValueSetAssignment)(nil)),
+	"objectClassAssignment": reflect.TypeOf((*ObjectClassAssignment)(nil)),
+	"objectAssignment":      reflect.TypeOf((*ObjectAssignment)(nil)),
+	"objectSetAssignment":   reflect.TypeOf((*ObjectSetAssignment)(nil)),
+	"builtinType":           reflect.TypeOf((*BuiltinType)(nil)),
+	"integerType":           reflect.TypeOf((*IntegerType)(nil)),
+	"enumeratedType":        reflect.TypeOf((*EnumeratedType)(nil)),
+	"bitStringType":         reflect.TypeOf((*BitStringType)(nil)),
+	"sequenceType":          reflect.TypeOf((*SequenceType)(nil)),
+	"setType":               reflect.TypeOf((*SetType)(nil)),
+	"choiceType":            reflect.TypeOf((*ChoiceType)(nil)),
+	"sequenceOfType":        reflect.TypeOf((*SequenceOfType)(nil)),
+	"setOfType":             reflect.TypeOf((*SetOfType)(nil)),
+	"anyType":               reflect.TypeOf((*AnyType)(nil)),
+	"selectionType":         reflect.TypeOf((*SelectionType)(nil)),
+	"referencedType":        reflect.TypeOf((*ReferencedType)(nil)),
+	"objectClassFieldType":  reflect.TypeOf((*ObjectClassFieldType)(nil)),
+	"integerValue":          reflect.TypeOf((*IntegerValue)(nil)),
+	"stringValue":           reflect.TypeOf((*StringValue)(nil)),
+	"booleanValue":          reflect.TypeOf((*BooleanValue)(nil)),
+	"nullValue":             reflect.TypeOf((*NullValue)(nil)),
+	"realValue":             reflect.TypeOf((*RealValue)(nil)),
+	"bitStringValue":        reflect.TypeOf((*BitStringValue)(nil)),
+	"octetStringValue":      reflect.TypeOf((*OctetStringValue)(nil)),
+	"referencedValue":       reflect.TypeOf((*ReferencedValue)(nil)),
+	"objectIdentifierValue": reflect.TypeOf((*ObjectIdentifierValue)(nil)),
+	"sequenceValue":         reflect.TypeOf((*SequenceValue)(nil)),
+	"sequenceOfValue":       reflect.TypeOf((*SequenceOfValue)(nil)),
+	"choiceValue":           reflect.TypeOf((*ChoiceValue)(nil)),
+	"unionElement":          reflect.TypeOf((*UnionElement)(nil)),
+	"intersectionElement":   reflect.TypeOf((*IntersectionElement)(nil)),
+	"exclusionElement":      reflect.TypeOf((*ExclusionElement)(nil)),
+	"valueElement":          reflect.TypeOf((*ValueElement)(nil)),
+	"rangeElement":          reflect.TypeOf((*RangeElement)(nil)),
+	"sizeElement":           reflect.TypeOf((*SizeElement)(nil)),
+	"alphabetElement":       reflect.TypeOf((*AlphabetElement)(nil)),
+	"typeElement":           reflect.TypeOf((*TypeElement)(nil)),
+	"innerTypeElement":      reflect.TypeOf((*InnerTypeElement)(nil)),
+	"patternElement":        reflect.TypeOf((*PatternElement)(nil)),
+	"contentsElement":       reflect.TypeOf((*ContentsElement)(nil)),
+	"tableConstraint":       reflect.TypeOf((*TableConstraint)(nil)),
+	"objectElement":         reflect.TypeOf((*ObjectElement)(nil)),
+	"objectSetElement":      reflect.TypeOf((*ObjectSetElement)(nil)),
+	"userDefinedConstraint": reflect.TypeOf((*UserDefinedConstraint)(nil)),
+}
+
+// jsonKindNames are the kinds of jsonKinds by node type.
+var jsonKindNames = map[reflect.Type]string{}
+
+func init() {
+	for kind, t := range jsonKinds {
+		jsonKindNames[t] = kind
+	}
+}
+
+var bigIntType = reflect.TypeOf((*big.Int)(nil))
+
+// EncodeJSON writes module to w as a JSON object holding "version", set to
+// JSONVersion, and "module". Nodes are rendered as objects keyed by the json
+// tags of their fields, those tagged "-", which Check derives, left out, as
+// are fields with zero values. An embedded struct is rendered as an object
+// of its own. A node held by an interface, such as a Type or a Value, has
+// its kind from jsonKinds in "kind". Integers of arbitrary size are rendered
+// as decimal strings, bytes as hexadecimal strings, and positions in full.
+// Keys are sorted, so equal modules render equally.
+func EncodeJSON(w io.Writer, module *ModuleDefinition) error {
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"version": JSONVersion,
+		"module":  toJSON(reflect.ValueOf(module)),
+	}, "", "\t")
+	if nil != err {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// jsonKey returns the key field is rendered with, or "" when it is not
+// rendered.
+func jsonKey(field reflect.StructField) string {
+	key := field.Tag.Get("json")
+	if len(field.PkgPath) != 0 || key == "-" {
+		return ""
+	}
+	return key
+}
+
+func toJSON(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type() == bigIntType {
+			return v.Interface().(*big.Int).String()
+		}
+		return toJSON(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		object := toJSON(v.Elem()).(map[string]interface{})
+		object["kind"] = jsonKindNames[v.Elem().Type()]
+		return object
+	case reflect.Struct:
+		object := map[string]interface{}{}
+		toJSONFields(v, object)
+		return object
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return hex.EncodeToString(v.Bytes())
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = toJSON(v.Index(i))
+		}
+		return list
+	}
+	return v.Interface()
+}
+
+func toJSONFields(v reflect.Value, object map[string]interface{}) {
+	for i := 0; i < v.NumField(); i++ {
+		if key := jsonKey(v.Type().Field(i)); len(key) != 0 && !v.Field(i).IsZero() {
+			object[key] = toJSON(v.Field(i))
+		}
+	}
+}
+
+// DecodeJSON reads a module written by EncodeJSON.
+func DecodeJSON(r io.Reader) (*ModuleDefinition, error) {
+	var document struct {
+		Version json.Number `json:"version"`
+		Module  interface{} `json:"module"`
+	}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); nil != err {
+		return nil, err
+	}
+	if document.Version.String() != fmt.Sprint(JSONVersion) {
+		return nil, fmt.Errorf("unsupported JSON version %s", document.Version)
+	}
+	module := new(ModuleDefinition)
+	if err := fromJSON(document.Module, reflect.ValueOf(module).Elem()); nil != err {
+		return nil, err
+	}
+	return module, nil
+}
+
+func fromJSON(data interface{}, v reflect.Value) error {
+	if nil == data {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.Type() == bigIntType {
+			text, _ := data.(string)
+			n, ok := new(big.Int).SetString(text, 10)
+			if !ok {
+				return fmt.Errorf("invalid integer %v", data)
+			}
+			v.Set(reflect.ValueOf(n))
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		return fromJSON(data, v.Elem())
+	case reflect.Interface:
+		object, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected object for %s", v.Type().Name())
+		}
+		kind, _ := object["kind"].(string)
+		t, ok := jsonKinds[kind]
+		if !ok || !t.Implements(v.Type()) {
+			return fmt.Errorf("invalid kind %q for %s", kind, v.Type().Name())
+		}
+		node := reflect.New(t.Elem())
+		if err := fromJSON(object, node.Elem()); nil != err {
+			return err
+		}
+		v.Set(node)
+		return nil
+	case reflect.Struct:
+		object, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected object for %s", v.Type().Name())
+		}
+		return fromJSONFields(object, v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			text, _ := data.(string)
+			bytes, err := hex.DecodeString(text)
+			if nil != err {
+				return err
+			}
+			v.SetBytes(bytes)
+			return nil
+		}
+		list, ok := data.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected array for %s", v.Type())
+		}
+		v.Set(reflect.MakeSlice(v.Type(), len(list), len(list)))
+		for i, item := range list {
+			if err := fromJSON(item, v.Index(i)); nil != err {
+				return err
+			}
+		}
+		return nil
+	case reflect.String:
+		text, ok := data.(string)
+		if !ok {
+			return fmt.Errorf("expected string, found %v", data)
+		}
+		v.SetString(text)
+		return nil
+	case reflect.Bool:
+		b, ok := data.(bool)
+		if !ok {
+			return fmt.Errorf("expected boolean, found %v", data)
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int:
+		number, ok := data.(json.Number)
+		if !ok {
+			return fmt.Errorf("expected number, found %v", data)
+		}
+		n, err := number.Int64()
+		if nil != err {
+			return err
+		}
+		v.SetInt(n)
+		return nil
+	}
+	return fmt.Errorf("cannot decode %s", v.Type())
+}
+
+func fromJSONFields(object map[string]interface{}, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		key := jsonKey(v.Type().Field(i))
+		if len(key) == 0 {
+			continue
+		}
+		if err := fromJSON(object[key], v.Field(i)); nil != err {
+			return fmt.Errorf("%s.%s: %v", v.Type().Name(), key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package asn1c_go
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func TestJSONGolden(t *testing.T) {
+	set, err := Parse("testdata/json.asn1")
+	if nil != err {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	if err := EncodeJSON(&got, set.Modules[0]); nil != err {
+		t.Fatal(err)
+	}
+	golden := "testdata/json.golden"
+	if *update {
+		if err := ioutil.WriteFile(golden, got.Bytes(), 0644); nil != err {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("the rendering differs from %s, which -update rewrites:\n%s", golden, got.String())
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("Samples/*.asn1")
+	if nil != err {
+		t.Fatal(err)
+	}
+	more, err := filepath.Glob("Samples/012/*.asn1")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, filename := range append(append(files, more...), "testdata/json.asn1") {
+		set, err := Parse(filename)
+		if nil != err {
+			t.Fatal(err)
+		}
+		for _, module := range set.Modules {
+			var encoded bytes.Buffer
+			if err := EncodeJSON(&encoded, module); nil != err {
+				t.Fatal(err)
+			}
+			decoded, err := DecodeJSON(&encoded)
+			if nil != err {
+				t.Fatalf("%s: %v", filename, err)
+			}
+			if !reflect.DeepEqual(module, decoded) {
+				t.Errorf("%s: module %s decodes differently", filename, module.Name)
+			}
+		}
+	}
+}
+
+// TestJSONTags checks that every field of the syntax tree is given its key,
+// or left out, by a json tag.
+func TestJSONTags(t *testing.T) {
+	seen := map[reflect.Type]bool{}
+	var walk func(reflect.Type)
+	walk = func(t reflect.Type) {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice:
+			walk(t.Elem())
+		case reflect.Struct:
+			if seen[t] || t.PkgPath() != syntaxPackage {
+				return
+			}
+			seen[t] = true
+			for i := 0; i < t.NumField(); i++ {
+				walk(t.Field(i).Type)
+			}
+		}
+	}
+	walk(reflect.TypeOf(ModuleDefinition{}))
+	for _, kind := range jsonKinds {
+		walk(kind)
+	}
+	for typ := range seen {
+		for i := 0; i < typ.NumField(); i++ {
+			if field := typ.Field(i); len(field.Tag.Get("json")) == 0 {
+				t.Errorf("%s.%s has no json tag", typ.Name(), field.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,518 @@
+package asn1c_go
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Position struct {
+	Filename string `json:"filename"`
+	Offset   int    `json:"offset"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if len(s) != 0 {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if len(s) == 0 {
+		s = "-"
+	}
+	return s
+}
+
+type TokenKind int
+
+const (
+	TokenEOF TokenKind = iota
+	TokenTypeReference
+	TokenIdentifier
+	TokenKeyword
+	TokenNumber
+	TokenRealNumber
+	TokenCString
+	TokenBString
+	TokenHString
+	TokenTypeFieldReference
+	TokenValueFieldReference
+	TokenSymbol
+	TokenComment
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenEOF:
+		return "end of file"
+	case TokenTypeReference:
+		return "type reference"
+	case TokenIdentifier:
+		return "identifier"
+	case TokenKeyword:
+		return "keyword"
+	case TokenNumber:
+		return "number"
+	case TokenRealNumber:
+		return "real number"
+	case TokenCString:
+		return "character string"
+	case TokenBString:
+		return "binary string"
+	case TokenHString:
+		return "hexadecimal string"
+	case TokenTypeFieldReference:
+		return "type field reference"
+	case TokenValueFieldReference:
+		return "value field reference"
+	case TokenSymbol:
+		return "symbol"
+	case TokenComment:
+		return "comment"
+	}
+	return fmt.Sprintf("TokenKind(%d)", int(k))
+}
+
+type Token struct {
+	Kind     TokenKind `json:"kind"`
+	Text     string    `json:"text"`
+	Position Position  `json:"position"`
+}
+
+func (t Token) String() string {
+	switch t.Kind {
+	case TokenEOF:
+		return t.Kind.String()
+	case TokenCString:
+		return "\"" + t.Text + "\""
+	case TokenBString:
+		return "'" + t.Text + "'B"
+	case TokenHString:
+		return "'" + t.Text + "'H"
+	}
+	return "'" + t.Text + "'"
+}
+
+var keywords = map[string]bool{
+	Absent:           true,
+	AbstractSyntax:   true,
+	All:              true,
+	Application:      true,
+	Automatic:        true,
+	Begin:            true,
+	Bit:              true,
+	BMPString:        true,
+	Boolean:          true,
+	By:               true,
+	Character:        true,
+	Choice:           true,
+	Class:            true,
+	Component:        true,
+	Components:       true,
+	Constrained:      true,
+	Containing:       true,
+	Date:             true,
+	DateTime:         true,
+	Default:          true,
+	Definitions:      true,
+	Duration:         true,
+	Embedded:         true,
+	Encoded:          true,
+	EncodingControl:  true,
+	End:              true,
+	Enumerated:       true,
+	Except:           true,
+	Explicit:         true,
+	Exports:          true,
+	Extensibility:    true,
+	Externel:         true,
+	False:            true,
+	From:             true,
+	GeneralizedTime:  true,
+	GeneralString:    true,
+	GraphicString:    true,
+	IA5String:        true,
+	Identifier:       true,
+	Implicit:         true,
+	Implied:          true,
+	Imports:          true,
+	Includes:         true,
+	Instance:         true,
+	Instructions:     true,
+	Integer:          true,
+	Intersection:     true,
+	ISO646String:     true,
+	Max:              true,
+	Min:              true,
+	MinusInfinity:    true,
+	NotANumber:       true,
+	Null:             true,
+	NumericString:    true,
+	Object:           true,
+	ObjectDescriptor: true,
+	Octet:            true,
+	Of:               true,
+	OIDIRI:           true,
+	Optional:         true,
+	Pattern:          true,
+	PDV:              true,
+	PlusInfinity:     true,
+	Present:          true,
+	PrintableString:  true,
+	Private:          true,
+	Real:             true,
+	RelativeOID:      true,
+	RelativeOIDIRI:   true,
+	Sequence:         true,
+	Set:              true,
+	Settings:         true,
+	Size:             true,
+	String:           true,
+	Syntax:           true,
+	T61String:        true,
+	Tags:             true,
+	TeletexString:    true,
+	Time:             true,
+	TimeOfDay:        true,
+	True:             true,
+	TypeIdentifier:   true,
+	Union:            true,
+	Unique:           true,
+	Universal:        true,
+	UniversalString:  true,
+	UTCTime:          true,
+	UTF8String:       true,
+	VideotexString:   true,
+	VisibleString:    true,
+	With:             true,
+}
+
+func IsKeyword(word string) bool {
+	return keywords[word]
+}
+
+// Longest symbols first, so that "::=" wins over ":" and "..." over "..".
+var symbols = []string{
+	"::=", "...", "..",
+	"{", "}", "(", ")", "[", "]", "<", ",", ".", ";", ":",
+	"|", "^", "!", "@", "-",
+}
+
+type lexer struct {
+	filename string
+	src      []byte
+	offset   int
+	line     int
+	column   int
+}
+
+func newLexer(filename string, src []byte) *lexer {
+	return &lexer{
+		filename: filename,
+		src:      src,
+		line:     1,
+		column:   1,
+	}
+}
+
+// Tokenize splits src into tokens, comments included. It goes on past
+// errors, so the error is an ErrorList and the tokens always end with
+// TokenEOF.
+func Tokenize(filename string, src []byte) ([]Token, error) {
+	tokens, errors := tokenize(filename, src)
+	return tokens, errors.Err()
+}
+
+func tokenize(filename string, src []byte) ([]Token, ErrorList) {
+	var (
+		lex    = newLexer(filename, src)
+		tokens []Token
+		errors ErrorList
+	)
+	for {
+		token, err := lex.next()
+		if nil != err {
+			if len(errors) < maxErrors {
+				errors = append(errors, err.(*Error))
+			}
+			continue
+		}
+		tokens = append(tokens, token)
+		if token.Kind == TokenEOF {
+			return tokens, errors
+		}
+	}
+}
+
+func (l *lexer) position() Position {
+	return Position{
+		Filename: l.filename,
+		Offset:   l.offset,
+		Line:     l.line,
+		Column:   l.column,
+	}
+}
+
+func (l *lexer) peek(n int) byte {
+	if l.offset+n < len(l.src) {
+		return l.src[l.offset+n]
+	}
+	return 0
+}
+
+func (l *lexer) advance(n int) {
+	for ; n > 0 && l.offset < len(l.src); n-- {
+		if l.src[l.offset] == '\n' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+		l.offset++
+	}
+}
+
+func (l *lexer) errorf(pos Position, format string, args ...interface{}) error {
+	return &Error{Position: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\v', '\f':
+		return true
+	}
+	return false
+}
+
+// comment reads a comment starting at the current offset, if there is one.
+// A line comment ends at the end of the line or at the next "--"; block
+// comments may nest. The token holds the comment as written.
+func (l *lexer) comment() (Token, bool, error) {
+	var (
+		pos   = l.position()
+		start = l.offset
+	)
+	if l.peek(0) == '-' && l.peek(1) == '-' {
+		l.advance(2)
+		for l.offset < len(l.src) {
+			c := l.peek(0)
+			if c == '\n' || c == '\r' {
+				break
+			}
+			if c == '-' && l.peek(1) == '-' {
+				l.advance(2)
+				break
+			}
+			l.advance(1)
+		}
+		return Token{Kind: TokenComment, Text: string(l.src[start:l.offset]), Position: pos}, true, nil
+	}
+	if l.peek(0) == '/' && l.peek(1) == '*' {
+		depth := 0
+		for l.offset < len(l.src) {
+			if l.peek(0) == '/' && l.peek(1) == '*' {
+				depth++
+				l.advance(2)
+				continue
+			}
+			if l.peek(0) == '*' && l.peek(1) == '/' {
+				depth--
+				l.advance(2)
+				if depth == 0 {
+					return Token{Kind: TokenComment, Text: string(l.src[start:l.offset]), Position: pos}, true, nil
+				}
+				continue
+			}
+			l.advance(1)
+		}
+		return Token{}, true, l.errorf(pos, "unterminated block comment")
+	}
+	return Token{}, false, nil
+}
+
+func (l *lexer) skipSpace() {
+	for isSpace(l.peek(0)) {
+		l.advance(1)
+	}
+}
+
+func (l *lexer) next() (Token, error) {
+	l.skipSpace()
+	if comment, ok, err := l.comment(); ok {
+		return comment, err
+	}
+	pos := l.position()
+	if l.offset >= len(l.src) {
+		return Token{Kind: TokenEOF, Position: pos}, nil
+	}
+	c := l.peek(0)
+	switch {
+	case isLetter(c):
+		word := l.word()
+		kind := TokenIdentifier
+		if c >= 'A' && c <= 'Z' {
+			kind = TokenTypeReference
+			if keywords[word] {
+				kind = TokenKeyword
+			}
+		}
+		return Token{Kind: kind, Text: word, Position: pos}, nil
+	case c == '&' && isLetter(l.peek(1)):
+		l.advance(1)
+		word := l.word()
+		kind := TokenValueFieldReference
+		if word[0] >= 'A' && word[0] <= 'Z' {
+			kind = TokenTypeFieldReference
+		}
+		return Token{Kind: kind, Text: "&" + word, Position: pos}, nil
+	case isDigit(c):
+		return l.number(pos), nil
+	case c == '"':
+		return l.cstring(pos)
+	case c == '\'':
+		return l.bhstring(pos)
+	}
+	for _, symbol := range symbols {
+		if strings.HasPrefix(string(l.src[l.offset:]), symbol) {
+			l.advance(len(symbol))
+			return Token{Kind: TokenSymbol, Text: symbol, Position: pos}, nil
+		}
+	}
+	l.advance(1)
+	return Token{}, l.errorf(pos, "unexpected character %q", c)
+}
+
+// word reads a reference or identifier: a letter followed by letters, digits
+// and single hyphens, never ending in a hyphen.
+func (l *lexer) word() string {
+	start := l.offset
+	l.advance(1)
+	for {
+		c := l.peek(0)
+		if isLetter(c) || isDigit(c) {
+			l.advance(1)
+			continue
+		}
+		if c == '-' && (isLetter(l.peek(1)) || isDigit(l.peek(1))) {
+			l.advance(2)
+			continue
+		}
+		break
+	}
+	return string(l.src[start:l.offset])
+}
+
+func (l *lexer) digits() {
+	for isDigit(l.peek(0)) {
+		l.advance(1)
+	}
+}
+
+func (l *lexer) number(pos Position) Token {
+	var (
+		start = l.offset
+		kind  = TokenNumber
+	)
+	l.digits()
+	// "1..2" is a range, not a real number.
+	if l.peek(0) == '.' && isDigit(l.peek(1)) {
+		kind = TokenRealNumber
+		l.advance(1)
+		l.digits()
+	}
+	if c := l.peek(0); c == 'e' || c == 'E' {
+		if isDigit(l.peek(1)) {
+			kind = TokenRealNumber
+			l.advance(1)
+			l.digits()
+		} else if l.peek(1) == '-' && isDigit(l.peek(2)) {
+			kind = TokenRealNumber
+			l.advance(2)
+			l.digits()
+		}
+	}
+	return Token{Kind: kind, Text: string(l.src[start:l.offset]), Position: pos}
+}
+
+// cstring reads a quoted character string. Quotes are escaped by doubling
+// them and white-space around a line break is not significant.
+func (l *lexer) cstring(pos Position) (Token, error) {
+	var buffer strings.Builder
+	l.advance(1)
+	for l.offset < len(l.src) {
+		c := l.peek(0)
+		switch {
+		case c == '"' && l.peek(1) == '"':
+			buffer.WriteByte('"')
+			l.advance(2)
+		case c == '"':
+			l.advance(1)
+			return Token{Kind: TokenCString, Text: buffer.String(), Position: pos}, nil
+		case c == '\n' || c == '\r':
+			text := strings.TrimRight(buffer.String(), " \t")
+			buffer.Reset()
+			buffer.WriteString(text)
+			for isSpace(l.peek(0)) {
+				l.advance(1)
+			}
+		default:
+			buffer.WriteByte(c)
+			l.advance(1)
+		}
+	}
+	return Token{}, l.errorf(pos, "unterminated character string")
+}
+
+// bhstring reads a bstring ('0101'B) or an hstring ('0AF'H). White-space
+// between the quotes is not significant.
+func (l *lexer) bhstring(pos Position) (Token, error) {
+	var buffer strings.Builder
+	l.advance(1)
+	for l.offset < len(l.src) && l.peek(0) != '\'' {
+		if c := l.peek(0); !isSpace(c) {
+			buffer.WriteByte(c)
+		}
+		l.advance(1)
+	}
+	if l.offset >= len(l.src) {
+		return Token{}, l.errorf(pos, "unterminated quoted string")
+	}
+	l.advance(1)
+	var (
+		text  = buffer.String()
+		kind  TokenKind
+		valid func(byte) bool
+	)
+	switch l.peek(0) {
+	case 'B':
+		kind = TokenBString
+		valid = func(c byte) bool { return c == '0' || c == '1' }
+	case 'H':
+		kind = TokenHString
+		valid = func(c byte) bool { return isDigit(c) || (c >= 'A' && c <= 'F') }
+	default:
+		return Token{}, l.errorf(pos, "expected 'B' or 'H' after quoted string")
+	}
+	l.advance(1)
+	for i := 0; i < len(text); i++ {
+		if !valid(text[i]) {
+			return Token{}, l.errorf(pos, "invalid character %q in %s", text[i], kind)
+		}
+	}
+	return Token{Kind: kind, Text: text, Position: pos}, nil
+}
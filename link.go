@@ -0,0 +1,483 @@
+package asn1c_go
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Definition is an assignment together with the module holding it.
+type Definition struct {
+	Module     *CheckedModule
+	Assignment Assignment
+}
+
+// Program is a set of modules linked through their imports. Imports maps
+// each imported symbol to its definition, following modules that import and
+// export it again.
+type Program struct {
+	Modules []*CheckedModule
+	Imports map[*Symbol]*Definition
+
+	instances map[*ReferencedType]*TypeAssignment
+	keyed     map[string]*TypeAssignment
+}
+
+// Module returns the module called name. When several modules share the
+// name, the first one read is returned.
+func (p *Program) Module(name string) *CheckedModule {
+	for _, module := range p.Modules {
+		if module.Module.Name == name {
+			return module
+		}
+	}
+	return nil
+}
+
+// Imported returns the definition of name, which imported brings into a
+// module.
+func (p *Program) Imported(imported *Import, name string) *Definition {
+	for _, symbol := range imported.Symbols {
+		if symbol.Name == name {
+			return p.Imports[symbol]
+		}
+	}
+	return nil
+}
+
+// from finds the module imported refers to, using its identifier when
+// several modules have the name, and counts the modules having it. It
+// returns nil when there is no such module or it cannot be told which.
+func (p *Program) from(imported *Import) (*CheckedModule, int) {
+	var candidates []*CheckedModule
+	for _, module := range p.Modules {
+		if module.Module.Name == imported.Module {
+			candidates = append(candidates, module)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], 1
+	}
+	identifier, _ := imported.Identifier.(*ObjectIdentifierValue)
+	var found *CheckedModule
+	for _, candidate := range candidates {
+		if nil == identifier || !sameIdentifier(identifier, candidate.Module.Identifier) {
+			continue
+		}
+		if nil != found {
+			return nil, len(candidates)
+		}
+		found = candidate
+	}
+	return found, len(candidates)
+}
+
+// Reference returns what n, a reference in one of the modules, stands for.
+func (p *Program) Reference(n Node) *Reference {
+	for _, module := range p.Modules {
+		if reference := module.References[n]; nil != reference {
+			return reference
+		}
+	}
+	return nil
+}
+
+// ResolveType follows t through type references, imports included, to the
+// type it stands for. It stops at a reference it cannot follow, such as a
+// dummy reference, and returns that reference.
+func (p *Program) ResolveType(t Type) Type {
+	for i := 0; i <= len(p.Imports)+len(p.Modules); i++ {
+		ref, ok := t.(*ReferencedType)
+		if !ok {
+			return t
+		}
+		switch assignment := p.assignment(ref).(type) {
+		case *TypeAssignment:
+			t = assignment.Type
+		case *ValueSetAssignment:
+			t = assignment.Type
+		default:
+			return t
+		}
+	}
+	return t
+}
+
+// assignment returns the assignment ref names, following imports, or nil
+// when it names none. An instantiated reference names its instance.
+func (p *Program) assignment(ref *ReferencedType) Assignment {
+	if instance := p.instances[ref]; nil != instance {
+		return instance
+	}
+	reference := p.Reference(ref)
+	switch {
+	case nil == reference:
+		return nil
+	case nil != reference.Import:
+		if definition := p.Imported(reference.Import, ref.Name); nil != definition {
+			return definition.Assignment
+		}
+		return nil
+	}
+	return reference.Assignment
+}
+
+// ResolveValue follows v through value references, imports included, to
+// the value it stands for, or returns nil when they cannot be followed.
+func (p *Program) ResolveValue(v Value) Value {
+	return resolveValue(v, p.Reference, func(imported *Import, name string) Assignment {
+		if definition := p.Imported(imported, name); nil != definition {
+			return definition.Assignment
+		}
+		return nil
+	})
+}
+
+// ResolveDefault returns the DEFAULT value of component resolved as by
+// ResolveValue, with an item of an ENUMERATED, which has no value of its
+// own when it is not numbered, as an IntegerValue of the number X.680
+// gives it. It returns nil when component has no DEFAULT or the value
+// cannot be resolved.
+func (p *Program) ResolveDefault(component *ComponentType) Value {
+	if nil == component.Default {
+		return nil
+	}
+	base, _, _, err := p.Underlying(component.Type)
+	enumerated, ok := base.(*EnumeratedType)
+	if nil != err || !ok {
+		return p.ResolveValue(component.Default)
+	}
+	numbers := p.Enumerate(enumerated)
+	items := namedNumbers(enumerated)
+	seen := map[Value]bool{}
+	for v := component.Default; nil != v && !seen[v]; {
+		seen[v] = true
+		ref, ok := v.(*ReferencedValue)
+		if !ok {
+			return v
+		}
+		reference := p.Reference(ref)
+		switch {
+		case nil == reference:
+			return nil
+		case nil != reference.Named:
+			for i, item := range items {
+				if item == reference.Named && i < len(numbers) {
+					return &IntegerValue{Position: ref.Position, Value: numbers[i]}
+				}
+			}
+			return nil
+		}
+		assignment := reference.Assignment
+		if nil != reference.Import {
+			if definition := p.Imported(reference.Import, ref.Name); nil != definition {
+				assignment = definition.Assignment
+			}
+		}
+		a, ok := assignment.(*ValueAssignment)
+		if !ok {
+			return nil
+		}
+		v = a.Value
+	}
+	return nil
+}
+
+func (p *Program) definitions() []*ModuleDefinition {
+	modules := make([]*ModuleDefinition, 0, len(p.Modules))
+	for _, module := range p.Modules {
+		modules = append(modules, module.Module)
+	}
+	return modules
+}
+
+// exports reports whether module exports name.
+func exports(module *ModuleDefinition, name string) bool {
+	if nil == module.Exports || module.Exports.All {
+		return true
+	}
+	for _, symbol := range module.Exports.Symbols {
+		if symbol.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type linker struct {
+	program  *Program
+	options  []Option
+	parsers  []*parser
+	checkers []*checker
+	files    map[string]bool
+	includes []string
+	scanned  bool
+	errors   ErrorList
+}
+
+// ParseAndLink parses files and links their modules through their imports.
+// A module imported but not found in files is looked for in includeDirs,
+// first in a file named after it with an .asn1 or .asn extension, then in
+// every such file of the directories.
+//
+// Like ParseBytes, it goes on past errors, so the error is an ErrorList and
+// the program holds whatever could be linked.
+func ParseAndLink(files []string, includeDirs []string, options ...Option) (*Program, error) {
+	l := &linker{
+		program:  &Program{Imports: map[*Symbol]*Definition{}},
+		options:  options,
+		files:    map[string]bool{},
+		includes: includeDirs,
+	}
+	for _, filename := range files {
+		if err := l.load(filename); nil != err {
+			return nil, err
+		}
+	}
+	l.include()
+	for changed := true; changed; {
+		changed = false
+		for _, p := range l.parsers {
+			changed = p.defineImportedClasses(l.program.definitions()) || changed
+		}
+	}
+	for _, p := range l.parsers {
+		p.defineImportedObjects(l.program.definitions())
+		l.errors = append(l.errors, *p.errors...)
+	}
+	l.checkModuleNames()
+	for _, c := range l.checkers {
+		c.checkTypes()
+	}
+	for _, module := range l.program.Modules {
+		l.linkImports(module.Module)
+	}
+	instances := l.instantiate()
+	for _, c := range l.checkers {
+		c.applyTags()
+	}
+	for _, c := range l.checkers {
+		c.checkValues()
+	}
+	for _, c := range l.checkers {
+		c.orderComponents()
+	}
+	for _, c := range l.checkers {
+		c.checkBounds()
+		c.checkNumbers()
+		c.checkConformance()
+		c.checked.Warnings.Sort()
+		l.errors = append(l.errors, c.errors...)
+	}
+	l.reportInstances(instances)
+	l.errors.Sort()
+	return l.program, l.errors.Err()
+}
+
+func (l *linker) errorf(pos Position, format string, args ...interface{}) {
+	l.errors = append(l.errors, &Error{Position: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// reportInstances adds the errors of instances not reported already, as those in the
+// copied parts of a parameterized type that do not depend on its parameters
+// are found in every instance and in the type itself.
+func (l *linker) reportInstances(errors ErrorList) {
+	reported := map[Error]bool{}
+	for _, e := range l.errors {
+		reported[*e] = true
+	}
+	for _, e := range errors {
+		if !reported[*e] {
+			reported[*e] = true
+			l.errors = append(l.errors, e)
+		}
+	}
+}
+
+// load parses filename unless it was read already.
+func (l *linker) load(filename string) error {
+	path, err := filepath.Abs(filename)
+	if nil != err {
+		return err
+	}
+	if l.files[path] {
+		return nil
+	}
+	l.files[path] = true
+	data, err := ioutil.ReadFile(filename)
+	if nil != err {
+		return err
+	}
+	p, modules := parseModules(filename, data, l.options)
+	l.parsers = append(l.parsers, p)
+	for _, module := range modules {
+		c := newChecker(module)
+		c.program = l.program
+		l.checkers = append(l.checkers, c)
+		l.program.Modules = append(l.program.Modules, c.checked)
+	}
+	return nil
+}
+
+// include reads the modules imported from the include directories until
+// every import is found or nothing more can be read.
+func (l *linker) include() {
+	for i := 0; i < len(l.program.Modules); i++ {
+		for _, imported := range l.program.Modules[i].Module.Imports {
+			if nil == l.program.Module(imported.Module) {
+				l.search(imported.Module)
+			}
+		}
+	}
+}
+
+func (l *linker) search(name string) {
+	for _, dir := range l.includes {
+		for _, ext := range []string{".asn1", ".asn"} {
+			filename := filepath.Join(dir, name+ext)
+			if _, err := os.Stat(filename); nil == err {
+				l.report(l.load(filename))
+				if nil != l.program.Module(name) {
+					return
+				}
+			}
+		}
+	}
+	if l.scanned {
+		return
+	}
+	l.scanned = true
+	for _, dir := range l.includes {
+		entries, err := ioutil.ReadDir(dir)
+		if nil != err {
+			l.report(err)
+			continue
+		}
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if !entry.IsDir() && (ext == ".asn1" || ext == ".asn") {
+				l.report(l.load(filepath.Join(dir, entry.Name())))
+			}
+		}
+	}
+}
+
+func (l *linker) report(err error) {
+	if nil != err {
+		l.errors = append(l.errors, &Error{Message: err.Error()})
+	}
+}
+
+// checkModuleNames reports modules defined twice. Modules may share a name
+// when their identifiers tell them apart.
+func (l *linker) checkModuleNames() {
+	modules := l.program.Modules
+	for i, module := range modules {
+		for _, other := range modules[:i] {
+			if module.Module.Name != other.Module.Name {
+				continue
+			}
+			if !sameIdentifier(module.Module.Identifier, other.Module.Identifier) {
+				continue
+			}
+			l.errorf(module.Module.Position, "module %s already defined at %s", module.Module.Name, other.Module.Position)
+			break
+		}
+	}
+}
+
+// sameIdentifier reports whether two module identifiers may denote the same
+// module. An absent identifier matches any other.
+func sameIdentifier(a, b *ObjectIdentifierValue) bool {
+	if nil == a || nil == b {
+		return true
+	}
+	if len(a.Components) != len(b.Components) {
+		return false
+	}
+	for i, x := range a.Components {
+		y := b.Components[i]
+		m, ok := x.Value.(*IntegerValue)
+		n, same := y.Value.(*IntegerValue)
+		if ok && same {
+			same = m.Value.Cmp(n.Value) == 0
+		} else {
+			same = x.Name == y.Name
+		}
+		if !same {
+			return false
+		}
+	}
+	return true
+}
+
+// imported finds the module an import refers to. Failures are reported
+// when report is set.
+func (l *linker) imported(imported *Import, report bool) *ModuleDefinition {
+	from, candidates := l.program.from(imported)
+	switch {
+	case nil != from:
+		return from.Module
+	case !report:
+	case candidates == 0:
+		l.errorf(imported.Position, "module %s not found", imported.Module)
+	default:
+		l.errorf(imported.Position, "module %s is ambiguous", imported.Module)
+	}
+	return nil
+}
+
+func (l *linker) checked(module *ModuleDefinition) *CheckedModule {
+	for _, checked := range l.program.Modules {
+		if checked.Module == module {
+			return checked
+		}
+	}
+	return nil
+}
+
+// linkImports resolves the symbols module imports against the exports of
+// the modules they come from.
+func (l *linker) linkImports(module *ModuleDefinition) {
+	for _, imported := range module.Imports {
+		from := l.imported(imported, true)
+		if nil == from {
+			continue
+		}
+		for _, symbol := range imported.Symbols {
+			if definition := l.define(from, symbol); nil != definition {
+				l.program.Imports[symbol] = definition
+			}
+		}
+	}
+}
+
+// define finds the definition symbol names in module, following the module
+// into its own imports when it exports a symbol it imported. A failing
+// import of that module is left for it to report.
+func (l *linker) define(module *ModuleDefinition, symbol *Symbol) *Definition {
+	for i := 0; i <= len(l.program.Modules); i++ {
+		if !exports(module, symbol.Name) {
+			l.errorf(symbol.Position, "%s is not exported by module %s", symbol.Name, module.Name)
+			return nil
+		}
+		if assignment := module.Lookup(symbol.Name); nil != assignment {
+			return &Definition{Module: l.checked(module), Assignment: assignment}
+		}
+		if _, ok := builtinObjectClasses[symbol.Name]; ok {
+			return nil
+		}
+		imported := module.LookupImport(symbol.Name)
+		if nil == imported {
+			l.errorf(symbol.Position, "%s is not defined in module %s", symbol.Name, module.Name)
+			return nil
+		}
+		if module = l.imported(imported, false); nil == module {
+			return nil
+		}
+	}
+	l.errorf(symbol.Position, "%s is imported in a cycle", symbol.Name)
+	return nil
+}
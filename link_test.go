@@ -0,0 +1,186 @@
+package asn1c_go
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLinkSamples(t *testing.T) {
+	files, err := filepath.Glob("Samples/*.asn1")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, filename := range files {
+		if _, err := ParseAndLink([]string{filename}, []string{"Samples/012"}); nil != err {
+			t.Errorf("%s: %v", filename, err)
+		}
+	}
+}
+
+func TestLinkInstances(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name: "numbers",
+			source: `M DEFINITIONS ::= BEGIN
+Numbers {INTEGER:a, INTEGER:b} ::= INTEGER { x(a), y(b) }
+Distinct ::= Numbers {1, 2}
+Same ::= Numbers {1, 1}
+END`,
+			want: []string{"1.asn:2:52: y reuses the number 1 of x at 1.asn:2:46"},
+		},
+		{
+			name: "default",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Flags {INTEGER:n} ::= SEQUENCE { flag BOOLEAN DEFAULT n }
+Used ::= SEQUENCE { flags Flags {5} }
+END`,
+			want: []string{"1.asn:2:55: BOOLEAN value expected for the type at 1.asn:2:39"},
+		},
+		{
+			name: "independent",
+			source: `M DEFINITIONS ::= BEGIN
+Pair {T} ::= SEQUENCE { first T, second Undefined }
+One ::= Pair {INTEGER}
+Two ::= Pair {BOOLEAN}
+END`,
+			want: []string{"1.asn:2:41: undefined reference Undefined"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := link(t, test.source)
+			if got := messages(t, err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// defaultText returns the text of v.
+func defaultText(v Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case *IntegerValue:
+		return v.Value.String()
+	case *BooleanValue:
+		return fmt.Sprint(v.Value)
+	case *StringValue:
+		return strconv.Quote(v.Value)
+	case *RealValue:
+		if len(v.Special) != 0 {
+			return v.Special
+		}
+		return fmt.Sprintf("%s*%d^%d", v.Mantissa, v.Base, v.Exponent)
+	case *BitStringValue:
+		return fmt.Sprintf("bits %x/%d", v.Bytes, v.Length)
+	case *OctetStringValue:
+		return fmt.Sprintf("octets %x/%d", v.Bytes, v.Length)
+	case *NullValue:
+		return "NULL"
+	case *ReferencedValue:
+		return v.Name
+	case *ObjectIdentifierValue:
+		var components []string
+		for _, component := range v.Components {
+			if nil == component.Value {
+				components = append(components, component.Name)
+			} else {
+				components = append(components, defaultText(component.Value))
+			}
+		}
+		return "{" + strings.Join(components, " ") + "}"
+	case *SequenceValue:
+		var components []string
+		for _, component := range v.Components {
+			components = append(components, component.Name+" "+defaultText(component.Value))
+		}
+		return "{" + strings.Join(components, ", ") + "}"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func TestResolveDefault(t *testing.T) {
+	program, err := link(t, `Values DEFINITIONS ::= BEGIN
+EXPORTS ALL;
+Color ::= ENUMERATED { red, green(5), blue }
+limit INTEGER ::= 7
+imported-color Color ::= blue
+END`, `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+IMPORTS limit, imported-color, Color FROM Values;
+local INTEGER ::= 3
+alias INTEGER ::= local
+on BOOLEAN ::= TRUE
+green-again Color ::= green
+Numbered ::= INTEGER { one(1), two(2) }
+Defaults ::= SEQUENCE {
+	integer		INTEGER DEFAULT 4,
+	negative	INTEGER DEFAULT -4,
+	named		Numbered DEFAULT two,
+	local		INTEGER DEFAULT alias,
+	imported	INTEGER DEFAULT limit,
+	qualified	INTEGER DEFAULT Values.limit,
+	boolean		BOOLEAN DEFAULT on,
+	string		IA5String DEFAULT "x",
+	real		REAL DEFAULT 1.5,
+	infinity	REAL DEFAULT MINUS-INFINITY,
+	bits		BIT STRING DEFAULT '101'B,
+	octets		OCTET STRING DEFAULT 'DEAD'H,
+	null		NULL DEFAULT NULL,
+	first		Color DEFAULT red,
+	numbered	Color DEFAULT green,
+	after		Color DEFAULT blue,
+	valued		Color DEFAULT green-again,
+	from		Color DEFAULT imported-color,
+	none		INTEGER
+}
+END`)
+	if nil != err {
+		t.Fatal(err)
+	}
+	var defaults *SequenceType
+	for _, assignment := range program.Modules[1].Module.Assignments {
+		if a, ok := assignment.(*TypeAssignment); ok && a.Name == "Defaults" {
+			defaults = a.Type.(*SequenceType)
+		}
+	}
+	tests := map[string]string{
+		"integer":   "4",
+		"negative":  "-4",
+		"named":     "2",
+		"local":     "3",
+		"imported":  "7",
+		"qualified": "7",
+		"boolean":   "true",
+		"string":    `"x"`,
+		"real":      "15*10^-1",
+		"infinity":  "MINUS-INFINITY",
+		"bits":      "bits a0/3",
+		"octets":    "octets dead/16",
+		"null":      "NULL",
+		"first":     "0",
+		"numbered":  "5",
+		"after":     "1",
+		"valued":    "5",
+		"from":      "1",
+		"none":      "nil",
+	}
+	for _, component := range defaults.RootComponents() {
+		want, ok := tests[component.Name]
+		if !ok {
+			t.Errorf("no test of %s", component.Name)
+			continue
+		}
+		if got := defaultText(program.ResolveDefault(component)); got != want {
+			t.Errorf("%s: got %s, want %s", component.Name, got, want)
+		}
+	}
+}
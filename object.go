@@ -0,0 +1,419 @@
+package asn1c_go
+
+type FieldSetting struct {
+	Position Position `json:"position"`
+	Name     string   `json:"name"`
+	Setting  *Setting `json:"setting"`
+}
+
+// InformationObject is either a reference to an object, or an object
+// defined in the syntax of its class. How a definition reads depends on the
+// class, which may live in another module, so the definition is kept as Body
+// tokens until the class is known and then parsed into Fields.
+type InformationObject struct {
+	Position  Position        `json:"position"`
+	Module    string          `json:"module"`
+	Reference string          `json:"reference"`
+	Body      []Token         `json:"body"`
+	Fields    []*FieldSetting `json:"fields"`
+}
+
+func (o *InformationObject) Pos() Position { return o.Position }
+
+func (o *InformationObject) Field(name string) *Setting {
+	for _, field := range o.Fields {
+		if field.Name == name {
+			return field.Setting
+		}
+	}
+	return nil
+}
+
+type ObjectSet struct {
+	Position        Position `json:"position"`
+	ElementSetSpecs `json:"specs"`
+}
+
+func (s *ObjectSet) Pos() Position { return s.Position }
+
+type ObjectElement struct {
+	Position Position           `json:"position"`
+	Object   *InformationObject `json:"object"`
+}
+
+type ObjectSetElement struct {
+	Position Position `json:"position"`
+	Module   string   `json:"module"`
+	Name     string   `json:"name"`
+}
+
+func (e *ObjectElement) Pos() Position    { return e.Position }
+func (e *ObjectSetElement) Pos() Position { return e.Position }
+
+func (*ObjectElement) elementNode()    {}
+func (*ObjectSetElement) elementNode() {}
+
+type ObjectAssignment struct {
+	Position Position           `json:"position"`
+	Name     string             `json:"name"`
+	Class    string             `json:"class"`
+	Object   *InformationObject `json:"object"`
+	Doc      string             `json:"doc"`
+	Comment  string             `json:"comment"`
+}
+
+type ObjectSetAssignment struct {
+	Position Position   `json:"position"`
+	Name     string     `json:"name"`
+	Class    string     `json:"class"`
+	Set      *ObjectSet `json:"set"`
+	Doc      string     `json:"doc"`
+	Comment  string     `json:"comment"`
+}
+
+func (a *ObjectAssignment) Pos() Position    { return a.Position }
+func (a *ObjectSetAssignment) Pos() Position { return a.Position }
+
+func (a *ObjectAssignment) Reference() string    { return a.Name }
+func (a *ObjectSetAssignment) Reference() string { return a.Name }
+
+// TableConstraint restricts a class field type to the values the objects
+// of Set hold in that field. With Components it is a component relation
+// constraint, which selects the object by the values of the referenced
+// components.
+type TableConstraint struct {
+	Position   Position      `json:"position"`
+	Set        *ObjectSet    `json:"set"`
+	Components []*AtNotation `json:"components"`
+}
+
+// AtNotation references a component from a component relation constraint.
+// Level counts the dots after "@", zero meaning the outermost type.
+type AtNotation struct {
+	Position   Position `json:"position"`
+	Level      int      `json:"level"`
+	Components []string `json:"components"`
+}
+
+func (e *TableConstraint) Pos() Position { return e.Position }
+func (a *AtNotation) Pos() Position      { return a.Position }
+
+func (*TableConstraint) elementNode() {}
+
+// pendingObject is an object definition waiting for its class. Scope parses
+// the definition with what is known of the module it appears in.
+type pendingObject struct {
+	object *InformationObject
+	class  string
+	scope  *parser
+}
+
+// captureBraces consumes a balanced "{ ... }" group and returns its tokens,
+// braces included.
+func (p *parser) captureBraces() ([]Token, error) {
+	var (
+		start = p.index
+		depth = 0
+	)
+	for {
+		tok := p.next()
+		switch {
+		case tok.Kind == TokenEOF:
+			return nil, p.errorf(p.tokens[start].Position, "unbalanced '{'")
+		case tok.Kind == TokenSymbol && tok.Text == "{":
+			depth++
+		case tok.Kind == TokenSymbol && tok.Text == "}":
+			depth--
+			if depth == 0 {
+				return append([]Token(nil), p.tokens[start:p.index]...), nil
+			}
+		}
+	}
+}
+
+func (p *parser) parseObject(class string) (*InformationObject, error) {
+	tok := p.peek(0)
+	switch {
+	case p.is("{"):
+		body, err := p.captureBraces()
+		if nil != err {
+			return nil, err
+		}
+		object := &InformationObject{Position: tok.Position, Body: body}
+		*p.pending = append(*p.pending, &pendingObject{object: object, class: class, scope: p.fork(body)})
+		return object, nil
+	case tok.Kind == TokenIdentifier:
+		p.next()
+		return &InformationObject{Position: tok.Position, Reference: tok.Text}, nil
+	case tok.Kind == TokenTypeReference && p.isAt(1, ".") && p.peek(2).Kind == TokenIdentifier:
+		p.index += 2
+		return &InformationObject{Position: tok.Position, Module: tok.Text, Reference: p.next().Text}, nil
+	}
+	return nil, p.unexpected("object")
+}
+
+func (p *parser) parseObjectSet(class string) (*ObjectSet, error) {
+	start, err := p.expect("{")
+	if nil != err {
+		return nil, err
+	}
+	specs, err := p.parseElementSetSpecs(func() (Element, error) {
+		return p.parseObjectSetElement(class)
+	})
+	if nil != err {
+		return nil, err
+	}
+	if _, err := p.expect("}"); nil != err {
+		return nil, err
+	}
+	return &ObjectSet{Position: start.Position, ElementSetSpecs: specs}, nil
+}
+
+func (p *parser) parseObjectSetElement(class string) (Element, error) {
+	tok := p.peek(0)
+	switch {
+	case p.is("{"), tok.Kind == TokenIdentifier:
+		object, err := p.parseObject(class)
+		if nil != err {
+			return nil, err
+		}
+		return &ObjectElement{Position: tok.Position, Object: object}, nil
+	case tok.Kind == TokenTypeReference && p.isAt(1, ".") && p.peek(2).Kind == TokenIdentifier:
+		object, err := p.parseObject(class)
+		if nil != err {
+			return nil, err
+		}
+		return &ObjectElement{Position: tok.Position, Object: object}, nil
+	case tok.Kind == TokenTypeReference:
+		p.next()
+		element := &ObjectSetElement{Position: tok.Position, Name: tok.Text}
+		if p.is(".") && p.peek(1).Kind == TokenTypeReference {
+			p.next()
+			element.Module = tok.Text
+			element.Name = p.next().Text
+		}
+		return element, nil
+	}
+	return nil, p.unexpected("object or object set")
+}
+
+func (p *parser) parseTableConstraint(class string) (*Constraint, error) {
+	start, err := p.expect("(")
+	if nil != err {
+		return nil, err
+	}
+	set, err := p.parseObjectSet(class)
+	if nil != err {
+		return nil, err
+	}
+	table := &TableConstraint{Position: set.Position, Set: set}
+	if p.accept("{") {
+		for {
+			at, err := p.parseAtNotation()
+			if nil != err {
+				return nil, err
+			}
+			table.Components = append(table.Components, at)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if _, err := p.expect("}"); nil != err {
+			return nil, err
+		}
+	}
+	if _, err := p.expect(")"); nil != err {
+		return nil, err
+	}
+	return &Constraint{Position: start.Position, ElementSetSpecs: ElementSetSpecs{Root: table}}, nil
+}
+
+func (p *parser) parseAtNotation() (*AtNotation, error) {
+	start, err := p.expect("@")
+	if nil != err {
+		return nil, err
+	}
+	at := &AtNotation{Position: start.Position}
+	for {
+		switch {
+		case p.accept("."):
+			at.Level++
+			continue
+		case p.accept(".."):
+			at.Level += 2
+			continue
+		case p.accept("..."):
+			at.Level += 3
+			continue
+		}
+		break
+	}
+	for {
+		name, err := p.expectKind(TokenIdentifier)
+		if nil != err {
+			return nil, err
+		}
+		at.Components = append(at.Components, name.Text)
+		if !p.accept(".") {
+			return at, nil
+		}
+	}
+}
+
+// defineObjects parses the pending object definitions of module whose class
+// is defined in it. Definitions of imported classes stay pending.
+func (p *parser) defineObjects(module *ModuleDefinition) {
+	var remaining []*pendingObject
+	for i := 0; i < len(*p.pending); i++ {
+		pending := (*p.pending)[i]
+		if pending.scope.module != module {
+			remaining = append(remaining, pending)
+			continue
+		}
+		class := module.ObjectClass(pending.class)
+		if nil == class {
+			remaining = append(remaining, pending)
+			continue
+		}
+		if err := pending.define(class); nil != err {
+			p.report(err)
+		}
+	}
+	*p.pending = remaining
+}
+
+// defineImportedObjects parses the pending object definitions whose class
+// is imported from one of modules. Others stay pending.
+func (p *parser) defineImportedObjects(modules []*ModuleDefinition) {
+	var remaining []*pendingObject
+	for i := 0; i < len(*p.pending); i++ {
+		pending := (*p.pending)[i]
+		class := lookupObjectClass(modules, pending.scope.module, pending.class)
+		if nil == class {
+			remaining = append(remaining, pending)
+			continue
+		}
+		if err := pending.define(class); nil != err {
+			p.report(err)
+		}
+	}
+	*p.pending = remaining
+}
+
+func (o *pendingObject) define(class *ObjectClass) error {
+	sub := o.scope
+	fields, err := sub.parseObjectDefinition(class)
+	if nil != err {
+		return err
+	}
+	if tok := sub.peek(0); tok.Kind != TokenEOF {
+		return sub.errorf(tok.Position, "unexpected %s after object definition", tok)
+	}
+	o.object.Fields = fields
+	o.object.Body = nil
+	return nil
+}
+
+func (p *parser) parseObjectDefinition(class *ObjectClass) ([]*FieldSetting, error) {
+	start, err := p.expect("{")
+	if nil != err {
+		return nil, err
+	}
+	var fields []*FieldSetting
+	if nil == class.Syntax {
+		err = p.parseDefaultSyntax(class, &fields)
+	} else {
+		_, err = p.parseDefinedSyntax(class, class.Syntax, false, &fields)
+	}
+	if nil != err {
+		return nil, err
+	}
+	if _, err := p.expect("}"); nil != err {
+		return nil, err
+	}
+	for _, field := range class.Fields {
+		if field.Optional || nil != field.Default {
+			continue
+		}
+		found := false
+		for _, setting := range fields {
+			found = found || setting.Name == field.Name
+		}
+		if !found {
+			return nil, p.errorf(start.Position, "object does not set mandatory field %s", field.Name)
+		}
+	}
+	return fields, nil
+}
+
+func (p *parser) parseFieldSetting(class *ObjectClass, name Token, fields *[]*FieldSetting) error {
+	field := class.Field(name.Text)
+	if nil == field {
+		return p.errorf(name.Position, "class has no field %s", name.Text)
+	}
+	for _, setting := range *fields {
+		if setting.Name == field.Name {
+			return p.errorf(name.Position, "field %s set more than once", field.Name)
+		}
+	}
+	setting, err := p.parseSetting(field)
+	if nil != err {
+		return err
+	}
+	*fields = append(*fields, &FieldSetting{Position: name.Position, Name: field.Name, Setting: setting})
+	return nil
+}
+
+func (p *parser) parseDefaultSyntax(class *ObjectClass, fields *[]*FieldSetting) error {
+	for !p.is("}") {
+		name := p.next()
+		if !isFieldReference(name) {
+			return p.errorf(name.Position, "expected field name, found %s", name)
+		}
+		if err := p.parseFieldSetting(class, name, fields); nil != err {
+			return err
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	return nil
+}
+
+func isLiteral(tok Token, literal string) bool {
+	switch tok.Kind {
+	case TokenTypeReference, TokenKeyword, TokenSymbol:
+		return tok.Text == literal
+	}
+	return false
+}
+
+// parseDefinedSyntax matches the tokens against a WITH SYNTAX list. An
+// optional group is absent when its leading literal does not match, which
+// X.681 guarantees is enough to decide.
+func (p *parser) parseDefinedSyntax(class *ObjectClass, elements []*SyntaxElement, optional bool, fields *[]*FieldSetting) (bool, error) {
+	for i, element := range elements {
+		switch {
+		case len(element.Literal) != 0:
+			if isLiteral(p.peek(0), element.Literal) {
+				p.next()
+				continue
+			}
+			if optional && i == 0 {
+				return false, nil
+			}
+			return false, p.unexpected("'" + element.Literal + "'")
+		case len(element.Field) != 0:
+			name := p.peek(0)
+			name.Text = element.Field
+			if err := p.parseFieldSetting(class, name, fields); nil != err {
+				return false, err
+			}
+		default:
+			if _, err := p.parseDefinedSyntax(class, element.Group, true, fields); nil != err {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
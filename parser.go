@@ -3,13 +3,14 @@ package asn1c_go
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/big"
 	"regexp"
+	"sort"
+	"strings"
 )
 
-type ModuleDefinition struct {
-}
-
 func RemoveBlanks(buffer []byte) []byte {
 	regex := regexp.MustCompile("(?m)^\\s*$[\r\n]*")
 	return bytes.Trim(regex.ReplaceAll(buffer, []byte("")), "\r\n")
@@ -29,12 +30,1926 @@ func RemoveComments(content []byte) []byte {
 	return RemoveBlanks(RemoveLineComment(RemoveBlockComment(content)))
 }
 
-func Parse(filename string) error {
+func Parse(filename string, options ...Option) (*ModuleSet, error) {
 	data, err := ioutil.ReadFile(filename)
 	if nil != err {
-		return err
+		return nil, err
+	}
+	return ParseBytes(filename, data, options...)
+}
+
+func ParseReader(name string, r io.Reader, options ...Option) (*ModuleSet, error) {
+	data, err := ioutil.ReadAll(r)
+	if nil != err {
+		return nil, err
+	}
+	return ParseBytes(name, data, options...)
+}
+
+// Option changes how modules are parsed.
+type Option func(*parser)
+
+// Strict rejects notation withdrawn from X.680, such as ANY, which is
+// otherwise accepted.
+func Strict() Option {
+	return func(p *parser) {
+		p.strict = true
+	}
+}
+
+// ParseBytes parses every module definition in src, name being used in
+// diagnostics. Objects of a class imported from another of these modules are
+// read once all are parsed, just as if the modules came from separate files.
+//
+// Parsing goes on past errors where it can, so the error is an ErrorList
+// and the returned set holds whatever could be salvaged.
+func ParseBytes(name string, src []byte, options ...Option) (*ModuleSet, error) {
+	p, modules := parseModules(name, src, options)
+	for p.defineImportedClasses(modules) {
+	}
+	p.defineImportedObjects(modules)
+	p.errors.Sort()
+	return &ModuleSet{Modules: modules}, p.errors.Err()
+}
+
+// parseModules parses the module definitions of src, leaving the objects of
+// classes defined elsewhere pending on the returned parser.
+func parseModules(name string, src []byte, options []Option) (*parser, []*ModuleDefinition) {
+	tokens, errors := tokenize(name, src)
+	p := newParser(tokens)
+	for _, option := range options {
+		option(p)
+	}
+	*p.errors = errors
+	var modules []*ModuleDefinition
+	for !p.full() {
+		module, err := p.parseModuleDefinition()
+		if nil != module {
+			modules = append(modules, module)
+		}
+		if nil != err {
+			p.report(err)
+			break
+		}
+		if p.peek(0).Kind == TokenEOF {
+			break
+		}
+	}
+	return p, modules
+}
+
+type Error struct {
+	Position Position
+	Message  string
+}
+
+func (e *Error) Error() string {
+	return e.Position.String() + ": " + e.Message
+}
+
+// maxErrors caps an ErrorList, as errors past the first few are mostly
+// consequences of them.
+const maxErrors = 100
+
+// ErrorList holds the errors of a parse in the order they were found.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Sort orders l by position, lexical errors being found ahead of the others.
+func (l ErrorList) Sort() {
+	sort.SliceStable(l, func(i, j int) bool {
+		a, b := l[i].Position, l[j].Position
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+}
+
+// Err returns nil when l is empty and l otherwise.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+type parser struct {
+	tokens   []Token
+	comments []Token
+	index    int
+	classes  map[string]bool
+	defined  map[string]bool
+	deferred map[string]bool
+	pending  *[]*pendingObject
+	aliases  *[]*pendingAlias
+	module   *ModuleDefinition
+	strict   bool
+	errors   *ErrorList
+}
+
+// newParser returns a parser over tokens, setting their comments aside to
+// document what follows them.
+func newParser(tokens []Token) *parser {
+	p := &parser{
+		classes:  make(map[string]bool),
+		defined:  make(map[string]bool),
+		deferred: make(map[string]bool),
+		pending:  new([]*pendingObject),
+		aliases:  new([]*pendingAlias),
+		errors:   new(ErrorList),
+	}
+	for _, tok := range tokens {
+		if tok.Kind == TokenComment {
+			p.comments = append(p.comments, tok)
+		} else {
+			p.tokens = append(p.tokens, tok)
+		}
+	}
+	return p
+}
+
+// fork returns a parser over tokens captured earlier, sharing what is known
+// about the module being parsed.
+func (p *parser) fork(tokens []Token) *parser {
+	eof := Token{Kind: TokenEOF}
+	if len(tokens) != 0 {
+		eof.Position = tokens[len(tokens)-1].Position
+	}
+	return &parser{
+		tokens:   append(append([]Token(nil), tokens...), eof),
+		classes:  p.classes,
+		defined:  p.defined,
+		deferred: p.deferred,
+		pending:  p.pending,
+		aliases:  p.aliases,
+		module:   p.module,
+		strict:   p.strict,
+		errors:   p.errors,
+	}
+}
+
+func (p *parser) peek(n int) Token {
+	if p.index+n < len(p.tokens) {
+		return p.tokens[p.index+n]
+	}
+	if len(p.tokens) == 0 {
+		return Token{Kind: TokenEOF}
+	}
+	last := p.tokens[len(p.tokens)-1]
+	return Token{Kind: TokenEOF, Position: last.Position}
+}
+
+func (p *parser) next() Token {
+	tok := p.peek(0)
+	if p.index < len(p.tokens) {
+		p.index++
+	}
+	return tok
+}
+
+func (p *parser) isAt(n int, text string) bool {
+	tok := p.peek(n)
+	return tok.Text == text && (tok.Kind == TokenSymbol || tok.Kind == TokenKeyword)
+}
+
+func (p *parser) is(text string) bool {
+	return p.isAt(0, text)
+}
+
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(pos Position, format string, args ...interface{}) error {
+	return &Error{Position: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// report records err, which the parser then recovers from.
+func (p *parser) report(err error) {
+	switch err := err.(type) {
+	case *Error:
+		if !p.full() {
+			*p.errors = append(*p.errors, err)
+		}
+	case ErrorList:
+		for _, e := range err {
+			p.report(e)
+		}
+	default:
+		p.report(&Error{Position: p.peek(0).Position, Message: err.Error()})
+	}
+}
+
+func (p *parser) full() bool {
+	return len(*p.errors) >= maxErrors
+}
+
+// synchronize skips to the start of the next assignment, or to IMPORTS or
+// END, after an error in the middle of something else.
+func (p *parser) synchronize() {
+	depth := 0
+	for {
+		tok := p.peek(0)
+		switch {
+		case tok.Kind == TokenEOF:
+			return
+		case tok.Kind == TokenSymbol && tok.Text == "{":
+			depth++
+		case tok.Kind == TokenSymbol && tok.Text == "}":
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && (p.is(End) || p.is(Imports) || p.atAssignment()):
+			return
+		}
+		p.next()
+	}
+}
+
+// atAssignment reports whether an assignment appears to start at the
+// current token: "Name ::=", "name Governor ::=" or "Name { ... } ::=".
+func (p *parser) atAssignment() bool {
+	tok := p.peek(0)
+	if tok.Kind != TokenTypeReference && tok.Kind != TokenIdentifier {
+		return false
+	}
+	if p.isAt(1, "::=") {
+		return true
+	}
+	if next := p.peek(1); next.Kind == TokenTypeReference || next.Kind == TokenKeyword {
+		return p.isAt(2, "::=")
+	}
+	if tok.Kind != TokenTypeReference || !p.isAt(1, "{") {
+		return false
+	}
+	depth := 0
+	for n := 1; ; n++ {
+		next := p.peek(n)
+		switch {
+		case next.Kind == TokenEOF:
+			return false
+		case next.Kind == TokenSymbol && next.Text == "{":
+			depth++
+		case next.Kind == TokenSymbol && next.Text == "}":
+			depth--
+			if depth == 0 {
+				return p.isAt(n+1, "::=")
+			}
+		}
+	}
+}
+
+// skipListItem skips to the ',' or '}' that ends the current item of a
+// braced list, after an error in the middle of it.
+func (p *parser) skipListItem() {
+	depth := 0
+	for {
+		tok := p.peek(0)
+		if tok.Kind == TokenEOF {
+			return
+		}
+		if tok.Kind == TokenSymbol {
+			switch tok.Text {
+			case ",", "}":
+				if depth == 0 {
+					return
+				}
+				if tok.Text == "}" {
+					depth--
+				}
+			case "{", "(", "[":
+				depth++
+			case ")", "]":
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+		p.next()
+	}
+}
+
+func (p *parser) unexpected(expected string) error {
+	tok := p.peek(0)
+	return p.errorf(tok.Position, "expected %s, found %s", expected, tok)
+}
+
+func (p *parser) expect(text string) (Token, error) {
+	if !p.is(text) {
+		return p.peek(0), p.unexpected("'" + text + "'")
+	}
+	return p.next(), nil
+}
+
+func (p *parser) expectKind(kind TokenKind) (Token, error) {
+	if p.peek(0).Kind != kind {
+		return p.peek(0), p.unexpected(kind.String())
+	}
+	return p.next(), nil
+}
+
+// scanAssignments looks ahead over the module body and records which
+// references are assigned, and which of those name object classes. The
+// grammar needs this to tell objects from values and object sets from value
+// sets, which are otherwise written the same way.
+func (p *parser) scanAssignments() {
+	var (
+		start   = p.index
+		depth   = 0
+		aliases = make(map[string]string)
+	)
+	for i := start; i < len(p.tokens); i++ {
+		tok := p.tokens[i]
+		if tok.Kind == TokenEOF || (depth == 0 && tok.Kind == TokenKeyword && tok.Text == End) {
+			break
+		}
+		if tok.Kind == TokenSymbol {
+			switch tok.Text {
+			case "{":
+				depth++
+			case "}":
+				depth--
+			}
+			continue
+		}
+		if depth != 0 || tok.Kind != TokenTypeReference {
+			continue
+		}
+		if i+3 >= len(p.tokens) || p.tokens[i+1].Text != "::=" {
+			continue
+		}
+		// In "Name Governor ::=" the reference before "::=" is not the one
+		// assigned, unless it ends the previous assignment.
+		if prev := p.tokens[i-1]; i-2 >= start && (prev.Kind == TokenTypeReference || prev.Kind == TokenIdentifier) {
+			if text := p.tokens[i-2].Text; text != "::=" && text != "." {
+				continue
+			}
+		}
+		rhs := p.tokens[i+2]
+		switch {
+		case rhs.Kind == TokenKeyword && rhs.Text == Class:
+			p.classes[tok.Text] = true
+		case rhs.Kind == TokenKeyword && (rhs.Text == TypeIdentifier || rhs.Text == AbstractSyntax):
+			if p.tokens[i+3].Text != "." {
+				p.classes[tok.Text] = true
+			} else {
+				p.defined[tok.Text] = true
+			}
+		case rhs.Kind == TokenTypeReference && p.tokens[i+3].Text != "." && p.tokens[i+3].Text != "{" && p.tokens[i+3].Text != "(":
+			aliases[tok.Text] = rhs.Text
+		default:
+			p.defined[tok.Text] = true
+		}
+	}
+	// An alias names a class when the aliases it leads through end at one.
+	// When they end at a reference not assigned here the linker decides,
+	// and until then the reference is taken for what it looks like.
+	for name, target := range aliases {
+		for seen := map[string]bool{name: true}; !seen[target]; {
+			next, ok := aliases[target]
+			if !ok {
+				break
+			}
+			seen[target] = true
+			target = next
+		}
+		_, cycle := aliases[target]
+		switch {
+		case p.classes[target]:
+			p.classes[name] = true
+		case cycle || p.defined[target]:
+			p.defined[name] = true
+		default:
+			p.deferred[name] = true
+			if p.isClassReference(target) {
+				p.classes[name] = true
+			} else {
+				p.defined[name] = true
+			}
+		}
+	}
+}
+
+func (p *parser) parseModuleDefinition() (*ModuleDefinition, error) {
+	name, err := p.expectKind(TokenTypeReference)
+	if nil != err {
+		return nil, err
+	}
+	module := &ModuleDefinition{Position: name.Position, Name: name.Text}
+	p.module = module
+	p.classes = make(map[string]bool)
+	p.defined = make(map[string]bool)
+	p.deferred = make(map[string]bool)
+	if p.is("{") {
+		module.Identifier, err = p.parseObjectIdentifierValue()
+		if nil != err {
+			return nil, err
+		}
+		if p.peek(0).Kind == TokenCString {
+			module.IRI = p.next().Text
+		}
+	}
+	if _, err := p.expect(Definitions); nil != err {
+		return nil, err
+	}
+	if p.peek(0).Kind == TokenTypeReference && p.isAt(1, Instructions) {
+		p.index += 2
+	}
+	tagged := true
+	switch {
+	case p.accept(Explicit):
+		module.TagDefault = ExplicitTags
+	case p.accept(Implicit):
+		module.TagDefault = ImplicitTags
+	case p.accept(Automatic):
+		module.TagDefault = AutomaticTags
+	default:
+		tagged = false
+	}
+	if tagged {
+		if _, err := p.expect(Tags); nil != err {
+			return nil, err
+		}
+	}
+	if p.accept(Extensibility) {
+		if _, err := p.expect(Implied); nil != err {
+			return nil, err
+		}
+		module.ExtensibilityImplied = true
+	}
+	if _, err := p.expect("::="); nil != err {
+		return nil, err
+	}
+	if _, err := p.expect(Begin); nil != err {
+		return nil, err
+	}
+	p.scanAssignments()
+	if p.is(Exports) {
+		module.Exports, err = p.parseExports()
+		if nil != err {
+			p.report(err)
+			p.synchronize()
+		}
+	}
+	if p.is(Imports) {
+		module.Imports, err = p.parseImports()
+		if nil != err {
+			p.report(err)
+			p.synchronize()
+		}
+	}
+	for !p.is(End) {
+		if p.peek(0).Kind == TokenEOF {
+			return module, p.unexpected("'" + End + "'")
+		}
+		start := p.index
+		doc := p.leadingComment(p.peek(0))
+		assignment, err := p.parseAssignment()
+		if nil != err {
+			p.report(err)
+			if p.full() {
+				return module, nil
+			}
+			if p.index == start {
+				p.next()
+			}
+			p.synchronize()
+			continue
+		}
+		document(assignment, doc, p.trailingComment())
+		module.Assignments = append(module.Assignments, assignment)
+	}
+	p.next()
+	p.defineObjects(module)
+	return module, nil
+}
+
+func (p *parser) parseSymbol() (*Symbol, error) {
+	tok := p.next()
+	switch tok.Kind {
+	case TokenTypeReference, TokenIdentifier:
+	case TokenKeyword:
+		if tok.Text != TypeIdentifier && tok.Text != AbstractSyntax {
+			return nil, p.errorf(tok.Position, "expected symbol, found %s", tok)
+		}
+	default:
+		return nil, p.errorf(tok.Position, "expected symbol, found %s", tok)
+	}
+	symbol := &Symbol{Position: tok.Position, Name: tok.Text}
+	if p.is("{") && p.isAt(1, "}") {
+		p.index += 2
+		symbol.Parameterized = true
+	}
+	return symbol, nil
+}
+
+func (p *parser) parseExports() (*ExportList, error) {
+	start := p.next()
+	exports := &ExportList{Position: start.Position}
+	if p.accept(All) {
+		exports.All = true
+	} else {
+		for !p.is(";") {
+			symbol, err := p.parseSymbol()
+			if nil != err {
+				return nil, err
+			}
+			exports.Symbols = append(exports.Symbols, symbol)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	if _, err := p.expect(";"); nil != err {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (p *parser) parseImports() ([]*Import, error) {
+	var imports []*Import
+	p.next()
+	for !p.is(";") {
+		var symbols []*Symbol
+		for {
+			symbol, err := p.parseSymbol()
+			if nil != err {
+				return nil, err
+			}
+			symbols = append(symbols, symbol)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if _, err := p.expect(From); nil != err {
+			return nil, err
+		}
+		module, err := p.expectKind(TokenTypeReference)
+		if nil != err {
+			return nil, err
+		}
+		imported := &Import{Position: module.Position, Module: module.Text, Symbols: symbols}
+		// The assigned identifier is optional, and a defined value in its
+		// place is only told apart from the next symbol list by what
+		// follows it.
+		switch {
+		case p.is("{"):
+			imported.Identifier, err = p.parseObjectIdentifierValue()
+		case p.peek(0).Kind == TokenIdentifier && !p.isAt(1, ",") && !p.isAt(1, From) && !p.isAt(1, "{"):
+			imported.Identifier, err = p.parseValue()
+		}
+		if nil != err {
+			return nil, err
+		}
+		if p.is(With) {
+			p.next()
+			if tok := p.next(); tok.Text != "SUCCESSORS" && tok.Text != "DESCENDANTS" {
+				return nil, p.errorf(tok.Position, "expected SUCCESSORS or DESCENDANTS, found %s", tok)
+			}
+		}
+		imports = append(imports, imported)
+	}
+	p.next()
+	return imports, nil
+}
+
+func (p *parser) parseAssignment() (Assignment, error) {
+	first := p.peek(0)
+	switch first.Kind {
+	case TokenTypeReference:
+		p.next()
+		if p.accept("::=") {
+			return p.parseTypeOrClassAssignment(first)
+		}
+		if p.is("{") {
+			return p.parseParameterizedAssignment(first)
+		}
+		if p.isClassAt(0) && p.isAt(1, "::=") {
+			class := p.next().Text
+			p.next()
+			set, err := p.parseObjectSet(class)
+			if nil != err {
+				return nil, err
+			}
+			return &ObjectSetAssignment{Position: first.Position, Name: first.Text, Class: class, Set: set}, nil
+		}
+		governor, err := p.parseType()
+		if nil != err {
+			return nil, err
+		}
+		if _, err := p.expect("::="); nil != err {
+			return nil, err
+		}
+		set, err := p.parseValueSet()
+		if nil != err {
+			return nil, err
+		}
+		return &ValueSetAssignment{Position: first.Position, Name: first.Text, Type: governor, Set: set}, nil
+	case TokenIdentifier:
+		p.next()
+		// Only braces or a reference can be an object, so a class guessed
+		// from how its name looks does not take other values for one.
+		if next := p.peek(2); p.isClassAt(0) && p.isAt(1, "::=") && (p.isAt(2, "{") || next.Kind == TokenIdentifier || next.Kind == TokenTypeReference) {
+			class := p.next().Text
+			p.next()
+			object, err := p.parseObject(class)
+			if nil != err {
+				return nil, err
+			}
+			return &ObjectAssignment{Position: first.Position, Name: first.Text, Class: class, Object: object}, nil
+		}
+		governor, err := p.parseType()
+		if nil != err {
+			return nil, err
+		}
+		if _, err := p.expect("::="); nil != err {
+			return nil, err
+		}
+		value, err := p.parseValueOf(governor)
+		if nil != err {
+			return nil, err
+		}
+		return &ValueAssignment{Position: first.Position, Name: first.Text, Type: governor, Value: value}, nil
+	}
+	return nil, p.unexpected("assignment")
+}
+
+func (p *parser) parseTypeOrClassAssignment(name Token) (Assignment, error) {
+	if p.is(Class) {
+		class, err := p.parseObjectClassDefinition()
+		if nil != err {
+			return nil, err
+		}
+		return &ObjectClassAssignment{Position: name.Position, Name: name.Text, Class: class}, nil
+	}
+	if p.isClassAt(0) && !p.deferred[name.Text] {
+		tok := p.next()
+		class := &ObjectClass{Position: tok.Position, Reference: tok.Text}
+		return &ObjectClassAssignment{Position: name.Position, Name: name.Text, Class: class}, nil
+	}
+	typ, err := p.parseType()
+	if nil != err {
+		return nil, err
+	}
+	assignment := &TypeAssignment{Position: name.Position, Name: name.Text, Type: typ}
+	if _, ok := typ.(*ReferencedType); ok && p.deferred[name.Text] {
+		*p.aliases = append(*p.aliases, &pendingAlias{assignment: assignment, module: p.module})
+	}
+	return assignment, nil
+}
+
+// parseParameterizedAssignment reads "Name { parameters } ::= Type". Only
+// parameterized types are supported, being what protocol specifications use.
+func (p *parser) parseParameterizedAssignment(name Token) (Assignment, error) {
+	parameters, err := p.parseParameters()
+	if nil != err {
+		return nil, err
+	}
+	if _, err := p.expect("::="); nil != err {
+		return nil, err
+	}
+	typ, err := p.parseType()
+	if nil != err {
+		return nil, err
+	}
+	return &TypeAssignment{Position: name.Position, Name: name.Text, Parameters: parameters, Type: typ}, nil
+}
+
+func (p *parser) parseParameters() ([]*Parameter, error) {
+	if _, err := p.expect("{"); nil != err {
+		return nil, err
+	}
+	var parameters []*Parameter
+	for {
+		parameter := &Parameter{Position: p.peek(0).Position}
+		if !p.isAt(1, ",") && !p.isAt(1, "}") {
+			if p.isClassAt(0) && p.isAt(1, ":") {
+				parameter.Class = p.next().Text
+			} else {
+				governor, err := p.parseType()
+				if nil != err {
+					return nil, err
+				}
+				parameter.Governor = governor
+			}
+			if _, err := p.expect(":"); nil != err {
+				return nil, err
+			}
+		}
+		name := p.next()
+		if name.Kind != TokenTypeReference && name.Kind != TokenIdentifier {
+			return nil, p.errorf(name.Position, "expected parameter name, found %s", name)
+		}
+		parameter.Name = name.Text
+		parameters = append(parameters, parameter)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect("}"); nil != err {
+		return nil, err
+	}
+	return parameters, nil
+}
+
+func (p *parser) parseTag() (*Tag, error) {
+	start, err := p.expect("[")
+	if nil != err {
+		return nil, err
+	}
+	tag := &Tag{Position: start.Position}
+	switch {
+	case p.accept(Universal):
+		tag.Class = TagClassUniversal
+	case p.accept(Application):
+		tag.Class = TagClassApplication
+	case p.accept(Private):
+		tag.Class = TagClassPrivate
+	}
+	switch tok := p.peek(0); tok.Kind {
+	case TokenNumber:
+		tag.Number, err = p.parseValue()
+	case TokenIdentifier, TokenTypeReference:
+		tag.Number, err = p.parseDefinedValue()
+	default:
+		err = p.unexpected("tag number")
+	}
+	if nil != err {
+		return nil, err
+	}
+	if _, err := p.expect("]"); nil != err {
+		return nil, err
+	}
+	switch {
+	case p.accept(Implicit):
+		tag.Mode = TagModeImplicit
+	case p.accept(Explicit):
+		tag.Mode = TagModeExplicit
+	}
+	return tag, nil
+}
+
+func (p *parser) parseType() (Type, error) {
+	var (
+		start = p.peek(0)
+		tag   *Tag
+		err   error
+	)
+	if p.is("[") {
+		tag, err = p.parseTag()
+		if nil != err {
+			return nil, err
+		}
+		if p.is("[") {
+			return nil, p.errorf(p.peek(0).Position, "multiple tags on one type are not supported")
+		}
+	}
+	typ, err := p.parseUntaggedType()
+	if nil != err {
+		return nil, err
+	}
+	base := typ.Base()
+	base.Position = start.Position
+	base.Tag = tag
+	for p.is("(") {
+		var constraint *Constraint
+		if field, ok := typ.(*ObjectClassFieldType); ok && p.isAt(1, "{") {
+			constraint, err = p.parseTableConstraint(field.Class)
+		} else {
+			constraint, err = p.parseConstraint()
+		}
+		if nil != err {
+			return nil, err
+		}
+		base.Constraints = append(base.Constraints, constraint)
+	}
+	return typ, nil
+}
+
+// isSimpleBuiltinType reports whether name is a single keyword builtin type
+// taking no further notation.
+func isSimpleBuiltinType(name string) bool {
+	switch name {
+	case Boolean, Null, Real, GeneralizedTime, UTCTime, Time, Date, TimeOfDay, DateTime,
+		Duration, ObjectDescriptor, Externel, RelativeOID, OIDIRI, RelativeOIDIRI:
+		return true
+	}
+	return false
+}
+
+func isRestrictedCharacterStringType(name string) bool {
+	switch name {
+	case BMPString, GeneralString, GraphicString, IA5String, ISO646String, NumericString,
+		PrintableString, TeletexString, T61String, UniversalString, UTF8String,
+		VideotexString, VisibleString:
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseUntaggedType() (Type, error) {
+	var (
+		tok  = p.peek(0)
+		base = TypeBase{Position: tok.Position}
+	)
+	switch tok.Kind {
+	case TokenKeyword:
+		switch {
+		case (tok.Text == TypeIdentifier || tok.Text == AbstractSyntax) && p.isAt(1, "."):
+			return p.parseObjectClassFieldType()
+		case isSimpleBuiltinType(tok.Text):
+			p.next()
+			return &BuiltinType{TypeBase: base, Name: tok.Text}, nil
+		case tok.Text == Embedded:
+			p.next()
+			if _, err := p.expect(PDV); nil != err {
+				return nil, err
+			}
+			return &BuiltinType{TypeBase: base, Name: EmbeddedPDV}, nil
+		case isRestrictedCharacterStringType(tok.Text):
+			p.next()
+			return &BuiltinType{TypeBase: base, Name: tok.Text}, nil
+		case tok.Text == Octet || tok.Text == Character:
+			p.next()
+			if _, err := p.expect(String); nil != err {
+				return nil, err
+			}
+			return &BuiltinType{TypeBase: base, Name: tok.Text + " " + String}, nil
+		case tok.Text == Object:
+			p.next()
+			if _, err := p.expect(Identifier); nil != err {
+				return nil, err
+			}
+			return &BuiltinType{TypeBase: base, Name: ObjectIdentifier}, nil
+		case tok.Text == Integer:
+			p.next()
+			typ := &IntegerType{TypeBase: base}
+			if p.is("{") {
+				numbers, err := p.parseNamedNumberList()
+				if nil != err {
+					return nil, err
+				}
+				typ.NamedNumbers = numbers
+			}
+			return typ, nil
+		case tok.Text == Bit:
+			p.next()
+			if _, err := p.expect(String); nil != err {
+				return nil, err
+			}
+			typ := &BitStringType{TypeBase: base}
+			if p.is("{") {
+				bits, err := p.parseNamedNumberList()
+				if nil != err {
+					return nil, err
+				}
+				typ.NamedBits = bits
+			}
+			return typ, nil
+		case tok.Text == Enumerated:
+			return p.parseEnumeratedType()
+		case tok.Text == Sequence || tok.Text == Set:
+			return p.parseSequenceOrSetType()
+		case tok.Text == Choice:
+			p.next()
+			list, err := p.parseComponentList(true)
+			if nil != err {
+				return nil, err
+			}
+			return &ChoiceType{TypeBase: base, ComponentList: list}, nil
+		}
+	case TokenIdentifier:
+		if !p.isAt(1, "<") {
+			break
+		}
+		p.index += 2
+		typ, err := p.parseType()
+		if nil != err {
+			return nil, err
+		}
+		return &SelectionType{TypeBase: base, Alternative: tok.Text, Type: typ}, nil
+	case TokenTypeReference:
+		if p.isAt(1, ".") && isFieldReference(p.peek(2)) {
+			return p.parseObjectClassFieldType()
+		}
+		if tok.Text == Any && !p.defined[Any] {
+			return p.parseAnyType()
+		}
+		p.next()
+		typ := &ReferencedType{TypeBase: base, Name: tok.Text}
+		if p.is(".") && p.peek(1).Kind == TokenTypeReference {
+			p.next()
+			typ.Module = tok.Text
+			typ.Name = p.next().Text
+		}
+		if p.is("{") {
+			parameters, err := p.parseActualParameters()
+			if nil != err {
+				return nil, err
+			}
+			typ.Parameters = parameters
+		}
+		return typ, nil
+	}
+	return nil, p.unexpected("type")
+}
+
+// parseActualParameters reads the "{ ... }" list of a parameterized
+// reference. What each parameter is depends on the parameterized assignment,
+// so its tokens are kept as written.
+func (p *parser) parseActualParameters() ([]*ActualParameter, error) {
+	if _, err := p.expect("{"); nil != err {
+		return nil, err
+	}
+	var parameters []*ActualParameter
+	for {
+		var (
+			start = p.index
+			depth = 0
+		)
+		for depth != 0 || !(p.is(",") || p.is("}")) {
+			tok := p.next()
+			switch {
+			case tok.Kind == TokenEOF:
+				return nil, p.errorf(tok.Position, "unexpected %s in actual parameter", tok)
+			case tok.Kind == TokenSymbol && (tok.Text == "{" || tok.Text == "("):
+				depth++
+			case tok.Kind == TokenSymbol && (tok.Text == "}" || tok.Text == ")"):
+				depth--
+			}
+		}
+		if start == p.index {
+			return nil, p.unexpected("actual parameter")
+		}
+		parameters = append(parameters, &ActualParameter{
+			Position: p.tokens[start].Position,
+			Tokens:   append([]Token(nil), p.tokens[start:p.index]...),
+		})
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect("}"); nil != err {
+		return nil, err
+	}
+	return parameters, nil
+}
+
+func (p *parser) parseAnyType() (Type, error) {
+	tok := p.next()
+	if p.strict {
+		return nil, p.errorf(tok.Position, "%s is not supported by X.680, use an open type", Any)
+	}
+	typ := &AnyType{TypeBase: TypeBase{Position: tok.Position}}
+	if next := p.peek(0); next.Kind == TokenTypeReference && next.Text == Defined && p.isAt(1, By) {
+		p.index += 2
+		name, err := p.expectKind(TokenIdentifier)
+		if nil != err {
+			return nil, err
+		}
+		typ.DefinedBy = name.Text
+	}
+	return typ, nil
+}
+
+func (p *parser) parseNamedNumberList() ([]*NamedNumber, error) {
+	if _, err := p.expect("{"); nil != err {
+		return nil, err
+	}
+	var numbers []*NamedNumber
+	for {
+		number, err := p.parseNamedNumber(true)
+		if nil != err {
+			return nil, err
+		}
+		numbers = append(numbers, number)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect("}"); nil != err {
+		return nil, err
+	}
+	return numbers, nil
+}
+
+func (p *parser) parseNamedNumber(required bool) (*NamedNumber, error) {
+	name, err := p.expectKind(TokenIdentifier)
+	if nil != err {
+		return nil, err
+	}
+	number := &NamedNumber{Position: name.Position, Name: name.Text}
+	if !p.is("(") {
+		if required {
+			return nil, p.unexpected("'('")
+		}
+		return number, nil
+	}
+	p.next()
+	switch tok := p.peek(0); {
+	case tok.Kind == TokenNumber || (tok.Kind == TokenSymbol && tok.Text == "-"):
+		number.Value, err = p.parseValue()
+	default:
+		number.Value, err = p.parseDefinedValue()
+	}
+	if nil != err {
+		return nil, err
+	}
+	if _, err := p.expect(")"); nil != err {
+		return nil, err
+	}
+	return number, nil
+}
+
+func (p *parser) parseEnumeratedType() (Type, error) {
+	start := p.next()
+	if _, err := p.expect("{"); nil != err {
+		return nil, err
+	}
+	typ := &EnumeratedType{TypeBase: TypeBase{Position: start.Position}}
+	for {
+		if p.is("...") {
+			if typ.Extensible {
+				return nil, p.errorf(p.peek(0).Position, "duplicate extension marker")
+			}
+			p.next()
+			typ.Extensible = true
+			if p.is("!") {
+				exception, err := p.parseException()
+				if nil != err {
+					return nil, err
+				}
+				typ.Exception = exception
+			}
+		} else {
+			item, err := p.parseNamedNumber(false)
+			if nil != err {
+				return nil, err
+			}
+			if typ.Extensible {
+				typ.Additions = append(typ.Additions, item)
+			} else {
+				typ.Items = append(typ.Items, item)
+			}
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect("}"); nil != err {
+		return nil, err
+	}
+	return typ, nil
+}
+
+func (p *parser) parseSequenceOrSetType() (Type, error) {
+	var (
+		start = p.next()
+		base  = TypeBase{Position: start.Position}
+	)
+	if p.is("{") {
+		list, err := p.parseComponentList(false)
+		if nil != err {
+			return nil, err
+		}
+		if start.Text == Set {
+			return &SetType{TypeBase: base, ComponentList: list}, nil
+		}
+		return &SequenceType{TypeBase: base, ComponentList: list}, nil
+	}
+	switch {
+	case p.is("("):
+		constraint, err := p.parseConstraint()
+		if nil != err {
+			return nil, err
+		}
+		base.Constraints = append(base.Constraints, constraint)
+	case p.is(Size):
+		tok := p.next()
+		constraint, err := p.parseConstraint()
+		if nil != err {
+			return nil, err
+		}
+		size := &SizeElement{Position: tok.Position, Constraint: constraint}
+		base.Constraints = append(base.Constraints, &Constraint{
+			Position:        tok.Position,
+			ElementSetSpecs: ElementSetSpecs{Root: size},
+		})
+	}
+	if _, err := p.expect(Of); nil != err {
+		return nil, err
+	}
+	var name string
+	if p.peek(0).Kind == TokenIdentifier {
+		name = p.next().Text
+	}
+	element, err := p.parseType()
+	if nil != err {
+		return nil, err
+	}
+	if start.Text == Set {
+		return &SetOfType{TypeBase: base, ElementName: name, Element: element}, nil
+	}
+	return &SequenceOfType{TypeBase: base, ElementName: name, Element: element}, nil
+}
+
+func (p *parser) parseComponentList(choice bool) (ComponentList, error) {
+	var list ComponentList
+	start, err := p.expect("{")
+	if nil != err {
+		return list, err
+	}
+	if p.accept("}") {
+		if choice {
+			return list, p.errorf(start.Position, "CHOICE must have at least one alternative")
+		}
+		return list, nil
+	}
+	for {
+		switch {
+		case p.is("..."):
+			if list.ExtensionEnd {
+				return list, p.errorf(p.peek(0).Position, "more than two extension markers")
+			}
+			p.next()
+			list.ExtensionEnd = list.Extensible
+			list.Extensible = true
+			if p.is("!") {
+				exception, err := p.parseException()
+				if nil != err {
+					return list, err
+				}
+				list.Exception = exception
+			}
+		case p.is("[") && p.isAt(1, "["):
+			if !list.Extensible || list.ExtensionEnd {
+				return list, p.errorf(p.peek(0).Position, "extension addition group outside the extension")
+			}
+			group, err := p.parseExtensionAdditionGroup(choice)
+			if nil != err {
+				return list, err
+			}
+			list.Additions = append(list.Additions, group)
+		default:
+			component, err := p.parseComponentType(choice)
+			if nil != err {
+				p.report(err)
+				if p.full() {
+					return list, err
+				}
+				p.skipListItem()
+				break
+			}
+			if list.ExtensionEnd {
+				if choice {
+					return list, p.errorf(component.Position, "CHOICE alternatives cannot follow the extension end marker")
+				}
+				list.Trailing = append(list.Trailing, component)
+			} else if list.Extensible {
+				list.Additions = append(list.Additions, &ExtensionAddition{
+					Position:   component.Position,
+					Components: []*ComponentType{component},
+				})
+			} else {
+				list.Components = append(list.Components, component)
+			}
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect("}"); nil != err {
+		return list, err
+	}
+	return list, nil
+}
+
+func (p *parser) parseExtensionAdditionGroup(choice bool) (*ExtensionAddition, error) {
+	start := p.next()
+	p.next()
+	group := &ExtensionAddition{Position: start.Position, Group: true}
+	if p.peek(0).Kind == TokenNumber && p.isAt(1, ":") {
+		tok := p.next()
+		p.next()
+		if _, err := fmt.Sscan(tok.Text, &group.Version); nil != err || group.Version < 2 {
+			return nil, p.errorf(tok.Position, "invalid version number %s", tok.Text)
+		}
+	}
+	for {
+		component, err := p.parseComponentType(choice)
+		if nil != err {
+			return nil, err
+		}
+		group.Components = append(group.Components, component)
+		if !p.accept(",") {
+			break
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := p.expect("]"); nil != err {
+			return nil, err
+		}
+	}
+	return group, nil
+}
+
+func (p *parser) parseComponentType(choice bool) (*ComponentType, error) {
+	var (
+		tok = p.peek(0)
+		doc = p.leadingComment(tok)
+	)
+	if !choice && p.is(Components) && p.isAt(1, Of) {
+		p.index += 2
+		typ, err := p.parseType()
+		if nil != err {
+			return nil, err
+		}
+		return &ComponentType{Position: tok.Position, Type: typ, ComponentsOf: true, Doc: doc, Comment: p.trailingComment()}, nil
+	}
+	name, err := p.expectKind(TokenIdentifier)
+	if nil != err {
+		return nil, err
+	}
+	typ, err := p.parseType()
+	if nil != err {
+		return nil, err
+	}
+	component := &ComponentType{Position: name.Position, Name: name.Text, Type: typ, Doc: doc}
+	switch {
+	case choice:
+	case p.accept(Optional):
+		component.Optional = true
+	case p.accept(Default):
+		component.Default, err = p.parseValueOf(typ)
+		if nil != err {
+			return nil, err
+		}
+	}
+	component.Comment = p.trailingComment()
+	return component, nil
+}
+
+func (p *parser) parseNumber(tok Token, negative bool) (*IntegerValue, error) {
+	number, ok := new(big.Int).SetString(tok.Text, 10)
+	if !ok {
+		return nil, p.errorf(tok.Position, "invalid number %s", tok.Text)
+	}
+	if negative {
+		number.Neg(number)
+	}
+	return &IntegerValue{Position: tok.Position, Value: number}, nil
+}
+
+// packBits packs the digits of a bstring or hstring, each worth width bits,
+// into bytes from the most significant bit on.
+func packBits(digits string, width int) ([]byte, int) {
+	var (
+		length = len(digits) * width
+		bytes  = make([]byte, (length+7)/8)
+	)
+	for i := 0; i < len(digits); i++ {
+		c := digits[i]
+		digit := int(c - '0')
+		if c >= 'A' {
+			digit = int(c-'A') + 10
+		}
+		for j := 0; j < width; j++ {
+			if digit&(1<<(width-1-j)) != 0 {
+				bit := i*width + j
+				bytes[bit/8] |= 0x80 >> (bit % 8)
+			}
+		}
+	}
+	return bytes, length
+}
+
+// parseRealNumber reads a realnumber such as 3.14 or 5e-3 into a base 10
+// RealValue without loss of precision.
+func (p *parser) parseRealNumber(tok Token, negative bool) (*RealValue, error) {
+	var (
+		text     = tok.Text
+		exponent int
+	)
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		if _, err := fmt.Sscan(text[i+1:], &exponent); nil != err {
+			return nil, p.errorf(tok.Position, "invalid real number %s", tok.Text)
+		}
+		text = text[:i]
+	}
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		exponent -= len(text) - i - 1
+		text = text[:i] + text[i+1:]
+	}
+	mantissa, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return nil, p.errorf(tok.Position, "invalid real number %s", tok.Text)
+	}
+	if negative {
+		mantissa.Neg(mantissa)
+	}
+	return &RealValue{Position: tok.Position, Mantissa: mantissa, Base: 10, Exponent: exponent}, nil
+}
+
+// parseValueOf reads a value of the governor type. Only the type tells a
+// REAL written as { mantissa m, base b, exponent e } from a SEQUENCE value.
+func (p *parser) parseValueOf(governor Type) (Value, error) {
+	value, err := p.parseValue()
+	if nil != err {
+		return nil, err
+	}
+	builtin, ok := governor.(*BuiltinType)
+	if !ok || builtin.Name != Real {
+		return value, nil
+	}
+	if sequence, ok := value.(*SequenceValue); ok {
+		return p.realFromSequence(sequence)
+	}
+	return value, nil
+}
+
+func (p *parser) realFromSequence(sequence *SequenceValue) (*RealValue, error) {
+	var (
+		value = &RealValue{Position: sequence.Position}
+		names = []string{"mantissa", "base", "exponent"}
+	)
+	if len(sequence.Components) != len(names) {
+		return nil, p.errorf(sequence.Position, "REAL value must have mantissa, base and exponent")
+	}
+	for i, component := range sequence.Components {
+		number, ok := component.Value.(*IntegerValue)
+		if component.Name != names[i] || !ok {
+			return nil, p.errorf(component.Position, "expected %s number in REAL value", names[i])
+		}
+		switch component.Name {
+		case "mantissa":
+			value.Mantissa = number.Value
+		case "base":
+			if !number.Value.IsInt64() || (number.Value.Int64() != 2 && number.Value.Int64() != 10) {
+				return nil, p.errorf(component.Position, "REAL base must be 2 or 10")
+			}
+			value.Base = int(number.Value.Int64())
+		case "exponent":
+			if !number.Value.IsInt64() || number.Value.BitLen() > 31 {
+				return nil, p.errorf(component.Position, "REAL exponent out of range")
+			}
+			value.Exponent = int(number.Value.Int64())
+		}
+	}
+	return value, nil
+}
+
+func (p *parser) parseDefinedValue() (Value, error) {
+	tok := p.peek(0)
+	switch {
+	case tok.Kind == TokenIdentifier:
+		p.next()
+		return &ReferencedValue{Position: tok.Position, Name: tok.Text}, nil
+	case tok.Kind == TokenTypeReference && p.isAt(1, ".") && p.peek(2).Kind == TokenIdentifier:
+		p.index += 2
+		return &ReferencedValue{Position: tok.Position, Module: tok.Text, Name: p.next().Text}, nil
+	}
+	return nil, p.unexpected("defined value")
+}
+
+func (p *parser) parseValue() (Value, error) {
+	tok := p.peek(0)
+	switch tok.Kind {
+	case TokenNumber:
+		p.next()
+		return p.parseNumber(tok, false)
+	case TokenRealNumber:
+		p.next()
+		return p.parseRealNumber(tok, false)
+	case TokenKeyword:
+		switch tok.Text {
+		case True, False:
+			p.next()
+			return &BooleanValue{Position: tok.Position, Value: tok.Text == True}, nil
+		case Null:
+			p.next()
+			return &NullValue{Position: tok.Position}, nil
+		case PlusInfinity, MinusInfinity, NotANumber:
+			p.next()
+			return &RealValue{Position: tok.Position, Special: tok.Text}, nil
+		}
+	case TokenCString:
+		p.next()
+		return &StringValue{Position: tok.Position, Value: tok.Text}, nil
+	case TokenBString:
+		p.next()
+		bytes, length := packBits(tok.Text, 1)
+		return &BitStringValue{Position: tok.Position, Bytes: bytes, Length: length}, nil
+	case TokenHString:
+		p.next()
+		bytes, length := packBits(tok.Text, 4)
+		return &OctetStringValue{Position: tok.Position, Bytes: bytes, Length: length}, nil
+	case TokenIdentifier:
+		if p.isAt(1, ":") {
+			p.index += 2
+			value, err := p.parseValue()
+			if nil != err {
+				return nil, err
+			}
+			return &ChoiceValue{Position: tok.Position, Name: tok.Text, Value: value}, nil
+		}
+		return p.parseDefinedValue()
+	case TokenTypeReference:
+		return p.parseDefinedValue()
+	case TokenSymbol:
+		switch {
+		case tok.Text == "-" && p.peek(1).Kind == TokenNumber:
+			p.next()
+			value, err := p.parseNumber(p.next(), true)
+			if nil != err {
+				return nil, err
+			}
+			value.Position = tok.Position
+			return value, nil
+		case tok.Text == "-" && p.peek(1).Kind == TokenRealNumber:
+			p.next()
+			value, err := p.parseRealNumber(p.next(), true)
+			if nil != err {
+				return nil, err
+			}
+			value.Position = tok.Position
+			return value, nil
+		case tok.Text == "{":
+			return p.parseBracedValue()
+		}
+	}
+	return nil, p.unexpected("value")
+}
+
+// isObjectIdentifierComponents reports whether the braced value starting at
+// the current "{" consists only of object identifier components, that is
+// numbers, names and name(number) forms without separating commas.
+func (p *parser) isObjectIdentifierComponents() bool {
+	n := 1
+	for !p.isAt(n, "}") {
+		tok := p.peek(n)
+		switch {
+		case tok.Kind == TokenNumber:
+			n++
+		case tok.Kind == TokenIdentifier:
+			n++
+			if p.isAt(n, "(") {
+				if inner := p.peek(n + 1); inner.Kind != TokenNumber && inner.Kind != TokenIdentifier {
+					return false
+				}
+				if !p.isAt(n+2, ")") {
+					return false
+				}
+				n += 3
+			}
+		case tok.Kind == TokenTypeReference && p.isAt(n+1, ".") && p.peek(n+2).Kind == TokenIdentifier:
+			n += 3
+		default:
+			return false
+		}
+	}
+	return n > 1
+}
+
+func (p *parser) parseObjectIdentifierValue() (*ObjectIdentifierValue, error) {
+	start, err := p.expect("{")
+	if nil != err {
+		return nil, err
+	}
+	value := &ObjectIdentifierValue{Position: start.Position}
+	for !p.accept("}") {
+		tok := p.peek(0)
+		component := &ObjectIdentifierComponent{Position: tok.Position}
+		switch {
+		case tok.Kind == TokenNumber:
+			component.Value, err = p.parseValue()
+		case tok.Kind == TokenIdentifier && p.isAt(1, "("):
+			p.index += 2
+			component.Name = tok.Text
+			if p.peek(0).Kind == TokenNumber {
+				component.Value, err = p.parseValue()
+			} else {
+				component.Value, err = p.parseDefinedValue()
+			}
+			if nil == err {
+				_, err = p.expect(")")
+			}
+		case tok.Kind == TokenIdentifier:
+			p.next()
+			component.Name = tok.Text
+		default:
+			component.Value, err = p.parseDefinedValue()
+		}
+		if nil != err {
+			return nil, err
+		}
+		value.Components = append(value.Components, component)
+	}
+	return value, nil
+}
+
+// parseBracedValue parses the value notations written in braces. Without
+// knowing the governing type "{ a b }" might be an object identifier or a
+// single named component; such values are parsed as object identifiers and
+// left for the type checks to reinterpret.
+func (p *parser) parseBracedValue() (Value, error) {
+	if p.isObjectIdentifierComponents() {
+		return p.parseObjectIdentifierValue()
+	}
+	start := p.next()
+	if p.accept("}") {
+		return &SequenceValue{Position: start.Position}, nil
+	}
+	var (
+		named    []*NamedValue
+		elements []Value
+	)
+	for {
+		tok := p.peek(0)
+		if tok.Kind == TokenIdentifier && !p.isAt(1, ",") && !p.isAt(1, "}") && !p.isAt(1, ":") {
+			p.next()
+			value, err := p.parseValue()
+			if nil != err {
+				return nil, err
+			}
+			named = append(named, &NamedValue{Position: tok.Position, Name: tok.Text, Value: value})
+		} else {
+			value, err := p.parseValue()
+			if nil != err {
+				return nil, err
+			}
+			elements = append(elements, value)
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect("}"); nil != err {
+		return nil, err
+	}
+	switch {
+	case len(elements) == 0:
+		return &SequenceValue{Position: start.Position, Components: named}, nil
+	case len(named) == 0:
+		return &SequenceOfValue{Position: start.Position, Elements: elements}, nil
+	}
+	return nil, p.errorf(start.Position, "mixed named and unnamed values in braces")
+}
+
+func (p *parser) parseValueSet() (*ValueSet, error) {
+	start, err := p.expect("{")
+	if nil != err {
+		return nil, err
+	}
+	specs, err := p.parseElementSetSpecs(p.parseSubtypeElement)
+	if nil != err {
+		return nil, err
+	}
+	if _, err := p.expect("}"); nil != err {
+		return nil, err
+	}
+	return &ValueSet{Position: start.Position, ElementSetSpecs: specs}, nil
+}
+
+func (p *parser) parseConstraint() (*Constraint, error) {
+	start, err := p.expect("(")
+	if nil != err {
+		return nil, err
+	}
+	specs, err := p.parseElementSetSpecs(p.parseSubtypeElement)
+	if nil != err {
+		return nil, err
+	}
+	constraint := &Constraint{Position: start.Position, ElementSetSpecs: specs}
+	// Some modules separate the exception from an extension marker by a
+	// comma, which is accepted.
+	if p.is(",") && p.isAt(1, "!") {
+		p.next()
+	}
+	if p.is("!") {
+		constraint.Exception, err = p.parseException()
+		if nil != err {
+			return nil, err
+		}
+	}
+	if _, err := p.expect(")"); nil != err {
+		return nil, err
+	}
+	return constraint, nil
+}
+
+// parseException reads an exception specification, whose tokens run up to
+// the end of the enclosing constraint or list item.
+func (p *parser) parseException() (*Exception, error) {
+	start, err := p.expect("!")
+	if nil != err {
+		return nil, err
+	}
+	var (
+		exception = &Exception{Position: start.Position}
+		depth     = 0
+	)
+	for depth != 0 || !(p.is(",") || p.is(")") || p.is("}")) {
+		tok := p.next()
+		switch {
+		case tok.Kind == TokenEOF:
+			return nil, p.errorf(tok.Position, "unexpected %s in exception specification", tok)
+		case tok.Kind == TokenSymbol && (tok.Text == "{" || tok.Text == "("):
+			depth++
+		case tok.Kind == TokenSymbol && (tok.Text == "}" || tok.Text == ")"):
+			depth--
+		}
+		exception.Tokens = append(exception.Tokens, tok)
+	}
+	if len(exception.Tokens) == 0 {
+		return nil, p.unexpected("exception identification")
+	}
+	return exception, nil
+}
+
+// The element set grammar is shared by constraints, value sets and object
+// sets, which only differ in the elements parsed by elementFunc.
+type elementFunc func() (Element, error)
+
+func (p *parser) parseElementSetSpecs(parse elementFunc) (ElementSetSpecs, error) {
+	var specs ElementSetSpecs
+	if p.accept("...") {
+		specs.Extensible = true
+	} else {
+		root, err := p.parseElementSet(parse)
+		if nil != err {
+			return specs, err
+		}
+		specs.Root = root
+		if !p.is(",") || !p.isAt(1, "...") {
+			return specs, nil
+		}
+		p.index += 2
+		specs.Extensible = true
+	}
+	if p.is(",") && !p.isAt(1, "!") {
+		p.next()
+		additional, err := p.parseElementSet(parse)
+		if nil != err {
+			return specs, err
+		}
+		specs.Additional = additional
+	}
+	return specs, nil
+}
+
+func (p *parser) parseElementSet(parse elementFunc) (Element, error) {
+	if p.is(All) {
+		start := p.next()
+		if _, err := p.expect(Except); nil != err {
+			return nil, err
+		}
+		except, err := p.parseElements(parse)
+		if nil != err {
+			return nil, err
+		}
+		return &ExclusionElement{Position: start.Position, Except: except}, nil
+	}
+	return p.parseUnions(parse)
+}
+
+func (p *parser) parseUnions(parse elementFunc) (Element, error) {
+	first, err := p.parseIntersections(parse)
+	if nil != err {
+		return nil, err
+	}
+	elements := []Element{first}
+	for p.accept("|") || p.accept(Union) {
+		element, err := p.parseIntersections(parse)
+		if nil != err {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	if len(elements) == 1 {
+		return first, nil
+	}
+	return &UnionElement{Position: first.Pos(), Elements: elements}, nil
+}
+
+func (p *parser) parseIntersections(parse elementFunc) (Element, error) {
+	first, err := p.parseIntersectionElements(parse)
+	if nil != err {
+		return nil, err
+	}
+	elements := []Element{first}
+	for p.accept("^") || p.accept(Intersection) {
+		element, err := p.parseIntersectionElements(parse)
+		if nil != err {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	if len(elements) == 1 {
+		return first, nil
+	}
+	return &IntersectionElement{Position: first.Pos(), Elements: elements}, nil
+}
+
+func (p *parser) parseIntersectionElements(parse elementFunc) (Element, error) {
+	element, err := p.parseElements(parse)
+	if nil != err {
+		return nil, err
+	}
+	if !p.accept(Except) {
+		return element, nil
+	}
+	except, err := p.parseElements(parse)
+	if nil != err {
+		return nil, err
+	}
+	return &ExclusionElement{Position: element.Pos(), Element: element, Except: except}, nil
+}
+
+func (p *parser) parseElements(parse elementFunc) (Element, error) {
+	if p.accept("(") {
+		element, err := p.parseElementSet(parse)
+		if nil != err {
+			return nil, err
+		}
+		if _, err := p.expect(")"); nil != err {
+			return nil, err
+		}
+		return element, nil
+	}
+	return parse()
+}
+
+func (p *parser) parseSubtypeElement() (Element, error) {
+	tok := p.peek(0)
+	switch {
+	case p.is(Size), p.is(From):
+		p.next()
+		constraint, err := p.parseConstraint()
+		if nil != err {
+			return nil, err
+		}
+		if tok.Text == Size {
+			return &SizeElement{Position: tok.Position, Constraint: constraint}, nil
+		}
+		return &AlphabetElement{Position: tok.Position, Constraint: constraint}, nil
+	case p.is(With):
+		return p.parseInnerTypeElement()
+	case p.is(Pattern):
+		p.next()
+		value, err := p.parseValue()
+		if nil != err {
+			return nil, err
+		}
+		return &PatternElement{Position: tok.Position, Value: value}, nil
+	case p.is(Includes):
+		p.next()
+		typ, err := p.parseType()
+		if nil != err {
+			return nil, err
+		}
+		return &TypeElement{Position: tok.Position, Includes: true, Type: typ}, nil
+	case p.is(Containing), p.is(Encoded):
+		return p.parseContentsElement()
+	case p.is(Constrained):
+		return p.parseUserDefinedConstraint()
+	case tok.Kind == TokenTypeReference && !(p.isAt(1, ".") && p.peek(2).Kind == TokenIdentifier):
+		typ, err := p.parseType()
+		if nil != err {
+			return nil, err
+		}
+		return &TypeElement{Position: tok.Position, Type: typ}, nil
+	}
+	lower := &RangeEndpoint{Position: tok.Position}
+	if p.is(Min) || p.is(Max) {
+		lower.Limit = p.next().Text
+	} else {
+		value, err := p.parseValue()
+		if nil != err {
+			return nil, err
+		}
+		if !p.is("<") && !p.is("..") {
+			return &ValueElement{Position: tok.Position, Value: value}, nil
+		}
+		lower.Value = value
+	}
+	lower.Open = p.accept("<")
+	if _, err := p.expect(".."); nil != err {
+		return nil, err
+	}
+	upper := &RangeEndpoint{Position: p.peek(0).Position, Open: p.accept("<")}
+	if p.is(Min) || p.is(Max) {
+		upper.Limit = p.next().Text
+	} else {
+		value, err := p.parseValue()
+		if nil != err {
+			return nil, err
+		}
+		upper.Value = value
+	}
+	return &RangeElement{Position: tok.Position, Lower: lower, Upper: upper}, nil
+}
+
+func (p *parser) parseContentsElement() (Element, error) {
+	element := &ContentsElement{Position: p.peek(0).Position}
+	if p.accept(Containing) {
+		typ, err := p.parseType()
+		if nil != err {
+			return nil, err
+		}
+		element.Type = typ
+	}
+	if p.accept(Encoded) {
+		if _, err := p.expect(By); nil != err {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if nil != err {
+			return nil, err
+		}
+		element.EncodedBy = value
+	} else if nil == element.Type {
+		return nil, p.unexpected("'" + Encoded + "'")
+	}
+	return element, nil
+}
+
+func (p *parser) parseUserDefinedConstraint() (Element, error) {
+	start := p.next()
+	if _, err := p.expect(By); nil != err {
+		return nil, err
+	}
+	element := &UserDefinedConstraint{Position: start.Position}
+	open := p.peek(0)
+	if p.is("{") && p.isAt(1, "}") {
+		p.index += 2
+	} else {
+		parameters, err := p.parseActualParameters()
+		if nil != err {
+			return nil, err
+		}
+		element.Parameters = parameters
+	}
+	element.Comment = p.innerComment(open, p.tokens[p.index-1])
+	return element, nil
+}
+
+func (p *parser) parseInnerTypeElement() (Element, error) {
+	start := p.next()
+	element := &InnerTypeElement{Position: start.Position}
+	if p.accept(Component) {
+		constraint, err := p.parseConstraint()
+		if nil != err {
+			return nil, err
+		}
+		element.Component = constraint
+		return element, nil
+	}
+	if _, err := p.expect(Components); nil != err {
+		return nil, err
+	}
+	if _, err := p.expect("{"); nil != err {
+		return nil, err
+	}
+	if p.is("...") && p.isAt(1, ",") {
+		p.index += 2
+		element.Partial = true
+	}
+	for {
+		name, err := p.expectKind(TokenIdentifier)
+		if nil != err {
+			return nil, err
+		}
+		named := &NamedConstraint{Position: name.Position, Name: name.Text}
+		if p.is("(") {
+			named.Constraint, err = p.parseConstraint()
+			if nil != err {
+				return nil, err
+			}
+		}
+		if p.is(Present) || p.is(Absent) || p.is(Optional) {
+			named.Presence = p.next().Text
+		}
+		element.Components = append(element.Components, named)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect("}"); nil != err {
+		return nil, err
 	}
-	data = RemoveComments(data)
-	fmt.Println(string(data))
-	return nil
+	return element, nil
 }
@@ -0,0 +1,668 @@
+package asn1c_go
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseErrors(t *testing.T) {
+	set, err := ParseBytes("errors.asn", []byte(`Errors DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+A ::= INTEGER (1..
+B ::= BOOLEAN
+C ::= SEQUENCE {
+	a INTEGER,
+	b ,
+	c BOOLEAN
+}
+D ::= IA5String
+e INTEGER ::= 5 #
+F ::= OCTET STRING
+END`))
+	want := []string{
+		"errors.asn:3:1: expected defined value, found 'B'",
+		"errors.asn:6:4: expected type, found ','",
+		"errors.asn:10:17: unexpected character '#'",
+	}
+	if got := messages(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	var names []string
+	for _, assignment := range set.Modules[0].Assignments {
+		names = append(names, assignment.Reference())
+	}
+	if want := []string{"B", "C", "D", "e", "F"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got assignments %q, want %q", names, want)
+	}
+}
+
+// objectText returns the fields of o, a definition, as &name=setting in
+// order, or else the object o refers to.
+func objectText(o *InformationObject) string {
+	if len(o.Reference) != 0 {
+		return "object " + o.Reference
+	}
+	var fields []string
+	for _, field := range o.Fields {
+		setting := fmt.Sprintf("%T", field.Setting)
+		switch {
+		case nil != field.Setting.Type:
+			setting = fmt.Sprintf("%T", field.Setting.Type)
+			if ref, ok := field.Setting.Type.(*ReferencedType); ok {
+				setting = ref.Name
+			}
+		case nil != field.Setting.Value:
+			setting = fmt.Sprintf("%T", field.Setting.Value)
+			if ref, ok := field.Setting.Value.(*ReferencedValue); ok {
+				setting = ref.Name
+			}
+		}
+		fields = append(fields, field.Name+"="+setting)
+	}
+	return "{" + strings.Join(fields, " ") + "}"
+}
+
+// elementsText returns the objects and object sets of e, a union of them.
+func elementsText(e Element) []string {
+	var texts []string
+	switch e := e.(type) {
+	case *UnionElement:
+		for _, element := range e.Elements {
+			texts = append(texts, elementsText(element)...)
+		}
+	case *ObjectElement:
+		texts = append(texts, objectText(e.Object))
+	case *ObjectSetElement:
+		texts = append(texts, "set "+e.Name)
+	case nil:
+	default:
+		texts = append(texts, fmt.Sprintf("%T", e))
+	}
+	return texts
+}
+
+func TestParseObjects(t *testing.T) {
+	// From NGAP-PDU-Contents and NGAP-Containers of 3GPP TS 38.413, with a
+	// named object and a set of common IEs added.
+	set, err := ParseBytes("ngap.asn", []byte(`NGAP-PDU-Contents DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+NGAP-PROTOCOL-IES ::= CLASS {
+	&id				ProtocolIE-ID			UNIQUE,
+	&criticality	Criticality,
+	&Value,
+	&presence		Presence
+}
+WITH SYNTAX {
+	ID				&id
+	CRITICALITY		&criticality
+	TYPE			&Value
+	PRESENCE		&presence
+}
+
+ie-AMF-UE-NGAP-ID NGAP-PROTOCOL-IES ::= { ID id-AMF-UE-NGAP-ID CRITICALITY reject TYPE AMF-UE-NGAP-ID PRESENCE mandatory }
+
+CommonIEs NGAP-PROTOCOL-IES ::= {
+	ie-AMF-UE-NGAP-ID |
+	{ ID id-RAN-UE-NGAP-ID						CRITICALITY reject	TYPE RAN-UE-NGAP-ID							PRESENCE mandatory		}
+}
+
+PDUSessionResourceSetupRequestIEs NGAP-PROTOCOL-IES ::= {
+	CommonIEs |
+	{ ID id-RANPagingPriority					CRITICALITY ignore	TYPE RANPagingPriority						PRESENCE optional		}|
+	{ ID id-NAS-PDU								CRITICALITY reject	TYPE NAS-PDU								PRESENCE optional		}|
+	{ ID id-PDUSessionResourceSetupListSUReq	CRITICALITY reject	TYPE PDUSessionResourceSetupListSUReq		PRESENCE mandatory		}|
+	{ ID id-UEAggregateMaximumBitRate			CRITICALITY ignore	TYPE UEAggregateMaximumBitRate				PRESENCE optional		},
+	...
+}
+
+MoreIEs NGAP-PROTOCOL-IES ::= { CommonIEs, ..., ie-AMF-UE-NGAP-ID }
+END`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	assignments := map[string]Assignment{}
+	for _, assignment := range set.Modules[0].Assignments {
+		assignments[assignment.Reference()] = assignment
+	}
+
+	object, ok := assignments["ie-AMF-UE-NGAP-ID"].(*ObjectAssignment)
+	if !ok {
+		t.Fatalf("ie-AMF-UE-NGAP-ID is a %T", assignments["ie-AMF-UE-NGAP-ID"])
+	}
+	if got, want := objectText(object.Object), "{&id=id-AMF-UE-NGAP-ID &criticality=reject &Value=AMF-UE-NGAP-ID &presence=mandatory}"; object.Class != "NGAP-PROTOCOL-IES" || got != want {
+		t.Errorf("ie-AMF-UE-NGAP-ID of %s: got %s, want %s", object.Class, got, want)
+	}
+
+	tests := []struct {
+		name       string
+		root       []string
+		extensible bool
+		additional []string
+	}{
+		{
+			name: "CommonIEs",
+			root: []string{
+				"object ie-AMF-UE-NGAP-ID",
+				"{&id=id-RAN-UE-NGAP-ID &criticality=reject &Value=RAN-UE-NGAP-ID &presence=mandatory}",
+			},
+		},
+		{
+			name: "PDUSessionResourceSetupRequestIEs",
+			root: []string{
+				"set CommonIEs",
+				"{&id=id-RANPagingPriority &criticality=ignore &Value=RANPagingPriority &presence=optional}",
+				"{&id=id-NAS-PDU &criticality=reject &Value=NAS-PDU &presence=optional}",
+				"{&id=id-PDUSessionResourceSetupListSUReq &criticality=reject &Value=PDUSessionResourceSetupListSUReq &presence=mandatory}",
+				"{&id=id-UEAggregateMaximumBitRate &criticality=ignore &Value=UEAggregateMaximumBitRate &presence=optional}",
+			},
+			extensible: true,
+		},
+		{
+			name:       "MoreIEs",
+			root:       []string{"set CommonIEs"},
+			extensible: true,
+			additional: []string{"object ie-AMF-UE-NGAP-ID"},
+		},
+	}
+	for _, test := range tests {
+		a, ok := assignments[test.name].(*ObjectSetAssignment)
+		if !ok {
+			t.Errorf("%s is a %T", test.name, assignments[test.name])
+			continue
+		}
+		if a.Class != "NGAP-PROTOCOL-IES" {
+			t.Errorf("%s is of %s", test.name, a.Class)
+		}
+		if got := elementsText(a.Set.Root); !reflect.DeepEqual(got, test.root) {
+			t.Errorf("%s: got root %q, want %q", test.name, got, test.root)
+		}
+		if a.Set.Extensible != test.extensible {
+			t.Errorf("%s: extensible %v, want %v", test.name, a.Set.Extensible, test.extensible)
+		}
+		if got := elementsText(a.Set.Additional); !reflect.DeepEqual(got, test.additional) {
+			t.Errorf("%s: got additions %q, want %q", test.name, got, test.additional)
+		}
+	}
+}
+
+func TestParseAny(t *testing.T) {
+	// AlgorithmIdentifier as the 1988 syntax of RFC 5280 writes it.
+	source := []byte(`PKIX1Explicit88 DEFINITIONS EXPLICIT TAGS ::= BEGIN
+AlgorithmIdentifier ::= SEQUENCE {
+	algorithm		OBJECT IDENTIFIER,
+	parameters		ANY DEFINED BY algorithm OPTIONAL }
+AttributeValue ::= ANY
+END`)
+	set, err := ParseBytes("pkix.asn", source)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err := Check(set.Modules[0]); nil != err {
+		t.Fatal(err)
+	}
+	types := map[string]Type{}
+	for _, assignment := range set.Modules[0].Assignments {
+		types[assignment.Reference()] = assignment.(*TypeAssignment).Type
+	}
+	parameters := types["AlgorithmIdentifier"].(*SequenceType).RootComponents()[1]
+	if any, ok := parameters.Type.(*AnyType); !ok || any.DefinedBy != "algorithm" || !parameters.Optional {
+		t.Errorf("parameters is a %T, optional %v, want ANY DEFINED BY algorithm OPTIONAL", parameters.Type, parameters.Optional)
+	}
+	if any, ok := types["AttributeValue"].(*AnyType); !ok || len(any.DefinedBy) != 0 {
+		t.Errorf("AttributeValue is %#v, want ANY", types["AttributeValue"])
+	}
+
+	_, err = ParseBytes("pkix.asn", source, Strict())
+	want := []string{
+		"pkix.asn:4:14: ANY is not supported by X.680, use an open type",
+		"pkix.asn:5:20: ANY is not supported by X.680, use an open type",
+	}
+	if got := messages(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Strict: got %q, want %q", got, want)
+	}
+}
+
+func TestParseSources(t *testing.T) {
+	const source = "M DEFINITIONS ::= BEGIN\nT ::= INTEGER\nU ::= BOOLEAN ,\nEND\n"
+	filename := filepath.Join(t.TempDir(), "m.asn")
+	if err := ioutil.WriteFile(filename, []byte(source), 0644); nil != err {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		parse func() (*ModuleSet, error)
+		error string
+	}{
+		{"Parse", func() (*ModuleSet, error) { return Parse(filename) }, filename + ":3:15: expected assignment, found ','"},
+		{"ParseBytes", func() (*ModuleSet, error) { return ParseBytes("bytes.asn", []byte(source)) }, "bytes.asn:3:15: expected assignment, found ','"},
+		{"ParseReader", func() (*ModuleSet, error) { return ParseReader("reader.asn", strings.NewReader(source)) }, "reader.asn:3:15: expected assignment, found ','"},
+	}
+	for _, test := range tests {
+		set, err := test.parse()
+		list, ok := err.(ErrorList)
+		if !ok || len(list) != 1 || list[0].Error() != test.error {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.error)
+		}
+		if nil == set || len(set.Modules) != 1 || set.Modules[0].Name != "M" {
+			t.Errorf("%s: got %#v, want module M", test.name, set)
+			continue
+		}
+		var names []string
+		for _, assignment := range set.Modules[0].Assignments {
+			names = append(names, assignment.Reference())
+		}
+		if want := []string{"T", "U"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("%s: got assignments %q, want %q", test.name, names, want)
+		}
+	}
+
+	failing := errors.New("read failed")
+	if set, err := ParseReader("failing.asn", iotest.ErrReader(failing)); nil != set || err != failing {
+		t.Errorf("ParseReader of a failing reader: got %v, %v, want nil, %v", set, err, failing)
+	}
+	missing := filepath.Join(t.TempDir(), "missing.asn")
+	if set, err := Parse(missing); nil != set || !os.IsNotExist(err) {
+		t.Errorf("Parse of a missing file: got %v, %v, want nil and a missing file", set, err)
+	}
+}
+
+// parsedTypes parses source, a module, and returns its types by name.
+func parsedTypes(t *testing.T, source string) map[string]Type {
+	t.Helper()
+	set, err := ParseBytes("m.asn", []byte(source))
+	if nil != err {
+		t.Fatal(err)
+	}
+	types := map[string]Type{}
+	for _, assignment := range set.Modules[0].Assignments {
+		if a, ok := assignment.(*TypeAssignment); ok {
+			types[a.Name] = a.Type
+		}
+	}
+	return types
+}
+
+// elementText writes e back in the notation of constraints, values given
+// by defaultText.
+func elementText(e Element) string {
+	switch e := e.(type) {
+	case nil:
+		return ""
+	case *UnionElement:
+		var elements []string
+		for _, element := range e.Elements {
+			elements = append(elements, elementText(element))
+		}
+		return strings.Join(elements, " | ")
+	case *ValueElement:
+		return defaultText(e.Value)
+	case *RangeElement:
+		endpoint := func(r *RangeEndpoint) string {
+			if nil == r.Value {
+				return r.Limit
+			}
+			return defaultText(r.Value)
+		}
+		text := endpoint(e.Lower)
+		if e.Lower.Open {
+			text += "<"
+		}
+		text += ".."
+		if e.Upper.Open {
+			text += "<"
+		}
+		return text + endpoint(e.Upper)
+	case *SizeElement:
+		return "SIZE (" + constraintText(e.Constraint) + ")"
+	case *AlphabetElement:
+		return "FROM (" + constraintText(e.Constraint) + ")"
+	}
+	return fmt.Sprintf("%T", e)
+}
+
+// constraintText writes c back without its parentheses.
+func constraintText(c *Constraint) string {
+	text := elementText(c.Root)
+	if c.Extensible {
+		text += ", ..."
+		if nil != c.Additional {
+			text += ", " + elementText(c.Additional)
+		}
+	}
+	return text
+}
+
+func TestParseLimitsAndSelections(t *testing.T) {
+	types := parsedTypes(t, `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Negative ::= INTEGER (MIN..0)
+Counter ::= INTEGER (0..MAX)
+Delta ::= INTEGER (MIN..<0 | 1<..MAX, ...)
+Names ::= SEQUENCE (SIZE (1..MAX, ...)) OF IA5String (SIZE (1..32))
+Message ::= CHOICE { request INTEGER (0..255), response BOOLEAN, ... }
+Exchange ::= SEQUENCE { first request < Message, last response < M.Message }
+END`)
+	constraints := map[string]string{
+		"Negative": "MIN..0",
+		"Counter":  "0..MAX",
+		"Delta":    "MIN..<0 | 1<..MAX, ...",
+		"Names":    "SIZE (1..MAX, ...)",
+	}
+	for name, want := range constraints {
+		c := types[name].Base().Constraints
+		if len(c) != 1 {
+			t.Errorf("%s: %d constraints", name, len(c))
+			continue
+		}
+		if got := constraintText(c[0]); got != want {
+			t.Errorf("%s: got (%s), want (%s)", name, got, want)
+		}
+	}
+	lower := types["Negative"].Base().Constraints[0].Root.(*RangeElement).Lower
+	if nil != lower.Value || lower.Limit != "MIN" || lower.Position.Column != 23 {
+		t.Errorf("MIN of Negative: got %+v", lower)
+	}
+
+	tests := []struct {
+		component, alternative, module string
+	}{
+		{"first", "request", ""},
+		{"last", "response", "M"},
+	}
+	components := types["Exchange"].(*SequenceType).RootComponents()
+	for i, test := range tests {
+		selection, ok := components[i].Type.(*SelectionType)
+		if !ok {
+			t.Errorf("%s is a %T", test.component, components[i].Type)
+			continue
+		}
+		ref, ok := selection.Type.(*ReferencedType)
+		if selection.Alternative != test.alternative || !ok || ref.Name != "Message" || ref.Module != test.module {
+			t.Errorf("%s: got %s < %#v", test.component, selection.Alternative, selection.Type)
+		}
+	}
+}
+
+func TestParseBinaryStrings(t *testing.T) {
+	set, err := ParseBytes("m.asn", []byte(`M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+flags BIT STRING ::= '10110'B
+mask BIT STRING ::= '1111 0000
+	101'B
+key OCTET STRING ::= 'A1F'H
+digest OCTET STRING ::= '0123 4567 89AB CDEF'H
+none OCTET STRING ::= ''H
+Header ::= OCTET STRING ('CAFE'H | 'BEEF'H)
+Marker ::= BIT STRING ('0'B | '1'B, ...)
+END`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, assignment := range set.Modules[0].Assignments {
+		switch a := assignment.(type) {
+		case *ValueAssignment:
+			got[a.Name] = defaultText(a.Value)
+		case *TypeAssignment:
+			got[a.Name] = constraintText(a.Type.Base().Constraints[0])
+		}
+	}
+	want := map[string]string{
+		"flags":  "bits b0/5",
+		"mask":   "bits f0a0/11",
+		"key":    "octets a1f0/12",
+		"digest": "octets 0123456789abcdef/64",
+		"none":   "octets /0",
+		"Header": "octets cafe/16 | octets beef/16",
+		"Marker": "bits 00/1 | bits 80/1, ...",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	invalid := []struct {
+		source, want string
+	}{
+		{"'0102'B", "m.asn:1:42: invalid character '2' in binary string"},
+		{"'0a'H", "m.asn:1:42: invalid character 'a' in hexadecimal string"},
+		{"'01'X", "m.asn:1:42: expected 'B' or 'H' after quoted string"},
+		{"'01", "m.asn:1:42: unterminated quoted string"},
+	}
+	for _, test := range invalid {
+		_, err := ParseBytes("m.asn", []byte("M DEFINITIONS ::= BEGIN v BIT STRING ::= "+test.source+"\nEND"))
+		if got := messages(t, err); len(got) == 0 || got[0] != test.want {
+			t.Errorf("%s: got %q, want %q first", test.source, got, test.want)
+		}
+	}
+}
+
+func TestParseBasicValues(t *testing.T) {
+	set, err := ParseBytes("m.asn", []byte(`M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+enabled BOOLEAN ::= TRUE
+disabled BOOLEAN ::= FALSE
+nothing NULL ::= NULL
+pi REAL ::= 3.14
+small REAL ::= -0.5e3
+large REAL ::= 1e10
+whole REAL ::= 2
+exact REAL ::= { mantissa 31415, base 10, exponent -4 }
+half REAL ::= { mantissa -1, base 2, exponent -1 }
+infinity REAL ::= PLUS-INFINITY
+negative-infinity REAL ::= MINUS-INFINITY
+undefined REAL ::= NOT-A-NUMBER
+Ratio ::= REAL (0.0..1.0)
+END`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, assignment := range set.Modules[0].Assignments {
+		switch a := assignment.(type) {
+		case *ValueAssignment:
+			got[a.Name] = defaultText(a.Value)
+		case *TypeAssignment:
+			got[a.Name] = constraintText(a.Type.Base().Constraints[0])
+		}
+	}
+	want := map[string]string{
+		"enabled":           "true",
+		"disabled":          "false",
+		"nothing":           "NULL",
+		"pi":                "314*10^-2",
+		"small":             "-5*10^2",
+		"large":             "1*10^10",
+		"whole":             "2",
+		"exact":             "31415*10^-4",
+		"half":              "-1*2^-1",
+		"infinity":          "PLUS-INFINITY",
+		"negative-infinity": "MINUS-INFINITY",
+		"undefined":         "NOT-A-NUMBER",
+		"Ratio":             "0*10^-1..10*10^-1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	invalid := []struct {
+		value, want string
+	}{
+		{"{ mantissa 1, base 3, exponent 0 }", "m.asn:1:50: REAL base must be 2 or 10"},
+		{"{ mantissa 1, base 10 }", "m.asn:1:36: REAL value must have mantissa, base and exponent"},
+		{"{ mantissa 1, exponent 0, base 10 }", "m.asn:1:50: expected base number in REAL value"},
+		{"{ mantissa 1, base 2, exponent 2147483648 }", "m.asn:1:58: REAL exponent out of range"},
+	}
+	for _, test := range invalid {
+		_, err := ParseBytes("m.asn", []byte("M DEFINITIONS ::= BEGIN v REAL ::= "+test.value+"\nEND"))
+		if got := messages(t, err); len(got) == 0 || got[0] != test.want {
+			t.Errorf("%s: got %q, want %q first", test.value, got, test.want)
+		}
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	set, err := ParseBytes("m.asn", []byte(`M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Settings ::= SEQUENCE {
+	mode		Mode				DEFAULT idle,
+	retries		INTEGER (0..10)		DEFAULT maxRetries,
+	timeout		INTEGER				DEFAULT 30,
+	enabled		BOOLEAN				DEFAULT TRUE,
+	options		Options				DEFAULT {},
+	flags		BIT STRING (SIZE (4))	DEFAULT '0000'B,
+	key			OCTET STRING		DEFAULT 'FF'H,
+	scale		REAL				DEFAULT { mantissa 1, base 2, exponent 0 },
+	oid			OBJECT IDENTIFIER	DEFAULT { 1 2 3 },
+	name		IA5String			DEFAULT "none",
+	point		Point				DEFAULT { x 1, y 2 },
+	optional	NULL				OPTIONAL,
+	...
+}
+Set ::= SET { a INTEGER DEFAULT 1, b BOOLEAN }
+SCALED ::= CLASS { &scale REAL DEFAULT { mantissa 5, base 10, exponent 0 } }
+scaled SCALED ::= { &scale { mantissa 1, base 10, exponent 2 } }
+END`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, assignment := range set.Modules[0].Assignments {
+		switch a := assignment.(type) {
+		case *TypeAssignment:
+			for _, component := range componentList(a.Type).RootComponents() {
+				got[a.Name+"."+component.Name] = defaultText(component.Default)
+			}
+		case *ObjectClassAssignment:
+			got[a.Name] = defaultText(a.Class.Fields[0].Default.Value)
+		case *ObjectAssignment:
+			got[a.Name] = defaultText(a.Object.Field("&scale").Value)
+		}
+	}
+	want := map[string]string{
+		"Settings.mode":     "idle",
+		"Settings.retries":  "maxRetries",
+		"Settings.timeout":  "30",
+		"Settings.enabled":  "true",
+		"Settings.options":  "{}",
+		"Settings.flags":    "bits 00/4",
+		"Settings.key":      "octets ff/8",
+		"Settings.scale":    "1*2^0",
+		"Settings.oid":      "{1 2 3}",
+		"Settings.name":     `"none"`,
+		"Settings.point":    "{x 1, y 2}",
+		"Settings.optional": "nil",
+		"Set.a":             "1",
+		"Set.b":             "nil",
+		"SCALED":            "5*10^0",
+		"scaled":            "1*10^2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	_, err = ParseBytes("m.asn", []byte("M DEFINITIONS ::= BEGIN\nC ::= CHOICE { a INTEGER DEFAULT 1 }\nEND"))
+	if got, want := messages(t, err), []string{"m.asn:2:26: expected '}', found 'DEFAULT'"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DEFAULT in a CHOICE: got %q, want %q", got, want)
+	}
+}
+
+func TestParseBuiltinTypes(t *testing.T) {
+	keywords := []string{
+		"BOOLEAN", "NULL", "REAL", "CHARACTER STRING", "OCTET STRING",
+		"OBJECT IDENTIFIER", "RELATIVE-OID", "OID-IRI", "RELATIVE-OID-IRI",
+		"GeneralizedTime", "UTCTime", "TIME", "DATE", "TIME-OF-DAY",
+		"DATE-TIME", "DURATION", "ObjectDescriptor", "EXTERNAL", "EMBEDDED PDV",
+		"BMPString", "GeneralString", "GraphicString", "IA5String",
+		"ISO646String", "NumericString", "PrintableString", "T61String",
+		"TeletexString", "UniversalString", "UTF8String", "VideotexString",
+		"VisibleString",
+	}
+	var source strings.Builder
+	source.WriteString("M DEFINITIONS AUTOMATIC TAGS ::= BEGIN\nBuiltins ::= SEQUENCE {\n")
+	for i, keyword := range keywords {
+		fmt.Fprintf(&source, "\tc%d %s,\n", i, keyword)
+	}
+	source.WriteString("\tinteger INTEGER, enumerated ENUMERATED { one }, bits BIT STRING\n}\n")
+	source.WriteString("Time-Stamp ::= GeneralizedTime\nDated ::= SEQUENCE { date DATE, stamp Time-Stamp }\nEND")
+	types := parsedTypes(t, source.String())
+
+	components := types["Builtins"].(*SequenceType).RootComponents()
+	for i, keyword := range keywords {
+		if builtin, ok := components[i].Type.(*BuiltinType); !ok || builtin.Name != keyword {
+			t.Errorf("%s: got %#v", keyword, components[i].Type)
+		}
+	}
+	rest := components[len(keywords):]
+	if _, ok := rest[0].Type.(*IntegerType); !ok {
+		t.Errorf("INTEGER: got %T", rest[0].Type)
+	}
+	if _, ok := rest[1].Type.(*EnumeratedType); !ok {
+		t.Errorf("ENUMERATED: got %T", rest[1].Type)
+	}
+	if _, ok := rest[2].Type.(*BitStringType); !ok {
+		t.Errorf("BIT STRING: got %T", rest[2].Type)
+	}
+	if builtin, ok := types["Time-Stamp"].(*BuiltinType); !ok || builtin.Name != GeneralizedTime {
+		t.Errorf("Time-Stamp: got %#v", types["Time-Stamp"])
+	}
+	dated := types["Dated"].(*SequenceType).RootComponents()
+	if ref, ok := dated[1].Type.(*ReferencedType); !ok || ref.Name != "Time-Stamp" {
+		t.Errorf("stamp: got %#v", dated[1].Type)
+	}
+
+	_, err := ParseBytes("m.asn", []byte("M DEFINITIONS ::= BEGIN\nT ::= EMBEDDED STRING\nEND"))
+	if got, want := messages(t, err), []string{"m.asn:2:16: expected 'PDV', found 'STRING'"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EMBEDDED STRING: got %q, want %q", got, want)
+	}
+}
+
+func TestParseModules(t *testing.T) {
+	set, err := ParseBytes("m.asn", []byte(`Classes DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+EXPORTS PROTOCOL-IES, Criticality;
+Criticality ::= ENUMERATED { reject, ignore }
+PROTOCOL-IES ::= CLASS { &id INTEGER UNIQUE, &criticality Criticality, &Value }
+WITH SYNTAX { ID &id CRITICALITY &criticality TYPE &Value }
+END
+
+Contents DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+IMPORTS PROTOCOL-IES, Criticality FROM Classes;
+IEs PROTOCOL-IES ::= { { ID 1 CRITICALITY reject TYPE INTEGER } | { ID 2 CRITICALITY ignore TYPE BOOLEAN }, ... }
+Level ::= Criticality
+END
+
+Unrelated DEFINITIONS ::= BEGIN
+PROTOCOL-IES ::= INTEGER
+END`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, module := range set.Modules {
+		names = append(names, module.Name)
+	}
+	if want := []string{"Classes", "Contents", "Unrelated"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("got modules %q, want %q", names, want)
+	}
+	contents := set.Modules[1]
+	if imported := contents.LookupImport("Criticality"); nil == imported || imported.Module != "Classes" {
+		t.Errorf("Criticality is imported by %#v", imported)
+	}
+	if imported := contents.LookupImport("Level"); nil != imported {
+		t.Errorf("Level is imported by %#v", imported)
+	}
+	ies, ok := contents.Lookup("IEs").(*ObjectSetAssignment)
+	if !ok {
+		t.Fatalf("IEs is a %T", contents.Lookup("IEs"))
+	}
+	want := []string{
+		"{&id=*asn1c_go.IntegerValue &criticality=reject &Value=*asn1c_go.IntegerType}",
+		"{&id=*asn1c_go.IntegerValue &criticality=ignore &Value=*asn1c_go.BuiltinType}",
+	}
+	if got := elementsText(ies.Set.Root); !reflect.DeepEqual(got, want) || !ies.Set.Extensible {
+		t.Errorf("IEs: got %q, extensible %v, want %q", got, ies.Set.Extensible, want)
+	}
+	if a, ok := set.Modules[2].Lookup("PROTOCOL-IES").(*TypeAssignment); !ok || a.Position.Line != 15 {
+		t.Errorf("PROTOCOL-IES of Unrelated is %#v, want a type at line 15", set.Modules[2].Lookup("PROTOCOL-IES"))
+	}
+}
@@ -0,0 +1,1061 @@
+package asn1c_go
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Fprint writes module to w as ASN.1 text in one layout whatever the layout
+// of its source: components one per line, indented by four spaces, and the
+// "::=" of consecutive one-line assignments aligned. The comments that
+// document assignments and components, those following them on their lines
+// and those of user-defined constraints are written back, others are lost.
+// Parsing the text yields the module again, positions aside.
+//
+// An object of a class imported from another module is written in the
+// default syntax, its class being unknown here; Program.Fprint knows it.
+func Fprint(w io.Writer, module *ModuleDefinition) error {
+	return fprint(w, module, []*ModuleDefinition{module})
+}
+
+// Fprint is like the package function, finding the classes of objects
+// through the imports of module.
+func (p *Program) Fprint(w io.Writer, module *ModuleDefinition) error {
+	return fprint(w, module, p.definitions())
+}
+
+func fprint(w io.Writer, module *ModuleDefinition, modules []*ModuleDefinition) error {
+	p := &printer{module: module, modules: modules}
+	p.moduleDefinition()
+	_, err := io.WriteString(w, p.buffer.String())
+	return err
+}
+
+type printer struct {
+	module  *ModuleDefinition
+	modules []*ModuleDefinition
+	buffer  strings.Builder
+	indent  int
+}
+
+func (p *printer) print(texts ...string) {
+	for _, text := range texts {
+		p.buffer.WriteString(text)
+	}
+}
+
+func (p *printer) newline() {
+	p.buffer.WriteByte('\n')
+	p.buffer.WriteString(strings.Repeat("    ", p.indent))
+}
+
+// sub returns a printer at the same indentation writing a buffer of its own.
+func (p *printer) sub() *printer {
+	return &printer{module: p.module, modules: p.modules, indent: p.indent}
+}
+
+func (p *printer) moduleDefinition() {
+	m := p.module
+	p.print(m.Name)
+	if nil != m.Identifier {
+		p.print(" ")
+		p.value(m.Identifier)
+		if len(m.IRI) != 0 {
+			p.print(" ", quote(m.IRI))
+		}
+	}
+	p.newline()
+	p.print(Definitions)
+	if m.TagDefault != ExplicitTags {
+		p.print(" ", m.TagDefault.String())
+	}
+	if m.ExtensibilityImplied {
+		p.print(" ", Extensibility, " ", Implied)
+	}
+	p.print(" ::=")
+	p.newline()
+	p.print(Begin)
+	if nil != m.Exports {
+		p.newline()
+		p.newline()
+		p.exports(m.Exports)
+	}
+	if len(m.Imports) != 0 {
+		p.newline()
+		p.newline()
+		p.imports(m.Imports)
+	}
+	p.assignments(m.Assignments)
+	p.newline()
+	p.newline()
+	p.print(End, "\n")
+}
+
+func (p *printer) exports(exports *ExportList) {
+	p.print(Exports)
+	if exports.All {
+		p.print(" ", All)
+	}
+	for i, symbol := range exports.Symbols {
+		if i > 0 {
+			p.print(",")
+		}
+		p.print(" ")
+		p.symbol(symbol)
+	}
+	p.print(";")
+}
+
+func (p *printer) imports(imports []*Import) {
+	p.print(Imports)
+	p.indent++
+	for _, imported := range imports {
+		for i, symbol := range imported.Symbols {
+			p.newline()
+			p.symbol(symbol)
+			if i < len(imported.Symbols)-1 {
+				p.print(",")
+			}
+		}
+		p.indent++
+		p.newline()
+		p.print(From, " ", imported.Module)
+		if nil != imported.Identifier {
+			p.print(" ")
+			p.value(imported.Identifier)
+		}
+		p.indent--
+	}
+	p.indent--
+	p.print(";")
+}
+
+func (p *printer) symbol(symbol *Symbol) {
+	p.print(symbol.Name)
+	if symbol.Parameterized {
+		p.print("{}")
+	}
+}
+
+// assignments writes runs of one-line assignments with their "::=" aligned,
+// and a blank line between runs and around assignments spanning lines or
+// documented.
+func (p *printer) assignments(assignments []Assignment) {
+	var (
+		texts    = make([][2]string, len(assignments))
+		docs     = make([]string, len(assignments))
+		comments = make([]string, len(assignments))
+	)
+	for i, assignment := range assignments {
+		texts[i][0], texts[i][1], docs[i], comments[i] = p.assignment(assignment)
+	}
+	multiline := func(text [2]string) bool {
+		return strings.Contains(text[0]+text[1], "\n")
+	}
+	for i := 0; i < len(texts); {
+		j := i + 1
+		if !multiline(texts[i]) {
+			for j < len(texts) && !multiline(texts[j]) && len(docs[j]) == 0 {
+				j++
+			}
+		}
+		width := 0
+		for _, text := range texts[i:j] {
+			if len(text[0]) > width {
+				width = len(text[0])
+			}
+		}
+		p.newline()
+		for k, text := range texts[i:j] {
+			p.newline()
+			if len(docs[i+k]) != 0 {
+				p.doc(docs[i+k])
+			}
+			p.print(text[0], strings.Repeat(" ", width-len(text[0])), " ::= ", text[1])
+			if len(comments[i+k]) != 0 {
+				p.print(" ", comment(comments[i+k]))
+			}
+		}
+		i = j
+	}
+}
+
+// assignment returns the text before and after the "::=" of a, its
+// documentation and its trailing comment.
+func (p *printer) assignment(a Assignment) (string, string, string, string) {
+	var (
+		left, right  = p.sub(), p.sub()
+		doc, comment string
+	)
+	switch a := a.(type) {
+	case *TypeAssignment:
+		doc, comment = a.Doc, a.Comment
+		left.print(a.Name)
+		if len(a.Parameters) != 0 {
+			left.print(" ")
+			left.parameters(a.Parameters)
+		}
+		right.typ(a.Type)
+	case *ValueAssignment:
+		doc, comment = a.Doc, a.Comment
+		left.print(a.Name, " ")
+		left.typ(a.Type)
+		right.value(a.Value)
+	case *ValueSetAssignment:
+		doc, comment = a.Doc, a.Comment
+		left.print(a.Name, " ")
+		left.typ(a.Type)
+		right.valueSet(a.Set)
+	case *ObjectClassAssignment:
+		doc, comment = a.Doc, a.Comment
+		left.print(a.Name)
+		right.objectClass(a.Class)
+	case *ObjectAssignment:
+		doc, comment = a.Doc, a.Comment
+		left.print(a.Name, " ", a.Class)
+		right.object(a.Object, a.Class)
+	case *ObjectSetAssignment:
+		doc, comment = a.Doc, a.Comment
+		left.print(a.Name, " ", a.Class)
+		right.objectSet(a.Set, a.Class)
+	}
+	return left.buffer.String(), right.buffer.String(), doc, comment
+}
+
+// doc writes the lines of a leading comment, each followed by a newline.
+func (p *printer) doc(text string) {
+	if strings.Contains(text, "--") {
+		p.print(comment(text))
+		p.newline()
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		p.print(strings.TrimSpace("-- " + line))
+		p.newline()
+	}
+}
+
+// comment returns text as a single comment, a block comment when it spans
+// lines or holds "--".
+func comment(text string) string {
+	if strings.Contains(text, "--") || strings.Contains(text, "\n") {
+		return "/* " + text + " */"
+	}
+	return "-- " + text
+}
+
+// enclosed returns text as a comment that may be followed by more on its
+// line.
+func enclosed(text string) string {
+	if text := comment(text); strings.HasPrefix(text, "--") {
+		return text + " --"
+	}
+	return comment(text)
+}
+
+func (p *printer) parameters(parameters []*Parameter) {
+	p.print("{ ")
+	for i, parameter := range parameters {
+		if i > 0 {
+			p.print(", ")
+		}
+		switch {
+		case nil != parameter.Governor:
+			p.typ(parameter.Governor)
+			p.print(": ")
+		case len(parameter.Class) != 0:
+			p.print(parameter.Class, ": ")
+		}
+		p.print(parameter.Name)
+	}
+	p.print(" }")
+}
+
+func (p *printer) reference(module, name string) {
+	if len(module) != 0 {
+		p.print(module, ".")
+	}
+	p.print(name)
+}
+
+func (p *printer) tag(tag *Tag) {
+	p.print("[")
+	if tag.Class != TagClassContext {
+		p.print(tag.Class.String(), " ")
+	}
+	p.value(tag.Number)
+	p.print("]")
+	if tag.Mode != TagModeDefault {
+		p.print(" ", tag.Mode.String())
+	}
+}
+
+func (p *printer) typ(t Type) {
+	base := t.Base()
+	if nil != base.Tag {
+		p.tag(base.Tag)
+		p.print(" ")
+	}
+	class := ""
+	switch t := t.(type) {
+	case *BuiltinType:
+		p.print(t.Name)
+	case *IntegerType:
+		p.print(Integer)
+		p.namedNumbers(t.NamedNumbers)
+	case *BitStringType:
+		p.print(BitString)
+		p.namedNumbers(t.NamedBits)
+	case *EnumeratedType:
+		p.print(Enumerated, " { ")
+		for i, item := range t.Items {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.namedNumber(item)
+		}
+		if t.Extensible {
+			if len(t.Items) != 0 {
+				p.print(", ")
+			}
+			p.print("...")
+			p.exception(t.Exception)
+		}
+		for _, item := range t.Additions {
+			p.print(", ")
+			p.namedNumber(item)
+		}
+		p.print(" }")
+	case *SequenceType:
+		p.print(Sequence, " ")
+		p.componentList(&t.ComponentList)
+	case *SetType:
+		p.print(Set, " ")
+		p.componentList(&t.ComponentList)
+	case *ChoiceType:
+		p.print(Choice, " ")
+		p.componentList(&t.ComponentList)
+	case *SequenceOfType:
+		p.print(Sequence)
+		p.listOf(base, t.ElementName, t.Element)
+		return
+	case *SetOfType:
+		p.print(Set)
+		p.listOf(base, t.ElementName, t.Element)
+		return
+	case *AnyType:
+		p.print(Any)
+		if len(t.DefinedBy) != 0 {
+			p.print(" ", Defined, " ", By, " ", t.DefinedBy)
+		}
+	case *SelectionType:
+		p.print(t.Alternative, " < ")
+		p.typ(t.Type)
+	case *ReferencedType:
+		p.reference(t.Module, t.Name)
+		if len(t.Parameters) != 0 {
+			p.print(" ")
+			p.actualParameters(t.Parameters)
+		}
+	case *ObjectClassFieldType:
+		p.print(t.Class, ".", strings.Join(t.Field, "."))
+		class = t.Class
+	}
+	for _, constraint := range base.Constraints {
+		p.print(" ")
+		p.constraint(constraint, class)
+	}
+}
+
+// listOf writes the rest of a SEQUENCE OF or SET OF type, whose constraints
+// go before OF, as those after the element type constrain the element.
+func (p *printer) listOf(base *TypeBase, name string, element Type) {
+	for _, constraint := range base.Constraints {
+		p.print(" ")
+		p.constraint(constraint, "")
+	}
+	p.print(" ", Of, " ")
+	if len(name) != 0 {
+		p.print(name, " ")
+	}
+	p.typ(element)
+}
+
+func (p *printer) namedNumbers(numbers []*NamedNumber) {
+	if len(numbers) == 0 {
+		return
+	}
+	p.print(" { ")
+	for i, number := range numbers {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.namedNumber(number)
+	}
+	p.print(" }")
+}
+
+func (p *printer) namedNumber(number *NamedNumber) {
+	p.print(number.Name)
+	if nil != number.Value {
+		p.print("(")
+		p.value(number.Value)
+		p.print(")")
+	}
+}
+
+func (p *printer) componentList(list *ComponentList) {
+	var (
+		width   = componentWidth(list.Components) + 1
+		entries []listEntry
+	)
+	if w := componentWidth(list.Trailing) + 1; w > width {
+		width = w
+	}
+	for _, addition := range list.Additions {
+		if w := componentWidth(addition.Components) + 1; !addition.Group && w > width {
+			width = w
+		}
+	}
+	component := func(component *ComponentType) listEntry {
+		return listEntry{component: component, write: func() { p.componentType(component, width) }}
+	}
+	marker := listEntry{write: func() { p.print("...") }}
+	for _, c := range list.Components {
+		entries = append(entries, component(c))
+	}
+	if list.Extensible {
+		entries = append(entries, listEntry{write: func() {
+			p.print("...")
+			p.exception(list.Exception)
+		}})
+	}
+	for _, addition := range list.Additions {
+		addition := addition
+		if addition.Group {
+			entries = append(entries, listEntry{write: func() { p.extensionAdditionGroup(addition) }})
+			continue
+		}
+		for _, c := range addition.Components {
+			entries = append(entries, component(c))
+		}
+	}
+	if list.ExtensionEnd {
+		entries = append(entries, marker)
+	}
+	for _, c := range list.Trailing {
+		entries = append(entries, component(c))
+	}
+	if len(entries) == 0 {
+		p.print("{}")
+		return
+	}
+	p.print("{")
+	p.entries(entries)
+	p.newline()
+	p.print("}")
+}
+
+func (p *printer) extensionAdditionGroup(group *ExtensionAddition) {
+	p.print("[[")
+	if group.Version != 0 {
+		p.print(" ", strconv.Itoa(group.Version), ":")
+	}
+	var (
+		width   = componentWidth(group.Components) + 1
+		entries []listEntry
+	)
+	for _, component := range group.Components {
+		component := component
+		entries = append(entries, listEntry{component: component, write: func() { p.componentType(component, width) }})
+	}
+	p.entries(entries)
+	p.newline()
+	p.print("]]")
+}
+
+// listEntry is an item of a component list, with the component it writes
+// if there is one.
+type listEntry struct {
+	write     func()
+	component *ComponentType
+}
+
+// entries writes one entry per line, indented, each component with its
+// comments.
+func (p *printer) entries(entries []listEntry) {
+	p.indent++
+	for i, entry := range entries {
+		p.newline()
+		if nil != entry.component && len(entry.component.Doc) != 0 {
+			p.doc(entry.component.Doc)
+		}
+		entry.write()
+		if i < len(entries)-1 {
+			p.print(",")
+		}
+		if nil != entry.component && len(entry.component.Comment) != 0 {
+			p.print(" ", comment(entry.component.Comment))
+		}
+	}
+	p.indent--
+}
+
+// componentWidth is the length of the longest component name.
+func componentWidth(components []*ComponentType) int {
+	width := 0
+	for _, component := range components {
+		if !component.ComponentsOf && len(component.Name) > width {
+			width = len(component.Name)
+		}
+	}
+	return width
+}
+
+func (p *printer) componentType(component *ComponentType, width int) {
+	if component.ComponentsOf {
+		p.print(Components, " ", Of, " ")
+		p.typ(component.Type)
+		return
+	}
+	p.print(component.Name, strings.Repeat(" ", width-len(component.Name)))
+	p.typ(component.Type)
+	switch {
+	case component.Optional:
+		p.print(" ", Optional)
+	case nil != component.Default:
+		p.print(" ", Default, " ")
+		p.value(component.Default)
+	}
+}
+
+func (p *printer) value(v Value) {
+	switch v := v.(type) {
+	case *IntegerValue:
+		p.print(v.Value.String())
+	case *StringValue:
+		p.print(quote(v.Value))
+	case *BooleanValue:
+		if v.Value {
+			p.print(True)
+		} else {
+			p.print(False)
+		}
+	case *NullValue:
+		p.print(Null)
+	case *RealValue:
+		switch {
+		case len(v.Special) != 0:
+			p.print(v.Special)
+		case v.Base == 10:
+			p.print(decimal(v.Mantissa, v.Exponent))
+		default:
+			p.print(fmt.Sprintf("{ mantissa %s, base %d, exponent %d }", v.Mantissa, v.Base, v.Exponent))
+		}
+	case *BitStringValue:
+		var bits strings.Builder
+		for i := 0; i < v.Length; i++ {
+			if v.Bytes[i/8]&(0x80>>(i%8)) != 0 {
+				bits.WriteByte('1')
+			} else {
+				bits.WriteByte('0')
+			}
+		}
+		p.print("'", bits.String(), "'B")
+	case *OctetStringValue:
+		p.print("'", strings.ToUpper(hex.EncodeToString(v.Bytes))[:v.Length/4], "'H")
+	case *ReferencedValue:
+		p.reference(v.Module, v.Name)
+	case *ObjectIdentifierValue:
+		if len(v.Components) == 0 {
+			p.print("{}")
+			return
+		}
+		p.print("{")
+		for _, component := range v.Components {
+			p.print(" ", component.Name)
+			switch {
+			case nil == component.Value:
+			case len(component.Name) != 0:
+				p.print("(")
+				p.value(component.Value)
+				p.print(")")
+			default:
+				p.value(component.Value)
+			}
+		}
+		p.print(" }")
+	case *SequenceValue:
+		if len(v.Components) == 0 {
+			p.print("{}")
+			return
+		}
+		p.print("{ ")
+		for i, component := range v.Components {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(component.Name, " ")
+			p.value(component.Value)
+		}
+		p.print(" }")
+	case *SequenceOfValue:
+		p.print("{ ")
+		for i, element := range v.Elements {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.value(element)
+		}
+		p.print(" }")
+	case *ChoiceValue:
+		p.print(v.Name, ": ")
+		p.value(v.Value)
+	}
+}
+
+// decimal writes mantissa * 10^exponent as a realnumber the lexer reads
+// back into the same mantissa and exponent.
+func decimal(mantissa *big.Int, exponent int) string {
+	if exponent >= 0 {
+		return mantissa.String() + "e" + strconv.Itoa(exponent)
+	}
+	var (
+		digits = new(big.Int).Abs(mantissa).String()
+		sign   = ""
+	)
+	if mantissa.Sign() < 0 {
+		sign = "-"
+	}
+	if len(digits) <= -exponent {
+		digits = strings.Repeat("0", 1-exponent-len(digits)) + digits
+	}
+	point := len(digits) + exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func quote(text string) string {
+	return "\"" + strings.ReplaceAll(text, "\"", "\"\"") + "\""
+}
+
+func (p *printer) actualParameters(parameters []*ActualParameter) {
+	if len(parameters) == 0 {
+		p.print("{}")
+		return
+	}
+	p.print("{ ")
+	p.parameterTokens(parameters)
+	p.print(" }")
+}
+
+func (p *printer) parameterTokens(parameters []*ActualParameter) {
+	for i, parameter := range parameters {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.tokens(parameter.Tokens)
+	}
+}
+
+// tokens writes tokens kept as written, such as actual parameters.
+func (p *printer) tokens(tokens []Token) {
+	for i, tok := range tokens {
+		if i > 0 && !(tok.Kind == TokenSymbol && tok.Text == ",") {
+			p.print(" ")
+		}
+		switch tok.Kind {
+		case TokenCString:
+			p.print(quote(tok.Text))
+		case TokenBString:
+			p.print("'", tok.Text, "'B")
+		case TokenHString:
+			p.print("'", tok.Text, "'H")
+		default:
+			p.print(tok.Text)
+		}
+	}
+}
+
+func (p *printer) valueSet(set *ValueSet) {
+	p.print("{ ")
+	p.elementSetSpecs(set.ElementSetSpecs, "")
+	p.print(" }")
+}
+
+func (p *printer) objectSet(set *ObjectSet, class string) {
+	p.print("{ ")
+	p.elementSetSpecs(set.ElementSetSpecs, class)
+	p.print(" }")
+}
+
+// constraint writes c, class being that of the objects of a table
+// constraint.
+func (p *printer) constraint(c *Constraint, class string) {
+	p.print("(")
+	p.elementSetSpecs(c.ElementSetSpecs, class)
+	p.exception(c.Exception)
+	p.print(")")
+}
+
+func (p *printer) exception(exception *Exception) {
+	if nil != exception {
+		p.print(" !")
+		p.tokens(exception.Tokens)
+	}
+}
+
+func (p *printer) elementSetSpecs(specs ElementSetSpecs, class string) {
+	if nil == specs.Root {
+		p.print("...")
+	} else {
+		p.element(specs.Root, setLevel, class)
+		if specs.Extensible {
+			p.print(", ...")
+		}
+	}
+	if nil != specs.Additional {
+		p.print(", ")
+		p.element(specs.Additional, setLevel, class)
+	}
+}
+
+// The levels of the element set grammar, from a whole set down to an
+// operand of EXCEPT. An element written where a lower level is expected
+// takes parentheses.
+const (
+	setLevel = iota
+	unionLevel
+	intersectionLevel
+	exceptLevel
+)
+
+func elementLevel(e Element) int {
+	switch e := e.(type) {
+	case *UnionElement:
+		return setLevel
+	case *IntersectionElement:
+		return unionLevel
+	case *ExclusionElement:
+		if nil == e.Element {
+			return setLevel
+		}
+		return intersectionLevel
+	}
+	return exceptLevel
+}
+
+func (p *printer) element(e Element, level int, class string) {
+	if elementLevel(e) < level {
+		p.print("(")
+		defer p.print(")")
+	}
+	switch e := e.(type) {
+	case *UnionElement:
+		for i, element := range e.Elements {
+			if i > 0 {
+				p.print(" | ")
+			}
+			p.element(element, unionLevel, class)
+		}
+	case *IntersectionElement:
+		for i, element := range e.Elements {
+			if i > 0 {
+				p.print(" ^ ")
+			}
+			p.element(element, intersectionLevel, class)
+		}
+	case *ExclusionElement:
+		if nil == e.Element {
+			p.print(All)
+		} else {
+			p.element(e.Element, exceptLevel, class)
+		}
+		p.print(" ", Except, " ")
+		p.element(e.Except, exceptLevel, class)
+	case *ValueElement:
+		p.value(e.Value)
+	case *RangeElement:
+		p.rangeEndpoint(e.Lower)
+		if e.Lower.Open {
+			p.print("<")
+		}
+		p.print("..")
+		if e.Upper.Open {
+			p.print("<")
+		}
+		p.rangeEndpoint(e.Upper)
+	case *SizeElement:
+		p.print(Size, " ")
+		p.constraint(e.Constraint, "")
+	case *AlphabetElement:
+		p.print(From, " ")
+		p.constraint(e.Constraint, "")
+	case *TypeElement:
+		if e.Includes {
+			p.print(Includes, " ")
+		}
+		p.typ(e.Type)
+	case *InnerTypeElement:
+		if nil != e.Component {
+			p.print(With, " ", Component, " ")
+			p.constraint(e.Component, "")
+			return
+		}
+		p.print(With, " ", Components, " { ")
+		if e.Partial {
+			p.print("..., ")
+		}
+		for i, named := range e.Components {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(named.Name)
+			if nil != named.Constraint {
+				p.print(" ")
+				p.constraint(named.Constraint, "")
+			}
+			if len(named.Presence) != 0 {
+				p.print(" ", named.Presence)
+			}
+		}
+		p.print(" }")
+	case *PatternElement:
+		p.print(Pattern, " ")
+		p.value(e.Value)
+	case *ContentsElement:
+		if nil != e.Type {
+			p.print(Containing, " ")
+			p.typ(e.Type)
+			if nil != e.EncodedBy {
+				p.print(" ")
+			}
+		}
+		if nil != e.EncodedBy {
+			p.print(Encoded, " ", By, " ")
+			p.value(e.EncodedBy)
+		}
+	case *TableConstraint:
+		p.objectSet(e.Set, class)
+		if len(e.Components) != 0 {
+			p.print("{ ")
+			for i, at := range e.Components {
+				if i > 0 {
+					p.print(", ")
+				}
+				p.print("@", strings.Repeat(".", at.Level), strings.Join(at.Components, "."))
+			}
+			p.print(" }")
+		}
+	case *ObjectElement:
+		p.object(e.Object, class)
+	case *ObjectSetElement:
+		p.reference(e.Module, e.Name)
+	case *UserDefinedConstraint:
+		p.print(Constrained, " ", By, " ")
+		if len(e.Comment) == 0 {
+			p.actualParameters(e.Parameters)
+			break
+		}
+		p.print("{ ")
+		if len(e.Parameters) != 0 {
+			p.parameterTokens(e.Parameters)
+			p.print(" ")
+		}
+		p.print(enclosed(e.Comment), " }")
+	}
+}
+
+func (p *printer) rangeEndpoint(endpoint *RangeEndpoint) {
+	if nil == endpoint.Value {
+		p.print(endpoint.Limit)
+		return
+	}
+	p.value(endpoint.Value)
+}
+
+func (p *printer) objectClass(class *ObjectClass) {
+	if len(class.Reference) != 0 {
+		p.print(class.Reference)
+		return
+	}
+	width := 0
+	for _, field := range class.Fields {
+		if len(field.Name) > width {
+			width = len(field.Name)
+		}
+	}
+	p.print(Class, " {")
+	p.indent++
+	for i, field := range class.Fields {
+		p.newline()
+		p.fieldSpec(field, width)
+		if i < len(class.Fields)-1 {
+			p.print(",")
+		}
+	}
+	p.indent--
+	p.newline()
+	p.print("}")
+	if nil == class.Syntax {
+		return
+	}
+	p.newline()
+	p.print(With, " ", Syntax, " {")
+	p.indent++
+	p.newline()
+	p.syntaxElements(class.Syntax, true)
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+func (p *printer) fieldSpec(field *FieldSpec, width int) {
+	p.print(field.Name)
+	separator := strings.Repeat(" ", width-len(field.Name)+1)
+	word := func(texts ...string) {
+		p.print(separator)
+		p.print(texts...)
+		separator = " "
+	}
+	switch field.Kind {
+	case TypeField:
+	case VariableTypeValueField, VariableTypeValueSetField:
+		word(strings.Join(field.TypeField, "."))
+	case ObjectField, ObjectSetField:
+		word(field.Class)
+	default:
+		word()
+		p.typ(field.Type)
+	}
+	if field.Unique {
+		word(Unique)
+	}
+	switch {
+	case field.Optional:
+		word(Optional)
+	case nil != field.Default:
+		word(Default, " ")
+		p.setting(field.Default, field.Class)
+	}
+}
+
+// syntaxElements writes a WITH SYNTAX list, starting a new line at each
+// literal following a field at the top level.
+func (p *printer) syntaxElements(elements []*SyntaxElement, top bool) {
+	for i, element := range elements {
+		switch {
+		case i == 0, element.Literal == ",":
+		case top && len(element.Literal) != 0 && len(elements[i-1].Literal) == 0:
+			p.newline()
+		default:
+			p.print(" ")
+		}
+		switch {
+		case len(element.Literal) != 0:
+			p.print(element.Literal)
+		case len(element.Field) != 0:
+			p.print(element.Field)
+		default:
+			p.print("[")
+			p.syntaxElements(element.Group, false)
+			p.print("]")
+		}
+	}
+}
+
+func (p *printer) object(object *InformationObject, class string) {
+	switch {
+	case len(object.Reference) != 0:
+		p.reference(object.Module, object.Reference)
+	case nil != object.Body:
+		p.tokens(object.Body)
+	case len(object.Fields) == 0:
+		p.print("{}")
+	default:
+		definition := lookupObjectClass(p.modules, p.module, class)
+		p.print("{ ")
+		if nil == definition || nil == definition.Syntax {
+			for i, field := range object.Fields {
+				if i > 0 {
+					p.print(", ")
+				}
+				p.print(field.Name, " ")
+				p.setting(field.Setting, fieldClass(definition, field.Name))
+			}
+		} else {
+			p.definedSyntax(definition, definition.Syntax, object, new(bool))
+		}
+		p.print(" }")
+	}
+}
+
+// definedSyntax writes the fields of object in the syntax of its class,
+// leaving out optional groups setting none of them. Started is set once a
+// word is written.
+func (p *printer) definedSyntax(class *ObjectClass, elements []*SyntaxElement, object *InformationObject, started *bool) {
+	for _, element := range elements {
+		switch {
+		case len(element.Literal) != 0:
+			if *started && element.Literal != "," {
+				p.print(" ")
+			}
+			p.print(element.Literal)
+		case len(element.Field) != 0:
+			setting := object.Field(element.Field)
+			if nil == setting {
+				continue
+			}
+			if *started {
+				p.print(" ")
+			}
+			p.setting(setting, fieldClass(class, element.Field))
+		default:
+			if sets(element.Group, object) {
+				p.definedSyntax(class, element.Group, object, started)
+			}
+			continue
+		}
+		*started = true
+	}
+}
+
+// sets reports whether object sets a field of the syntax elements.
+func sets(elements []*SyntaxElement, object *InformationObject) bool {
+	for _, element := range elements {
+		if nil != object.Field(element.Field) || sets(element.Group, object) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldClass is the class of the objects an object or object set field of
+// class holds.
+func fieldClass(class *ObjectClass, name string) string {
+	if nil == class {
+		return ""
+	}
+	if field := class.Field(name); nil != field {
+		return field.Class
+	}
+	return ""
+}
+
+func (p *printer) setting(setting *Setting, class string) {
+	switch {
+	case nil != setting.Type:
+		p.typ(setting.Type)
+	case nil != setting.Value:
+		p.value(setting.Value)
+	case nil != setting.ValueSet:
+		p.valueSet(setting.ValueSet)
+	case nil != setting.Object:
+		p.object(setting.Object, class)
+	case nil != setting.ObjectSet:
+		p.objectSet(setting.ObjectSet, class)
+	}
+}
@@ -0,0 +1,122 @@
+package asn1c_go
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// clearPositions zeroes the positions within v, a pointer, for trees read
+// from different texts to compare equal.
+func clearPositions(v reflect.Value) {
+	seen := map[uintptr]bool{}
+	var walk func(reflect.Value)
+	walk = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Ptr:
+			if v.IsNil() || seen[v.Pointer()] {
+				return
+			}
+			seen[v.Pointer()] = true
+			walk(v.Elem())
+		case reflect.Interface:
+			if !v.IsNil() {
+				walk(v.Elem())
+			}
+		case reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				walk(v.Index(i))
+			}
+		case reflect.Struct:
+			if v.Type() == reflect.TypeOf(Position{}) {
+				v.Set(reflect.Zero(v.Type()))
+				return
+			}
+			for i := 0; i < v.NumField(); i++ {
+				if field := v.Field(i); field.CanSet() {
+					walk(field)
+				}
+			}
+		}
+	}
+	walk(v)
+}
+
+// roundTrip prints module, reads the text back and checks that it holds the
+// same module and prints the same text. It returns the text.
+func roundTrip(t *testing.T, name string, module *ModuleDefinition) string {
+	t.Helper()
+	var printed bytes.Buffer
+	if err := Fprint(&printed, module); nil != err {
+		t.Fatal(err)
+	}
+	set, err := ParseBytes(name, printed.Bytes())
+	if nil != err {
+		t.Fatalf("%s: %v\n%s", name, err, printed.String())
+	}
+	back := set.Modules[0]
+	var again bytes.Buffer
+	if err := Fprint(&again, back); nil != err {
+		t.Fatal(err)
+	}
+	if again.String() != printed.String() {
+		t.Errorf("%s: printed\n%s\nthen\n%s", name, printed.String(), again.String())
+	}
+	clearPositions(reflect.ValueOf(module))
+	clearPositions(reflect.ValueOf(back))
+	if !reflect.DeepEqual(module, back) {
+		t.Errorf("%s: the module read back differs:\n%s", name, printed.String())
+	}
+	return printed.String()
+}
+
+func TestFprintSamples(t *testing.T) {
+	files, err := filepath.Glob("Samples/*.asn1")
+	if nil != err {
+		t.Fatal(err)
+	}
+	more, err := filepath.Glob("Samples/012/*.asn1")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, filename := range append(files, more...) {
+		set, err := Parse(filename)
+		if nil != err {
+			t.Fatal(err)
+		}
+		// Read alone, a module does not know the classes it imports from
+		// another of its file.
+		if len(set.Modules) == 1 {
+			roundTrip(t, filename, set.Modules[0])
+		}
+	}
+}
+
+func TestFprint(t *testing.T) {
+	set, err := ParseBytes("m.asn", []byte(`M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+V ::= INTEGER -- after V
+Checked ::= OCTET STRING (CONSTRAINED BY { -- checked -- })
+Signed ::= BIT STRING (CONSTRAINED BY {V /* signature
+of */})
+Par {CLS: S, INTEGER: bound} ::= SEQUENCE (SIZE (1..bound)) OF CLS.&id ({S}{@id})
+Use ::= Par {CLS, {Set1}, 10}
+END`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	printed := roundTrip(t, "m.asn", set.Modules[0])
+	for _, want := range []string{
+		"V       ::= INTEGER -- after V\n",
+		"(CONSTRAINED BY { -- checked -- })\n",
+		"(CONSTRAINED BY { V /* signature\nof */ })\n",
+		"Par { CLS: S, INTEGER: bound } ::=",
+		"({ S }{ @id })",
+		"Par { CLS, { Set1 }, 10 }\n",
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("no %q in\n%s", want, printed)
+		}
+	}
+}
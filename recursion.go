@@ -0,0 +1,166 @@
+package asn1c_go
+
+// recursion describes the recursive types of a module: the strongly
+// connected components of the graph of type assignments that contain a
+// cycle, the references closing one, and of those the components or
+// alternatives reached without passing through a SEQUENCE OF or SET OF,
+// whose values would otherwise hold themselves. The generated Go types need
+// none of it, as OPTIONAL components and alternatives are held through
+// pointers and lists in slices already, so it is kept to the package.
+type recursion struct {
+	components [][]*TypeAssignment
+	recursive  map[*ReferencedType]bool
+	pointer    map[*ReferencedType]bool
+}
+
+// recursion analyses the recursive types of the module.
+func (m *CheckedModule) recursion() *recursion {
+	return analyzeRecursion([]*CheckedModule{m}, func(ref *ReferencedType) Assignment {
+		return m.assignment(ref)
+	})
+}
+
+type typeEdge struct {
+	ref    *ReferencedType
+	target *TypeAssignment
+	direct bool
+}
+
+func analyzeRecursion(modules []*CheckedModule, target func(*ReferencedType) Assignment) *recursion {
+	var (
+		nodes []*TypeAssignment
+		edges = map[*TypeAssignment][]typeEdge{}
+		index = map[*TypeAssignment]int{}
+	)
+	for _, module := range modules {
+		for _, assignment := range module.Module.Assignments {
+			node, ok := assignment.(*TypeAssignment)
+			if !ok {
+				continue
+			}
+			index[node] = len(nodes)
+			nodes = append(nodes, node)
+			walkReferences(node.Type, false, false, func(ref *ReferencedType, direct bool) {
+				if next, ok := target(ref).(*TypeAssignment); ok {
+					edges[node] = append(edges[node], typeEdge{ref: ref, target: next, direct: direct})
+				}
+			})
+		}
+	}
+	result := &recursion{
+		recursive: map[*ReferencedType]bool{},
+		pointer:   map[*ReferencedType]bool{},
+	}
+	component := map[*TypeAssignment]int{}
+	sccs := stronglyConnected(len(nodes), func(i int) []int {
+		var next []int
+		for _, edge := range edges[nodes[i]] {
+			if j, ok := index[edge.target]; ok {
+				next = append(next, j)
+			}
+		}
+		return next
+	})
+	for i, indices := range sccs {
+		var (
+			scc    []*TypeAssignment
+			cyclic = len(indices) > 1
+		)
+		for _, j := range indices {
+			node := nodes[j]
+			scc = append(scc, node)
+			component[node] = i
+			for _, edge := range edges[node] {
+				cyclic = cyclic || edge.target == node
+			}
+		}
+		if !cyclic {
+			continue
+		}
+		result.components = append(result.components, scc)
+		for _, node := range scc {
+			for _, edge := range edges[node] {
+				if target, ok := component[edge.target]; !ok || target != i {
+					continue
+				}
+				result.recursive[edge.ref] = true
+				if edge.direct {
+					result.pointer[edge.ref] = true
+				}
+			}
+		}
+	}
+	return result
+}
+
+// walkReferences calls f with the type references t is built of, telling
+// whether each is a component reached without passing through a list.
+// Constraints are not walked, as they do not make up values of t.
+func walkReferences(t Type, component, list bool, f func(*ReferencedType, bool)) {
+	switch t := t.(type) {
+	case *ReferencedType:
+		f(t, component && !list)
+	case *SequenceType, *SetType, *ChoiceType:
+		for _, c := range components(componentList(t)) {
+			walkReferences(c.Type, true, list, f)
+		}
+	case *SequenceOfType:
+		walkReferences(t.Element, component, true, f)
+	case *SetOfType:
+		walkReferences(t.Element, component, true, f)
+	}
+}
+
+// stronglyConnected returns the strongly connected components of a graph
+// of n nodes, each after those it has edges into, by Tarjan's algorithm.
+func stronglyConnected(n int, edges func(int) []int) [][]int {
+	var (
+		index   = make([]int, n)
+		low     = make([]int, n)
+		stacked = make([]bool, n)
+		count   = 0
+		stack   []int
+		result  [][]int
+		visit   func(int)
+	)
+	for node := range index {
+		index[node] = -1
+	}
+	visit = func(node int) {
+		index[node] = count
+		low[node] = count
+		count++
+		stack = append(stack, node)
+		stacked[node] = true
+		for _, next := range edges(node) {
+			if index[next] < 0 {
+				visit(next)
+				if low[next] < low[node] {
+					low[node] = low[next]
+				}
+			} else if stacked[next] && index[next] < low[node] {
+				low[node] = index[next]
+			}
+		}
+		if low[node] != index[node] {
+			return
+		}
+		var scc []int
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			stacked[top] = false
+			scc = append(scc, top)
+			if top == node {
+				break
+			}
+		}
+		result = append(result, scc)
+	}
+	for node := range index {
+		if index[node] < 0 {
+			visit(node)
+		}
+	}
+	return result
+}
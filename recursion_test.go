@@ -0,0 +1,102 @@
+package asn1c_go
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRecursion(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		components []string
+		references []string
+	}{
+		{
+			name: "direct",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Filter ::= CHOICE { and SET OF Filter, not Filter, present AttributeType }
+AttributeType ::= OCTET STRING
+END`,
+			components: []string{"Filter"},
+			references: []string{"2:32 Filter", "2:44 Filter pointer"},
+		},
+		{
+			name: "mutual",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Ping ::= SEQUENCE { pong Pong OPTIONAL }
+Pong ::= SEQUENCE { ping Ping OPTIONAL, n INTEGER }
+Plain ::= SEQUENCE { ping Ping }
+END`,
+			components: []string{"Ping Pong"},
+			references: []string{"2:26 Pong pointer", "3:26 Ping pointer"},
+		},
+		{
+			name: "list only",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Tree ::= SEQUENCE { children SEQUENCE OF Tree }
+END`,
+			components: []string{"Tree"},
+			references: []string{"2:42 Tree"},
+		},
+		{
+			name: "through an alias",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Alias ::= Node
+Node ::= SEQUENCE { next Alias OPTIONAL }
+END`,
+			components: []string{"Alias Node"},
+			references: []string{"2:11 Node", "3:26 Alias pointer"},
+		},
+		{
+			name: "none",
+			source: `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+A ::= SEQUENCE { b B, c SEQUENCE OF B }
+B ::= INTEGER
+END`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set, err := ParseBytes("m.asn", []byte(test.source))
+			if nil != err {
+				t.Fatal(err)
+			}
+			module, err := Check(set.Modules[0])
+			if nil != err {
+				t.Fatal(err)
+			}
+			var (
+				recursion  = module.recursion()
+				components []string
+				references []string
+			)
+			for _, component := range recursion.components {
+				var names []string
+				for _, a := range component {
+					names = append(names, a.Name)
+				}
+				sort.Strings(names)
+				components = append(components, strings.Join(names, " "))
+			}
+			sort.Strings(components)
+			for ref := range recursion.recursive {
+				text := fmt.Sprintf("%d:%d %s", ref.Position.Line, ref.Position.Column, ref.Name)
+				if recursion.pointer[ref] {
+					text += " pointer"
+				}
+				references = append(references, text)
+			}
+			sort.Strings(references)
+			if !reflect.DeepEqual(components, test.components) {
+				t.Errorf("got components %q, want %q", components, test.components)
+			}
+			if !reflect.DeepEqual(references, test.references) {
+				t.Errorf("got references %q, want %q", references, test.references)
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package asn1c_go
+
+import (
+	"math/big"
+	"sort"
+)
+
+// applyTags tags the components of the types met while checking types. When
+// the module tags automatically and no root component of a list carries a
+// tag, X.680 numbers the components from zero, root components first and
+// extension additions after them, counting those COMPONENTS OF brings in.
+// Otherwise components keep the tags written on them.
+func (c *checker) applyTags() {
+	for _, t := range c.structured {
+		list := componentList(t)
+		if c.module.TagDefault != AutomaticTags || c.tagged(list) {
+			c.resolveTags(list)
+			continue
+		}
+		var (
+			number int64
+			root   = append(append([]*ComponentType(nil), list.Components...), list.Trailing...)
+		)
+		for _, components := range [][]*ComponentType{root, additions(list)} {
+			for _, component := range components {
+				if component.ComponentsOf {
+					number += int64(c.rootCount(component.Type, 0))
+					continue
+				}
+				component.Tag = &Tag{
+					Position: component.Position,
+					Class:    TagClassContext,
+					Number:   &IntegerValue{Position: component.Position, Value: big.NewInt(number)},
+					Mode:     TagModeImplicit,
+				}
+				if c.explicit(component.Type) {
+					component.Tag.Mode = TagModeExplicit
+				}
+				number++
+			}
+		}
+	}
+}
+
+// resolveTags gives the components of list written with a tag that tag,
+// its mode resolved against the tag default of the module.
+func (c *checker) resolveTags(list *ComponentList) {
+	for _, components := range [][]*ComponentType{list.RootComponents(), additions(list)} {
+		for _, component := range components {
+			tag := component.Type.Base().Tag
+			if component.ComponentsOf || nil == tag {
+				continue
+			}
+			resolved := *tag
+			if resolved.Mode == TagModeDefault {
+				resolved.Mode = TagModeImplicit
+				if c.module.TagDefault == ExplicitTags || c.explicitBeneath(component.Type) {
+					resolved.Mode = TagModeExplicit
+				}
+			}
+			component.Tag = &resolved
+		}
+	}
+}
+
+func additions(list *ComponentList) []*ComponentType {
+	var all []*ComponentType
+	for _, addition := range list.Additions {
+		all = append(all, addition.Components...)
+	}
+	return all
+}
+
+// tagged reports whether a root component of list, not counting those of
+// COMPONENTS OF, is tagged.
+func (c *checker) tagged(list *ComponentList) bool {
+	for _, components := range [][]*ComponentType{list.Components, list.Trailing} {
+		for _, component := range components {
+			if !component.ComponentsOf && nil != component.Type.Base().Tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rootCount counts the root components COMPONENTS OF t brings in.
+func (c *checker) rootCount(t Type, depth int) int {
+	var list *ComponentList
+	switch t := c.governor(t).(type) {
+	case *SequenceType:
+		list = &t.ComponentList
+	case *SetType:
+		list = &t.ComponentList
+	}
+	if nil == list || depth > len(c.module.Assignments) {
+		return 0
+	}
+	count := 0
+	for _, components := range [][]*ComponentType{list.Components, list.Trailing} {
+		for _, component := range components {
+			if component.ComponentsOf {
+				count += c.rootCount(component.Type, depth+1)
+			} else {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// explicit reports whether an automatic tag on t must be explicit, which is
+// when t is an untagged CHOICE, an open type or a dummy reference. Types the
+// module cannot see into get an implicit tag.
+func (c *checker) explicit(t Type) bool {
+	return nil == t.Base().Tag && c.explicitBeneath(t)
+}
+
+// explicitBeneath is explicit for the tag written on t, looking at the type
+// beneath it.
+func (c *checker) explicitBeneath(t Type) bool {
+	for i := 0; i <= len(c.module.Assignments); i++ {
+		if i > 0 && nil != t.Base().Tag {
+			return false
+		}
+		switch u := t.(type) {
+		case *ChoiceType, *AnyType:
+			return true
+		case *ObjectClassFieldType:
+			class := c.objectClass(u.Class)
+			if nil == class || len(u.Field) != 1 {
+				return false
+			}
+			field := class.Field(u.Field[0])
+			return nil != field && field.Kind == TypeField
+		case *ReferencedType:
+			reference := c.reference(u)
+			if nil == reference {
+				return false
+			}
+			if nil != reference.Parameter {
+				return nil == reference.Parameter.Governor && len(reference.Parameter.Class) == 0
+			}
+			next := c.follow(u, reference)
+			if nil == next {
+				return false
+			}
+			t = next
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func (c *checker) reference(n Node) *Reference {
+	if nil != c.program {
+		return c.program.Reference(n)
+	}
+	return c.checked.References[n]
+}
+
+// follow returns the type ref stands for, or nil when it leads where the
+// checker cannot see.
+func (c *checker) follow(ref *ReferencedType, reference *Reference) Type {
+	switch a := c.target(reference, ref.Name).(type) {
+	case *TypeAssignment:
+		return a.Type
+	case *ValueSetAssignment:
+		return a.Type
+	}
+	return nil
+}
+
+// target returns the assignment reference stands for, looking name up in
+// the imported module when modules are linked.
+func (c *checker) target(reference *Reference, name string) Assignment {
+	if nil != reference.Import && nil != c.program {
+		if definition := c.program.Imported(reference.Import, name); nil != definition {
+			return definition.Assignment
+		}
+	}
+	return reference.Assignment
+}
+
+// universalTags are the tag numbers X.680 gives the builtin types.
+var universalTags = map[string]int64{
+	Boolean:          1,
+	BitString:        3,
+	OctetString:      4,
+	Null:             5,
+	ObjectIdentifier: 6,
+	ObjectDescriptor: 7,
+	Externel:         8,
+	Real:             9,
+	EmbeddedPDV:      11,
+	UTF8String:       12,
+	RelativeOID:      13,
+	Time:             14,
+	NumericString:    18,
+	PrintableString:  19,
+	TeletexString:    20,
+	T61String:        20,
+	VideotexString:   21,
+	IA5String:        22,
+	UTCTime:          23,
+	GeneralizedTime:  24,
+	GraphicString:    25,
+	VisibleString:    26,
+	ISO646String:     26,
+	GeneralString:    27,
+	UniversalString:  28,
+	CharacterString:  29,
+	BMPString:        30,
+	Date:             31,
+	TimeOfDay:        32,
+	DateTime:         33,
+	Duration:         34,
+	OIDIRI:           35,
+	RelativeOIDIRI:   36,
+}
+
+// tagKey is a tag reduced to what canonical order compares.
+type tagKey struct {
+	class  TagClass
+	number int64
+}
+
+// less reports whether k comes before other in canonical order, which
+// takes universal tags first, then application, context-specific and
+// private ones, each by number.
+func (k tagKey) less(other tagKey) bool {
+	rank := func(class TagClass) int {
+		switch class {
+		case TagClassUniversal:
+			return 0
+		case TagClassApplication:
+			return 1
+		case TagClassContext:
+			return 2
+		}
+		return 3
+	}
+	if k.class != other.class {
+		return rank(k.class) < rank(other.class)
+	}
+	return k.number < other.number
+}
+
+// orderComponents sets the canonical order of the root components of each
+// SET and CHOICE. Lists with a component whose tag cannot be told, such as
+// an open type, are left unordered.
+func (c *checker) orderComponents() {
+	for _, t := range c.structured {
+		if _, ok := t.(*SequenceType); ok {
+			continue
+		}
+		list := componentList(t)
+		var (
+			root = append(append([]*ComponentType(nil), list.Components...), list.Trailing...)
+			keys = map[*ComponentType]tagKey{}
+			ok   = true
+		)
+		for _, component := range root {
+			keys[component], ok = c.componentTag(component, 0)
+			if !ok {
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		sort.SliceStable(root, func(i, j int) bool {
+			return keys[root[i]].less(keys[root[j]])
+		})
+		list.Canonical = root
+	}
+}
+
+func (c *checker) componentTag(component *ComponentType, depth int) (tagKey, bool) {
+	if nil != component.Tag {
+		return c.tagKey(component.Tag)
+	}
+	return c.typeTag(component.Type, depth)
+}
+
+func (c *checker) tagKey(tag *Tag) (tagKey, bool) {
+	number, ok := c.valueOf(tag.Number).(*IntegerValue)
+	if !ok || !number.Value.IsInt64() {
+		return tagKey{}, false
+	}
+	return tagKey{class: tag.Class, number: number.Value.Int64()}, true
+}
+
+// typeTag returns the outermost tag of t. That of an untagged CHOICE is the
+// smallest tag of its root alternatives.
+func (c *checker) typeTag(t Type, depth int) (tagKey, bool) {
+	for i := 0; depth <= len(c.module.Assignments) && i <= len(c.module.Assignments); i++ {
+		if tag := t.Base().Tag; nil != tag {
+			return c.tagKey(tag)
+		}
+		switch u := t.(type) {
+		case *BuiltinType:
+			number, ok := universalTags[u.Name]
+			return tagKey{class: TagClassUniversal, number: number}, ok
+		case *IntegerType:
+			return tagKey{class: TagClassUniversal, number: 2}, true
+		case *EnumeratedType:
+			return tagKey{class: TagClassUniversal, number: 10}, true
+		case *BitStringType:
+			return tagKey{class: TagClassUniversal, number: 3}, true
+		case *SequenceType, *SequenceOfType:
+			return tagKey{class: TagClassUniversal, number: 16}, true
+		case *SetType, *SetOfType:
+			return tagKey{class: TagClassUniversal, number: 17}, true
+		case *ChoiceType:
+			var (
+				smallest tagKey
+				found    bool
+			)
+			for _, components := range [][]*ComponentType{u.Components, u.Trailing} {
+				for _, component := range components {
+					key, ok := c.componentTag(component, depth+1)
+					if !ok {
+						return tagKey{}, false
+					}
+					if !found || key.less(smallest) {
+						smallest, found = key, true
+					}
+				}
+			}
+			return smallest, found
+		case *SelectionType:
+			choice, ok := c.governor(u.Type).(*ChoiceType)
+			if !ok {
+				return tagKey{}, false
+			}
+			for _, component := range components(&choice.ComponentList) {
+				if component.Name == u.Alternative {
+					return c.componentTag(component, depth+1)
+				}
+			}
+			return tagKey{}, false
+		case *ObjectClassFieldType:
+			class := c.objectClass(u.Class)
+			if nil == class || len(u.Field) != 1 {
+				return tagKey{}, false
+			}
+			field := class.Field(u.Field[0])
+			if nil == field || nil == field.Type {
+				return tagKey{}, false
+			}
+			t = field.Type
+		case *ReferencedType:
+			reference := c.reference(u)
+			if nil == reference {
+				return tagKey{}, false
+			}
+			next := c.follow(u, reference)
+			if nil == next {
+				return tagKey{}, false
+			}
+			t = next
+		default:
+			return tagKey{}, false
+		}
+	}
+	return tagKey{}, false
+}
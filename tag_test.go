@@ -0,0 +1,136 @@
+package asn1c_go
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// componentTags lists the components of the SEQUENCE, SET or CHOICE t as
+// written, each with the tag Check gives it.
+func componentTags(t Type) []string {
+	var texts []string
+	for _, component := range components(componentList(t)) {
+		switch tag := component.Tag; {
+		case component.ComponentsOf:
+		case nil == tag:
+			texts = append(texts, component.Name)
+		default:
+			class := tag.Class.String()
+			if len(class) != 0 {
+				class += " "
+			}
+			texts = append(texts, fmt.Sprintf("%s [%s%v] %s", component.Name, class, valueText(tag.Number), tag.Mode))
+		}
+	}
+	return texts
+}
+
+func valueText(v Value) string {
+	if v, ok := v.(*IntegerValue); ok {
+		return v.Value.String()
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// checkedTypes checks source, a module, and returns its types by name.
+func checkedTypes(t *testing.T, source string) map[string]Type {
+	t.Helper()
+	set, err := ParseBytes("m.asn", []byte(source))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err := Check(set.Modules[0]); nil != err {
+		t.Fatal(err)
+	}
+	types := map[string]Type{}
+	for _, assignment := range set.Modules[0].Assignments {
+		if a, ok := assignment.(*TypeAssignment); ok {
+			types[a.Name] = a.Type
+		}
+	}
+	return types
+}
+
+func TestAutomaticTags(t *testing.T) {
+	types := checkedTypes(t, `M DEFINITIONS AUTOMATIC TAGS ::= BEGIN
+Plain ::= SEQUENCE { a INTEGER, ..., b BOOLEAN, ..., c NULL }
+Tagged ::= SEQUENCE { a [5] INTEGER, b BOOLEAN, c [APPLICATION 2] EXPLICIT NULL }
+TaggedAddition ::= SEQUENCE { a INTEGER, ..., b [5] BOOLEAN }
+Nested ::= CHOICE { x Inner, y CHOICE { p NULL, q NULL }, z Alias, w INTEGER, t TaggedInner }
+Inner ::= CHOICE { p INTEGER, q BOOLEAN }
+Alias ::= Inner
+TaggedInner ::= [7] Inner
+Base ::= SEQUENCE { k INTEGER, l INTEGER }
+Included ::= SET { COMPONENTS OF Base, m BOOLEAN, ..., n NULL }
+END`)
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"Plain", []string{"a [0] IMPLICIT", "b [2] IMPLICIT", "c [1] IMPLICIT"}},
+		{"Tagged", []string{"a [5] IMPLICIT", "b", "c [APPLICATION 2] EXPLICIT"}},
+		{"TaggedAddition", []string{"a [0] IMPLICIT", "b [1] IMPLICIT"}},
+		{"Nested", []string{"x [0] EXPLICIT", "y [1] EXPLICIT", "z [2] EXPLICIT", "w [3] IMPLICIT", "t [4] IMPLICIT"}},
+		{"Inner", []string{"p [0] IMPLICIT", "q [1] IMPLICIT"}},
+		{"Included", []string{"m [2] IMPLICIT", "n [3] IMPLICIT"}},
+	}
+	for _, test := range tests {
+		if got := componentTags(types[test.name]); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+	nested := types["Nested"].(*ChoiceType).Components[1].Type
+	if got, want := strings.Join(componentTags(nested), ", "), "p [0] IMPLICIT, q [1] IMPLICIT"; got != want {
+		t.Errorf("Nested.y: got %q, want %q", got, want)
+	}
+}
+
+func TestTagDefaults(t *testing.T) {
+	for _, test := range []struct {
+		tagDefault string
+		want       []string
+	}{
+		{"", []string{"a", "b [0] EXPLICIT", "c [1] IMPLICIT", "d [2] EXPLICIT"}},
+		{"IMPLICIT TAGS", []string{"a", "b [0] IMPLICIT", "c [1] IMPLICIT", "d [2] EXPLICIT"}},
+		{"EXPLICIT TAGS", []string{"a", "b [0] EXPLICIT", "c [1] IMPLICIT", "d [2] EXPLICIT"}},
+	} {
+		types := checkedTypes(t, `M DEFINITIONS `+test.tagDefault+` ::= BEGIN
+S ::= SEQUENCE { a INTEGER, b [0] INTEGER, c [1] IMPLICIT INTEGER, d [2] CHOICE { x NULL } }
+END`)
+		if got := componentTags(types["S"]); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %q, want %q", test.tagDefault, got, test.want)
+		}
+	}
+}
+
+func TestCanonicalOrder(t *testing.T) {
+	types := checkedTypes(t, `M DEFINITIONS ::= BEGIN
+Shuffled ::= CHOICE { c [2] INTEGER, a [0] INTEGER, p [PRIVATE 1] NULL, b [1] INTEGER, u [APPLICATION 9] BOOLEAN, ..., z [9] NULL }
+Universal ::= SET { s IA5String, i INTEGER, b BOOLEAN, nested Inner, o OCTET STRING }
+Inner ::= CHOICE { x [5] NULL, y [3] NULL }
+Referenced ::= CHOICE { a [four] INTEGER, b [1] INTEGER }
+four INTEGER ::= 4
+Sequence ::= SEQUENCE { b [1] INTEGER, a [0] INTEGER }
+END`)
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"Shuffled", []string{"u", "a", "b", "c", "p"}},
+		{"Universal", []string{"b", "i", "o", "s", "nested"}},
+		{"Inner", []string{"y", "x"}},
+		{"Referenced", []string{"b", "a"}},
+		{"Sequence", nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, component := range componentList(types[test.name]).Canonical {
+			got = append(got, component.Name)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package asn1c_go
+
+// Underlying follows t through type references and selection types to the
+// builtin type it stands for, collecting the constraints and tags met on
+// the way in the order they apply: those of the builtin type first, those
+// written on t last. The set of a value set assignment is collected as a
+// constraint. It fails on a reference it cannot follow within the module,
+// such as an import, and on one leading back to itself.
+func (m *CheckedModule) Underlying(t Type) (Type, []*Constraint, []*Tag, error) {
+	return underlying(t, func(ref *ReferencedType) (*Reference, Assignment) {
+		reference := m.References[ref]
+		if nil == reference {
+			return nil, nil
+		}
+		return reference, reference.Assignment
+	}, map[Type]bool{})
+}
+
+// Underlying is that of CheckedModule, following references through
+// imports.
+func (p *Program) Underlying(t Type) (Type, []*Constraint, []*Tag, error) {
+	return underlying(t, func(ref *ReferencedType) (*Reference, Assignment) {
+		return p.Reference(ref), p.assignment(ref)
+	}, map[Type]bool{})
+}
+
+// underlying is Underlying, seen holding the types met so far.
+func underlying(t Type, follow func(*ReferencedType) (*Reference, Assignment), seen map[Type]bool) (Type, []*Constraint, []*Tag, error) {
+	var (
+		constraints []*Constraint
+		tags        []*Tag
+	)
+	// The walk meets the outermost first, so both are collected backwards.
+	for {
+		if ref, ok := t.(*ReferencedType); ok && seen[t] {
+			return nil, nil, nil, &Error{Position: ref.Position, Message: ref.Name + " is defined in terms of itself"}
+		}
+		seen[t] = true
+		base := t.Base()
+		for i := len(base.Constraints) - 1; i >= 0; i-- {
+			constraints = append(constraints, base.Constraints[i])
+		}
+		if nil != base.Tag {
+			tags = append(tags, base.Tag)
+		}
+		switch u := t.(type) {
+		case *ReferencedType:
+			reference, assignment := follow(u)
+			switch a := assignment.(type) {
+			case *TypeAssignment:
+				t = a.Type
+			case *ValueSetAssignment:
+				constraints = append(constraints, &Constraint{Position: a.Set.Position, ElementSetSpecs: a.Set.ElementSetSpecs})
+				t = a.Type
+			default:
+				return nil, nil, nil, unfollowed(u, reference, assignment)
+			}
+		case *SelectionType:
+			selected, _, _, err := underlying(u.Type, follow, seen)
+			if nil != err {
+				return nil, nil, nil, err
+			}
+			choice, ok := selected.(*ChoiceType)
+			if !ok {
+				return nil, nil, nil, &Error{Position: u.Position, Message: "selection from a type that is not a CHOICE"}
+			}
+			next := componentType(&choice.ComponentList, u.Alternative)
+			if nil == next {
+				return nil, nil, nil, &Error{Position: u.Position, Message: "no alternative " + u.Alternative}
+			}
+			t = next
+		default:
+			for i, j := 0, len(constraints)-1; i < j; i, j = i+1, j-1 {
+				constraints[i], constraints[j] = constraints[j], constraints[i]
+			}
+			for i, j := 0, len(tags)-1; i < j; i, j = i+1, j-1 {
+				tags[i], tags[j] = tags[j], tags[i]
+			}
+			return t, constraints, tags, nil
+		}
+	}
+}
+
+// unfollowed tells why ref could not be followed.
+func unfollowed(ref *ReferencedType, reference *Reference, assignment Assignment) error {
+	var message string
+	switch {
+	case nil == reference:
+		message = "undefined reference " + ref.Name
+	case nil != reference.Parameter:
+		message = ref.Name + " is a dummy reference"
+	case nil != reference.Import && nil == assignment:
+		message = ref.Name + " is imported from " + reference.Import.Module + ", which is not linked"
+	default:
+		message = ref.Name + " is not a type"
+	}
+	return &Error{Position: ref.Position, Message: message}
+}